@@ -1,18 +1,24 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"time"
+	_ "time/tzdata" // contribution timezones must resolve on minimal images
 
 	"github.com/Gerard-007/ajor_app/internal/repository"
 	"github.com/Gerard-007/ajor_app/internal/routes"
+	"github.com/Gerard-007/ajor_app/internal/services"
+	"github.com/Gerard-007/ajor_app/pkg/fieldcrypt"
 	"github.com/Gerard-007/ajor_app/pkg/jobs"
+	"github.com/Gerard-007/ajor_app/pkg/notify"
 	"github.com/Gerard-007/ajor_app/pkg/payment"
+	"github.com/Gerard-007/ajor_app/pkg/ratelimit"
+	"github.com/Gerard-007/ajor_app/pkg/sms"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
-	"github.com/robfig/cron/v3"
 )
 
 func main() {
@@ -25,16 +31,58 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// Fail at startup rather than on the first request if the field
+	// encryption keys are misconfigured
+	fieldcrypt.Default()
+	if err := repository.EnsureUserIndexes(context.Background(), db); err != nil {
+		log.Printf("Failed to create user indexes: %v", err)
+	}
+	// These include the unique index that makes payment references
+	// idempotent and the store of used request signatures
+	if err := repository.EnsureOrganisationIndexes(context.Background(), db); err != nil {
+		log.Fatal("Failed to create organisation indexes:", err)
+	}
+	if err := repository.EnsureNotificationIndexes(context.Background(), db); err != nil {
+		log.Printf("Failed to create notification indexes: %v", err)
+	}
+	if err := repository.EnsureNotificationDeliveryIndexes(context.Background(), db); err != nil {
+		log.Printf("Failed to create notification delivery indexes: %v", err)
+	}
+	if err := repository.EnsureReminderIndexes(context.Background(), db); err != nil {
+		log.Printf("Failed to create reminder indexes: %v", err)
+	}
+	if err := repository.EnsureJobIndexes(context.Background(), db); err != nil {
+		log.Printf("Failed to create job indexes: %v", err)
+	}
+	if err := repository.EnsureOutboxIndexes(context.Background(), db); err != nil {
+		log.Printf("Failed to create outbox indexes: %v", err)
+	}
+	if err := repository.EnsureStreamTicketIndexes(context.Background(), db); err != nil {
+		log.Printf("Failed to create stream ticket indexes: %v", err)
+	}
+	if err := services.LoadSigningKeys(context.Background(), db); err != nil {
+		log.Fatal("Failed to load token signing keys:", err)
+	}
+
 	pg := payment.NewFlutterwaveGateway()
+	smsProvider := sms.NewProviderFromEnv()
+	senders, err := notify.NewSendersFromEnv(smsProvider)
+	if err != nil {
+		log.Fatal("Failed to set up notification channels:", err)
+	}
+	services.SetNotificationSenders(senders)
+	limiter := ratelimit.NewStoreFromEnv(db)
 
-	server := gin.Default()
+	// Event stream URLs may carry a stream ticket, so they are not logged
+	server := gin.New()
+	server.Use(gin.LoggerWithConfig(gin.LoggerConfig{SkipPaths: []string{"/events"}}), gin.Recovery())
 
 	// CORS middleware configuration
 	server.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Authorization", "Content-Type"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Authorization", "Content-Type", "X-Device-Name", "X-Transaction-PIN", "X-API-Key", "X-Signature", "X-Timestamp", "Last-Event-ID"},
+		ExposeHeaders:    []string{"Content-Length", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -44,20 +92,16 @@ func main() {
 		log.Fatal("Failed to set trusted proxies:", err)
 	}
 
-	routes.InitRoutes(server, db, pg)
-
-	// Start cron job
-	c := cron.New()
-	_, err = c.AddFunc("0 0 * * *", func() { // Runs daily at midnight
-		if err := jobs.ProcessCollections(db); err != nil {
-			log.Printf("Error processing collections: %v", err)
-		}
-	})
-	if err != nil {
+	// Background jobs; each scheduled run happens on one instance only
+	scheduler := jobs.NewScheduler(db)
+	if err := jobs.Register(scheduler, pg); err != nil {
 		log.Fatal(err)
 	}
-	c.Start()
-	defer c.Stop()
+
+	routes.InitRoutes(server, db, pg, smsProvider, limiter, scheduler)
+
+	scheduler.Start()
+	defer scheduler.Stop()
 
 	port := os.Getenv("PORT")
 	if port == "" {
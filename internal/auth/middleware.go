@@ -2,10 +2,14 @@ package auth
 
 import (
 	"net/http"
+	"strings"
 
+	"github.com/Gerard-007/ajor_app/internal/models"
 	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/internal/services"
 	"github.com/Gerard-007/ajor_app/pkg/utils"
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -26,30 +30,218 @@ func AuthMiddleware(db *mongo.Database) gin.HandlerFunc {
 			token = token[7:]
 		}
 
-		// Check if token is blacklisted
-		blacklistCollection := db.Collection("blacklisted_tokens")
-		isBlacklisted, err := repository.IsTokenBlacklisted(blacklistCollection, token)
+		claims, err := utils.ValidateToken(token)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate token"})
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "Invalid or expired token",
+			})
+			c.Abort()
 			return
 		}
-		if isBlacklisted {
+
+		// Check the token's session has not been revoked
+		userID, err := primitive.ObjectIDFromHex(claims.UserID)
+		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token is invalid"})
 			return
 		}
-
-		claims, err := utils.ValidateToken(token)
+		sessionID, err := primitive.ObjectIDFromHex(claims.SessionID)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid or expired token",
-			})
-			c.Abort()
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token is invalid"})
+			return
+		}
+		if err := services.ValidateSession(c.Request.Context(), db, sessionID, userID, c.ClientIP()); err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Session is no longer valid"})
 			return
 		}
 
 		// If valid, proceed to the next handler
 		c.Set("userID", claims.UserID)
-		c.Set("isAdmin", claims.IsAdmin)
+		c.Set("sessionID", claims.SessionID)
+		c.Set("tokenExpiresAt", claims.ExpiresAt.Time)
+		c.Next()
+	}
+}
+
+// StreamAuthMiddleware authenticates the event stream. Clients that cannot
+// set headers on it, such as browsers opening an EventSource or WebSocket,
+// pass a ticket from POST /events/tickets as the ticket query parameter
+// instead of an access token, which would end up in access logs. Everyone
+// else is authenticated as by AuthMiddleware.
+func StreamAuthMiddleware(db *mongo.Database) gin.HandlerFunc {
+	authenticate := AuthMiddleware(db)
+	return func(c *gin.Context) {
+		secret := c.Query("ticket")
+		if secret == "" || c.GetHeader("Authorization") != "" {
+			authenticate(c)
+			return
+		}
+		ticket, err := services.RedeemStreamTicket(c.Request.Context(), db, secret, c.ClientIP())
+		if err != nil {
+			if strings.Contains(err.Error(), "ticket") || strings.Contains(err.Error(), "session") {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired stream ticket"})
+			} else {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check stream ticket"})
+			}
+			return
+		}
+		c.Set("userID", ticket.UserID.Hex())
+		c.Set("sessionID", ticket.SessionID.Hex())
+		c.Set("tokenExpiresAt", ticket.TokenExpiresAt)
+		c.Next()
+	}
+}
+
+// RequireVerified blocks users who have not confirmed their phone number.
+// It must run after AuthMiddleware.
+func RequireVerified(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDStr, exists := c.Get("userID")
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+			return
+		}
+		userID, err := primitive.ObjectIDFromHex(userIDStr.(string))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token is invalid"})
+			return
+		}
+
+		user, err := repository.GetUserByID(db.Collection("users"), userID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
+		}
+		if !user.Verified {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Verify your phone number to continue"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireTransactionPIN checks the X-Transaction-PIN header before a request
+// that moves money out of a user's wallet. It must run after AuthMiddleware.
+func RequireTransactionPIN(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDStr, exists := c.Get("userID")
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+			return
+		}
+		userID, err := primitive.ObjectIDFromHex(userIDStr.(string))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token is invalid"})
+			return
+		}
+
+		pin := c.GetHeader("X-Transaction-PIN")
+		if pin == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Transaction PIN is required"})
+			return
+		}
+		if err := services.VerifyTransactionPIN(c.Request.Context(), db, userID, pin); err != nil {
+			switch {
+			case strings.Contains(err.Error(), "locked"):
+				c.AbortWithStatusJSON(http.StatusLocked, gin.H{"error": err.Error()})
+			case strings.Contains(err.Error(), "not set"), strings.Contains(err.Error(), "incorrect"):
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			default:
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify transaction PIN"})
+			}
+			return
+		}
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// RequireMFA blocks users whom the two-factor policy covers until they have
+// enabled it. It must run after AuthMiddleware.
+func RequireMFA(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userIDStr, exists := c.Get("userID")
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+			return
+		}
+		userID, err := primitive.ObjectIDFromHex(userIDStr.(string))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token is invalid"})
+			return
+		}
+
+		user, err := repository.GetUserByID(db.Collection("users"), userID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
+		}
+		required, err := services.MFARequired(c.Request.Context(), db, user)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check two-factor policy"})
+			return
+		}
+		if required {
+			enabled, err := services.MFAEnabled(c.Request.Context(), db, userID)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check two-factor policy"})
+				return
+			}
+			if !enabled {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Two-factor authentication is required for your account; set it up at /mfa/setup"})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// RequireAdmin admits only users with a platform administration role. Each
+// route in the admin group also checks its own permission.
+func RequireAdmin(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := authUser(c, db)
+		if !ok {
+			return
+		}
+		if len(user.EffectiveRoles()) == 0 {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission admits only users whose roles grant the permission.
+func RequirePermission(db *mongo.Database, permission models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := authUser(c, db)
+		if !ok {
+			return
+		}
+		if !user.HasPermission(permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Missing permission " + string(permission)})
+			return
+		}
+		c.Next()
+	}
+}
+
+// authUser loads the signed-in user, aborting the request if that fails.
+func authUser(c *gin.Context, db *mongo.Database) (*models.User, bool) {
+	userIDStr, exists := c.Get("userID")
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+		return nil, false
+	}
+	userID, err := primitive.ObjectIDFromHex(userIDStr.(string))
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token is invalid"})
+		return nil, false
+	}
+	user, err := repository.GetUserByID(db.Collection("users"), userID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return nil, false
+	}
+	return user, true
+}
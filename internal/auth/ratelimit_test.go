@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Gerard-007/ajor_app/pkg/ratelimit"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingStore struct{}
+
+func (failingStore) Allow(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("store unavailable")
+}
+
+func TestRateLimitByIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		store          ratelimit.Store
+		requests       int
+		wantStatus     int
+		wantRemaining  string
+		wantRetryAfter string
+	}{
+		{"within the limit", ratelimit.NewMemoryStore(), 2, http.StatusOK, "0", ""},
+		{"over the limit", ratelimit.NewMemoryStore(), 3, http.StatusTooManyRequests, "0", "30"},
+		{"store failure lets requests through", failingStore{}, 3, http.StatusOK, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/login", RateLimitByIP(tt.store, "auth", ratelimit.PerMinute(2)), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			var recorder *httptest.ResponseRecorder
+			for i := 0; i < tt.requests; i++ {
+				recorder = httptest.NewRecorder()
+				request := httptest.NewRequest(http.MethodGet, "/login", nil)
+				request.RemoteAddr = "203.0.113.7:4000"
+				router.ServeHTTP(recorder, request)
+			}
+			assert.Equal(t, tt.wantStatus, recorder.Code)
+			assert.Equal(t, tt.wantRemaining, recorder.Header().Get("X-RateLimit-Remaining"))
+			assert.Equal(t, tt.wantRetryAfter, recorder.Header().Get("Retry-After"))
+		})
+	}
+}
+
+func TestRateLimitByAccountRequiresAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/wallet", RateLimitByAccount(ratelimit.NewMemoryStore(), "api", ratelimit.PerMinute(2)), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/wallet", nil))
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/services"
+	"github.com/Gerard-007/ajor_app/pkg/payment"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type closeAccountRequest struct {
+	Password    string              `json:"password"`
+	BankAccount *models.BankAccount `json:"bank_account"`
+}
+
+func CloseAccountHandler(db *mongo.Database, pg payment.PaymentGateway) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		var req closeAccountRequest
+		if err := c.ShouldBindJSON(&req); err != nil || req.Password == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Password is required"})
+			return
+		}
+
+		closed, err := services.CloseAccount(c.Request.Context(), db, pg, userID, userID, req.Password, req.BankAccount)
+		if err != nil {
+			writeCloseAccountError(c, err)
+			return
+		}
+		if !closed {
+			c.JSON(http.StatusAccepted, gin.H{"message": "Account closure started; it completes once the balance payout settles"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Account closed successfully"})
+	}
+}
+
+// writeCloseAccountError maps CloseAccount errors for both the self-service
+// and the admin route.
+func writeCloseAccountError(c *gin.Context, err error) {
+	switch {
+	case err.Error() == "permission denied":
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can close other users' accounts"})
+	case strings.Contains(err.Error(), "KYC limit"), strings.Contains(err.Error(), "not verified"):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case err.Error() == "user not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case strings.Contains(err.Error(), "incorrect"):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "already closed"),
+		strings.Contains(err.Error(), "already in progress"),
+		strings.HasPrefix(err.Error(), "account still"),
+		err.Error() == "insufficient balance":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "bank account is required"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "failed to pay out"), strings.HasPrefix(err.Error(), "failed to verify"):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to close account"})
+	}
+}
+
+// ExportUserDataHandler returns a ZIP of the caller's data. The archive is
+// built in memory so a failure part way through can still be reported as
+// JSON rather than a truncated download.
+func ExportUserDataHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := services.ExportUserData(c.Request.Context(), db, userID, &buf); err != nil {
+			if err.Error() == "user not found" {
+				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export data"})
+			return
+		}
+		c.Header("Content-Disposition", `attachment; filename="ajor-data-export.zip"`)
+		c.Data(http.StatusOK, "application/zip", buf.Bytes())
+	}
+}
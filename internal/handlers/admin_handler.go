@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func GetRolesHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, models.RolePermissions)
+	}
+}
+
+func AssignRolesHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		var request struct {
+			Roles []models.Role `json:"roles"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+
+		user, err := services.AssignRoles(c.Request.Context(), db, actorID, userID, request.Roles)
+		if err != nil {
+			switch {
+			case err.Error() == "permission denied":
+				c.JSON(http.StatusForbidden, gin.H{"error": "Only super admins can assign roles"})
+			case strings.Contains(err.Error(), "not found"):
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			case strings.Contains(err.Error(), "unknown role"), strings.Contains(err.Error(), "last super admin"):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign roles"})
+			}
+			return
+		}
+		c.JSON(http.StatusOK, user)
+	}
+}
+
+func GetAuditEventsHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		events, err := services.GetAuditEvents(c.Request.Context(), db, actorID, models.AuditEventType(c.Query("type")))
+		if err != nil {
+			if err.Error() == "permission denied" {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can view audit events"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit events"})
+			return
+		}
+		c.JSON(http.StatusOK, events)
+	}
+}
@@ -32,7 +32,9 @@ func ApprovePayoutHandler(db *mongo.Database) gin.HandlerFunc {
 		}
 		err = services.ApprovePayout(c.Request.Context(), db, approvalID, approverID, request.Approve)
 		if err != nil {
-			if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "already processed") {
+			if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "already processed") ||
+				strings.Contains(err.Error(), "KYC limit") || strings.Contains(err.Error(), "not verified") ||
+				strings.Contains(err.Error(), "insufficient balance") {
 				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 				return
 			}
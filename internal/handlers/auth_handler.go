@@ -1,20 +1,24 @@
 package handlers
 
 import (
+	"errors"
 	"log"
+	"math"
 	"net/http"
-	"time"
+	"strconv"
+	"strings"
 
 	"github.com/Gerard-007/ajor_app/internal/models"
-	"github.com/Gerard-007/ajor_app/internal/repository"
 	"github.com/Gerard-007/ajor_app/internal/services"
 	"github.com/Gerard-007/ajor_app/pkg/payment"
-	"github.com/Gerard-007/ajor_app/pkg/utils"
+	"github.com/Gerard-007/ajor_app/pkg/ratelimit"
+	"github.com/Gerard-007/ajor_app/pkg/sms"
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func RegisterHandler(db *mongo.Database, pg payment.PaymentGateway) gin.HandlerFunc {
+func RegisterHandler(db *mongo.Database, pg payment.PaymentGateway, provider sms.Provider) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var user models.User
 		if err := c.ShouldBindJSON(&user); err != nil {
@@ -24,17 +28,19 @@ func RegisterHandler(db *mongo.Database, pg payment.PaymentGateway) gin.HandlerF
 		}
 		log.Printf("Bound user: %+v", user) // Debug log
 
-		// Register user and get JWT token
-		token, err := services.RegisterUser(db, &user, pg)
+		// Register user and start their first session
+		tokens, err := services.RegisterUser(db, &user, pg, provider, deviceName(c), c.ClientIP())
 		if err != nil {
 			log.Printf("Registration error: %v", err)
 			// Map specific errors to appropriate HTTP status codes
-			switch err.Error() {
-			case "email already exists", "username already exists", "phone already exists":
+			switch {
+			case err.Error() == "email already exists", err.Error() == "username already exists",
+				err.Error() == "phone already exists", err.Error() == "BVN already exists":
 				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			case "username is required", "email is required", "password is required",
-				"phone is required and must be at least 11 digits", "phone must contain only digits",
-				"BVN is required and must be 11 digits", "BVN must contain only digits":
+			case err.Error() == "username is required", err.Error() == "email is required",
+				err.Error() == "password is required", strings.HasPrefix(err.Error(), "password must be"),
+				err.Error() == "phone is required", err.Error() == "phone must be a valid Nigerian mobile number",
+				err.Error() == "BVN is required and must be 11 digits", err.Error() == "BVN must contain only digits":
 				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
 			default:
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register user: " + err.Error()})
@@ -42,56 +48,131 @@ func RegisterHandler(db *mongo.Database, pg payment.PaymentGateway) gin.HandlerF
 			return
 		}
 
-		// Return success response with token
+		// Return success response with tokens
 		log.Printf("User registered successfully with email: %s", user.Email)
 		c.JSON(http.StatusCreated, gin.H{
 			"message": "User registered successfully",
-			"token":   token,
+			"tokens":  tokens,
 		})
 	}
 }
 
-func LoginHandler(db *mongo.Collection) gin.HandlerFunc {
+// LoginHandler signs a user in. accountLimit caps attempts per email on
+// top of any per-IP limit on the route group.
+func LoginHandler(db *mongo.Database, limiter ratelimit.Store, accountLimit ratelimit.Limit) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var user models.User
-		if err := c.ShouldBindJSON(&user); err != nil {
+		var request struct {
+			Email      string `json:"email"`
+			Password   string `json:"password"`
+			DeviceName string `json:"device_name"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 			return
 		}
+		device := request.DeviceName
+		if device == "" {
+			device = deviceName(c)
+		}
+
+		limit, err := limiter.Allow(c.Request.Context(), "login:account:"+strings.ToLower(strings.TrimSpace(request.Email)), accountLimit)
+		if err != nil {
+			log.Printf("Login rate limit check failed: %v", err)
+		} else if !limit.Allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(limit.RetryAfter.Seconds()))))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many login attempts, try again later"})
+			return
+		}
 
-		token, err := services.LoginUser(db, user.Email, user.Password)
+		result, err := services.LoginUser(db, request.Email, request.Password, device, c.ClientIP())
 		if err != nil {
+			if strings.Contains(err.Error(), "login locked") {
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many login attempts, try again later"})
+				return
+			}
+			if strings.Contains(err.Error(), "being closed") {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 			return
 		}
+		if result.MFARequired {
+			c.JSON(http.StatusOK, gin.H{
+				"mfa_required": true,
+				"mfa_token":    result.MFAToken,
+				"expires_in":   result.ExpiresIn,
+			})
+			return
+		}
 
-		c.JSON(http.StatusOK, gin.H{"token": token})
+		c.JSON(http.StatusOK, result.Tokens)
 	}
 }
 
-func LogoutHandler(db *mongo.Database) gin.HandlerFunc {
+func LoginMFAHandler(db *mongo.Database) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Extract token
-		token := c.GetHeader("Authorization")
-		if token == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Authorization token is required"})
+		var request struct {
+			MFAToken string `json:"mfa_token" binding:"required"`
+			Code     string `json:"code" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "MFA token and code are required"})
 			return
 		}
-		if len(token) > 7 && token[:7] == "Bearer " {
-			token = token[7:]
+
+		tokens, err := services.CompleteMFALogin(c.Request.Context(), db, request.MFAToken, request.Code)
+		if err != nil {
+			if strings.Contains(err.Error(), "mfa token") || strings.Contains(err.Error(), "invalid code") {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to complete login"})
+			return
 		}
 
-		// Validate token to get expiration
-		claims, err := utils.ValidateToken(token)
+		c.JSON(http.StatusOK, tokens)
+	}
+}
+
+func RefreshTokenHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request struct {
+			RefreshToken string `json:"refresh_token" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Refresh token is required"})
+			return
+		}
+
+		tokens, err := services.RefreshSession(c.Request.Context(), db, request.RefreshToken, c.ClientIP())
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid token"})
+			if strings.Contains(err.Error(), "refresh token") || strings.Contains(err.Error(), "session") {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
 			return
 		}
 
-		// Blacklist token
-		blacklistCollection := db.Collection("blacklisted_tokens")
-		err = repository.BlacklistToken(blacklistCollection, token, time.Unix(claims.ExpiresAt, 0))
+		c.JSON(http.StatusOK, tokens)
+	}
+}
+
+func LogoutHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		sessionID, err := getAuthSessionID(c)
 		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		if err := services.RevokeSession(c.Request.Context(), db, userID, sessionID, "logout"); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to logout"})
 			return
 		}
@@ -99,3 +180,160 @@ func LogoutHandler(db *mongo.Database) gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
 	}
 }
+
+func GetSessionsHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		sessionID, _ := getAuthSessionID(c)
+
+		sessions, err := services.GetUserSessions(c.Request.Context(), db, userID, sessionID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get sessions"})
+			return
+		}
+		c.JSON(http.StatusOK, sessions)
+	}
+}
+
+func RevokeSessionHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		sessionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+			return
+		}
+
+		err = services.RevokeSession(c.Request.Context(), db, userID, sessionID, "signed out from another device")
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "unauthorized") {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+	}
+}
+
+func RevokeAllSessionsHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		count, err := services.RevokeAllSessions(c.Request.Context(), db, userID, "logged out everywhere")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke sessions"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Logged out of all devices", "revoked": count})
+	}
+}
+
+func ForgotPasswordHandler(db *mongo.Database, provider sms.Provider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request struct {
+			Email string `json:"email" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Email is required"})
+			return
+		}
+
+		if err := services.ForgotPassword(c.Request.Context(), db, provider, request.Email); err != nil {
+			log.Printf("Failed to send password reset: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send password reset"})
+			return
+		}
+
+		// Same response whether or not the account exists
+		c.JSON(http.StatusOK, gin.H{"message": "If the account exists, a reset token has been sent to its phone number"})
+	}
+}
+
+func ResetPasswordHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request struct {
+			Token       string `json:"token" binding:"required"`
+			NewPassword string `json:"new_password" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Token and new password are required"})
+			return
+		}
+
+		err := services.ResetPassword(c.Request.Context(), db, request.Token, request.NewPassword)
+		if err != nil {
+			if strings.Contains(err.Error(), "reset token") || strings.Contains(err.Error(), "password must") {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully. Log in with your new password"})
+	}
+}
+
+func ChangePasswordHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		var request struct {
+			CurrentPassword string `json:"current_password" binding:"required"`
+			NewPassword     string `json:"new_password" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Current and new password are required"})
+			return
+		}
+
+		tokens, err := services.ChangePassword(c.Request.Context(), db, userID, request.CurrentPassword, request.NewPassword, deviceName(c), c.ClientIP())
+		if err != nil {
+			switch {
+			case strings.Contains(err.Error(), "current password is incorrect"):
+				c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			case strings.Contains(err.Error(), "password must"):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully. Other devices have been signed out", "tokens": tokens})
+	}
+}
+
+// deviceName identifies the client for its session, preferring an explicit
+// X-Device-Name header over the user agent.
+func deviceName(c *gin.Context) string {
+	if name := c.GetHeader("X-Device-Name"); name != "" {
+		return name
+	}
+	return c.Request.UserAgent()
+}
+
+func getAuthSessionID(c *gin.Context) (primitive.ObjectID, error) {
+	sessionID, exists := c.Get("sessionID")
+	if !exists {
+		return primitive.ObjectID{}, errors.New("session not found")
+	}
+	return primitive.ObjectIDFromHex(sessionID.(string))
+}
\ No newline at end of file
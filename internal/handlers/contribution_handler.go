@@ -109,17 +109,23 @@ func UpdateContributionHandler(db *mongo.Database) gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contribution ID"})
 			return
 		}
-		var contribution models.Contribution
-		if err := c.ShouldBindJSON(&contribution); err != nil {
+		var update models.ContributionUpdate
+		if err := c.ShouldBindJSON(&update); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 			return
 		}
-		err = services.UpdateContribution(c.Request.Context(), db, contributionID, userID, &contribution)
+		err = services.UpdateContribution(c.Request.Context(), db, contributionID, userID, &update)
 		if err != nil {
 			if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "only group admin") {
 				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 				return
 			}
+			if strings.Contains(err.Error(), "cycle") || strings.Contains(err.Error(), "timezone") ||
+				strings.Contains(err.Error(), "cannot be") || strings.Contains(err.Error(), "must be") ||
+				strings.Contains(err.Error(), "invalid") {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update contribution"})
 			return
 		}
@@ -136,7 +142,8 @@ func JoinContributionHandler(db *mongo.Database) gin.HandlerFunc {
 		}
 
 		var req struct {
-			InviteCode string `json:"invite_code" binding:"required"`
+			InviteCode   string   `json:"invite_code" binding:"required"`
+			GuarantorIDs []string `json:"guarantor_ids"`
 		}
 
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -144,17 +151,31 @@ func JoinContributionHandler(db *mongo.Database) gin.HandlerFunc {
 			return
 		}
 
+		var guarantorIDs []primitive.ObjectID
+		for _, id := range req.GuarantorIDs {
+			guarantorID, err := primitive.ObjectIDFromHex(id)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid guarantor ID"})
+				return
+			}
+			guarantorIDs = append(guarantorIDs, guarantorID)
+		}
+
 		contribution, err := services.FindContributionByInviteCode(c.Request.Context(), db, req.InviteCode)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invite code"})
 			return
 		}
 
-		err = services.JoinContribution(c.Request.Context(), db, contribution.ID, userID, req.InviteCode)
+		pending, err := services.JoinContribution(c.Request.Context(), db, contribution.ID, userID, req.InviteCode, guarantorIDs)
 		if err != nil {
 			switch {
 			case strings.Contains(err.Error(), "already"):
 				c.JSON(http.StatusBadRequest, gin.H{"error": "You are already in the group"})
+			case strings.Contains(err.Error(), "reliability score"):
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			case strings.Contains(err.Error(), "guarantor"):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			case strings.Contains(err.Error(), "not found"):
 				c.JSON(http.StatusBadRequest, gin.H{"error": "Contribution not found"})
 			default:
@@ -163,6 +184,11 @@ func JoinContributionHandler(db *mongo.Database) gin.HandlerFunc {
 			return
 		}
 
+		if pending {
+			c.JSON(http.StatusAccepted, gin.H{"message": "Join request sent to your guarantors for approval"})
+			return
+		}
+
 		c.JSON(http.StatusOK, gin.H{"message": "Successfully joined the group"})
 	}
 }
@@ -252,6 +278,10 @@ func RecordContributionHandler(db *mongo.Database) gin.HandlerFunc {
 		}
 		err = services.RecordContribution(c.Request.Context(), db, contributionID, userID, request.Amount, request.PaymentMethod)
 		if err != nil {
+			if strings.Contains(err.Error(), "KYC limit") || strings.Contains(err.Error(), "not verified") {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
 			if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "amount mismatch") || strings.Contains(err.Error(), "insufficient balance") {
 				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 				return
@@ -286,7 +316,8 @@ func RecordPayoutHandler(db *mongo.Database) gin.HandlerFunc {
 		}
 		err = services.RecordPayout(c.Request.Context(), db, contributionID, request.UserID, groupAdminID, request.Amount, request.PaymentMethod)
 		if err != nil {
-			if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "only group admin") || strings.Contains(err.Error(), "insufficient balance") {
+			if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "only group admin") || strings.Contains(err.Error(), "insufficient balance") ||
+				strings.Contains(err.Error(), "KYC limit") || strings.Contains(err.Error(), "not verified") {
 				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 				return
 			}
@@ -299,13 +330,17 @@ func RecordPayoutHandler(db *mongo.Database) gin.HandlerFunc {
 
 func GetAllContributionsHandler(db *mongo.Database) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		isAdmin, exists := c.Get("isAdmin")
-		if !exists || !isAdmin.(bool) {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Only system admins can view all contributions"})
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
-		contributions, err := services.GetAllContributions(db, true)
+		contributions, err := services.GetAllContributions(db, userID)
 		if err != nil {
+			if err.Error() == "permission denied" {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Only system admins can view all contributions"})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
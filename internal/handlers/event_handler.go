@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/services"
+	"github.com/Gerard-007/ajor_app/pkg/events"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/net/websocket"
+)
+
+// eventHeartbeat is how often an idle stream is kept alive, and its session
+// checked to still be valid.
+const eventHeartbeat = 25 * time.Second
+
+// CreateStreamTicketHandler issues a single-use ticket for opening the
+// event stream from a client that cannot set headers on it.
+func CreateStreamTicketHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		sessionID, err := getAuthSessionID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		tokenExpiresAt, _ := c.Get("tokenExpiresAt")
+		expiry, _ := tokenExpiresAt.(time.Time)
+
+		ticket, expiresAt, err := services.CreateStreamTicket(c.Request.Context(), db, userID, sessionID, expiry)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create stream ticket"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"ticket": ticket, "expires_at": expiresAt})
+	}
+}
+
+// EventStreamHandler streams the user's events as server-sent events, or
+// over a WebSocket when the request asks to upgrade. Clients resume after a
+// dropped connection by sending the last event ID they saw, as the
+// Last-Event-ID header or the last_event_id query parameter. The stream
+// ends when the session is revoked or the access token expires; the client
+// then reconnects with a fresh token. WebSocket connections from browsers
+// are only accepted from the server's own origin and those listed in
+// STREAM_ALLOWED_ORIGINS.
+func EventStreamHandler(db *mongo.Database) gin.HandlerFunc {
+	allowedOrigins := originsFromEnv("STREAM_ALLOWED_ORIGINS")
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		sessionID, err := getAuthSessionID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		lastEventID := c.GetHeader("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = c.Query("last_event_id")
+		}
+
+		subscription, err := services.SubscribeEvents(c.Request.Context(), db, userID, lastEventID)
+		if err != nil && err != events.ErrEventsMissed {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to subscribe to events"})
+			return
+		}
+		defer subscription.Close()
+		if err == events.ErrEventsMissed {
+			subscription.Replay = append([]events.Event{{Type: events.StreamReset, CreatedAt: time.Now()}}, subscription.Replay...)
+		}
+
+		stream := &eventStream{
+			db:           db,
+			userID:       userID,
+			sessionID:    sessionID,
+			ip:           c.ClientIP(),
+			subscription: subscription,
+			origins:      allowedOrigins,
+		}
+		if expiresAt, ok := c.Get("tokenExpiresAt"); ok {
+			stream.expiresAt = expiresAt.(time.Time)
+		}
+		if c.IsWebsocket() {
+			stream.serveWebSocket(c)
+			return
+		}
+		stream.serveSSE(c)
+	}
+}
+
+type eventStream struct {
+	db           *mongo.Database
+	userID       primitive.ObjectID
+	sessionID    primitive.ObjectID
+	ip           string
+	expiresAt    time.Time
+	subscription *events.Subscription
+	origins      []string
+}
+
+// run sends replayed and then live events until the client goes away, the
+// subscriber falls behind or the session ends, calling heartbeat when idle.
+func (s *eventStream) run(ctx context.Context, send func(events.Event) error, heartbeat func() error) {
+	for _, event := range s.subscription.Replay {
+		if err := send(event); err != nil {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(eventHeartbeat)
+	defer ticker.Stop()
+	var expired <-chan time.Time
+	if !s.expiresAt.IsZero() {
+		timer := time.NewTimer(time.Until(s.expiresAt))
+		defer timer.Stop()
+		expired = timer.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-expired:
+			return
+		case event, ok := <-s.subscription.Events:
+			if !ok {
+				return
+			}
+			if err := send(event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := services.ValidateSession(ctx, s.db, s.sessionID, s.userID, s.ip); err != nil {
+				return
+			}
+			if err := heartbeat(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *eventStream) serveSSE(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // stop nginx buffering the stream
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	send := func(event events.Event) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if event.ID != "" {
+			if _, err := fmt.Fprintf(c.Writer, "id: %s\n", event.ID); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	}
+	heartbeat := func() error {
+		if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	}
+	s.run(c.Request.Context(), send, heartbeat)
+}
+
+// serveWebSocket sends each event as a JSON text message. Messages from the
+// client are ignored; reading them only detects when it disconnects.
+func (s *eventStream) serveWebSocket(c *gin.Context) {
+	server := websocket.Server{
+		Handshake: func(config *websocket.Config, req *http.Request) error {
+			origin, err := websocket.Origin(config, req)
+			if err != nil {
+				return err
+			}
+			if !originAllowed(origin, req.Host, s.origins) {
+				return errors.New("origin not allowed")
+			}
+			config.Origin = origin
+			return nil
+		},
+		Handler: func(conn *websocket.Conn) {
+			ctx, cancel := context.WithCancel(c.Request.Context())
+			defer cancel()
+			go func() {
+				defer cancel()
+				var message string
+				for websocket.Message.Receive(conn, &message) == nil {
+				}
+			}()
+
+			send := func(event events.Event) error {
+				return websocket.JSON.Send(conn, event)
+			}
+			heartbeat := func() error {
+				return websocket.JSON.Send(conn, gin.H{"type": "ping"})
+			}
+			s.run(ctx, send, heartbeat)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// originAllowed reports whether a WebSocket handshake may come from origin.
+// Clients other than browsers send no Origin and are always allowed; they
+// authenticate with a header or ticket anyway.
+func originAllowed(origin *url.URL, host string, allowed []string) bool {
+	if origin == nil {
+		return true
+	}
+	if strings.EqualFold(origin.Host, host) {
+		return true
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(strings.TrimSuffix(a, "/"), origin.Scheme+"://"+origin.Host) {
+			return true
+		}
+	}
+	return false
+}
+
+func originsFromEnv(key string) []string {
+	var origins []string
+	for _, origin := range strings.Split(os.Getenv(key), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOriginAllowed(t *testing.T) {
+	allowed := []string{"https://app.ajor.ng", "https://admin.ajor.ng/"}
+
+	tests := []struct {
+		name   string
+		origin string
+		host   string
+		want   bool
+	}{
+		{"no origin from a non-browser client", "", "api.ajor.ng", true},
+		{"same origin", "https://api.ajor.ng", "api.ajor.ng", true},
+		{"listed origin", "https://app.ajor.ng", "api.ajor.ng", true},
+		{"listed with a trailing slash", "https://admin.ajor.ng", "api.ajor.ng", true},
+		{"listed host over plain http", "http://app.ajor.ng", "api.ajor.ng", false},
+		{"other site", "https://evil.example", "api.ajor.ng", false},
+		{"lookalike subdomain", "https://app.ajor.ng.evil.example", "api.ajor.ng", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var origin *url.URL
+			if tt.origin != "" {
+				var err error
+				origin, err = url.ParseRequestURI(tt.origin)
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, originAllowed(origin, tt.host, allowed))
+		})
+	}
+}
+
+func TestOriginsFromEnv(t *testing.T) {
+	t.Setenv("STREAM_ALLOWED_ORIGINS", " https://app.ajor.ng, ,https://admin.ajor.ng")
+	assert.Equal(t, []string{"https://app.ajor.ng", "https://admin.ajor.ng"}, originsFromEnv("STREAM_ALLOWED_ORIGINS"))
+
+	t.Setenv("STREAM_ALLOWED_ORIGINS", "")
+	assert.Empty(t, originsFromEnv("STREAM_ALLOWED_ORIGINS"))
+}
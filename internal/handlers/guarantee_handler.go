@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Gerard-007/ajor_app/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func GetUserGuaranteesHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		guarantees, err := services.GetUserGuarantees(c.Request.Context(), db, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get guarantees"})
+			return
+		}
+		c.JSON(http.StatusOK, guarantees)
+	}
+}
+
+func RespondToGuaranteeHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		guarantorID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		guaranteeID, err := primitive.ObjectIDFromHex(c.Param("guarantee_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid guarantee ID"})
+			return
+		}
+		var request struct {
+			Accept bool `json:"accept"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		err = services.RespondToGuarantee(c.Request.Context(), db, guaranteeID, guarantorID, request.Accept)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "already processed") {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to respond to guarantee"})
+			return
+		}
+		status := "declined"
+		if request.Accept {
+			status = "accepted"
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Guarantee " + status + " successfully"})
+	}
+}
+
+func FileGuaranteeClaimHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		groupAdminID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		guaranteeID, err := primitive.ObjectIDFromHex(c.Param("guarantee_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid guarantee ID"})
+			return
+		}
+		transaction, err := services.FileGuaranteeClaim(c.Request.Context(), db, guaranteeID, groupAdminID)
+		if err != nil {
+			if strings.Contains(err.Error(), "only group admin") {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
+			if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "not active") || strings.Contains(err.Error(), "already pending") || strings.Contains(err.Error(), "not defaulted") || strings.Contains(err.Error(), "no group members available") {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to file guarantee claim"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"message": "Guarantee claim sent for approval", "transaction": transaction})
+	}
+}
+
+func GetContributionGuaranteesHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		contributionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contribution ID"})
+			return
+		}
+		guarantees, err := services.GetContributionGuarantees(c.Request.Context(), db, contributionID, userID)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "only group admin") {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get guarantees"})
+			return
+		}
+		c.JSON(http.StatusOK, guarantees)
+	}
+}
+
+func GetMemberArrearsHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		contributionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contribution ID"})
+			return
+		}
+		memberID, err := primitive.ObjectIDFromHex(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		arrears, err := services.GetMemberArrears(c.Request.Context(), db, contributionID, memberID, userID)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "unauthorized") || strings.Contains(err.Error(), "not in contribution") {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get arrears"})
+			return
+		}
+		c.JSON(http.StatusOK, arrears)
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func GetHolidaysHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		holidays, err := services.GetHolidays(c.Request.Context(), db)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get holidays"})
+			return
+		}
+		c.JSON(http.StatusOK, holidays)
+	}
+}
+
+func AddHolidayHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		var holiday models.Holiday
+		if err := c.ShouldBindJSON(&holiday); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		if err := services.AddHoliday(c.Request.Context(), db, userID, &holiday); err != nil {
+			if err.Error() == "permission denied" {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Only system admins can manage holidays"})
+				return
+			}
+			if strings.Contains(err.Error(), "holiday") {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add holiday"})
+			return
+		}
+		c.JSON(http.StatusCreated, holiday)
+	}
+}
+
+func DeleteHolidayHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		holidayID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid holiday ID"})
+			return
+		}
+		if err := services.DeleteHoliday(c.Request.Context(), db, userID, holidayID); err != nil {
+			if err.Error() == "permission denied" {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Only system admins can manage holidays"})
+				return
+			}
+			if strings.Contains(err.Error(), "not found") {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete holiday"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Holiday deleted successfully"})
+	}
+}
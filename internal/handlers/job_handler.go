@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/pkg/jobs"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultJobRunsLimit = 50
+	maxJobRunsLimit     = 200
+)
+
+// GetJobsHandler lists the background jobs with their schedules, who holds
+// their lease and their latest run.
+func GetJobsHandler(scheduler *jobs.Scheduler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		statuses, err := scheduler.Jobs(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch jobs"})
+			return
+		}
+		c.JSON(http.StatusOK, statuses)
+	}
+}
+
+// GetJobRunsHandler lists job runs, newest first. Query parameters: job,
+// status and limit.
+func GetJobRunsHandler(scheduler *jobs.Scheduler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := defaultJobRunsLimit
+		if value := c.Query("limit"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+				return
+			}
+			limit = parsed
+		}
+		if limit > maxJobRunsLimit {
+			limit = maxJobRunsLimit
+		}
+
+		runs, err := scheduler.Runs(c.Request.Context(), c.Query("job"), models.JobRunStatus(c.Query("status")), int64(limit))
+		if err != nil {
+			if err.Error() == "job not found" {
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch job runs"})
+			return
+		}
+		c.JSON(http.StatusOK, runs)
+	}
+}
+
+// RunJobHandler starts a job now. The run carries on in the background;
+// its record is returned so it can be followed in the run list.
+func RunJobHandler(scheduler *jobs.Scheduler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		run, err := scheduler.Trigger(c.Request.Context(), c.Param("name"), actorID)
+		if err != nil {
+			switch err.Error() {
+			case "job not found":
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			case "job already running":
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start job"})
+			}
+			return
+		}
+		c.JSON(http.StatusAccepted, run)
+	}
+}
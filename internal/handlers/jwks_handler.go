@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Gerard-007/ajor_app/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSHandler publishes the public keys access tokens are signed with, so
+// other services can verify tokens without a shared secret. Keys are
+// published before they start signing, so caching for a few minutes is safe.
+func JWKSHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, utils.JWKS())
+	}
+}
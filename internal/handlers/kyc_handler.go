@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/services"
+	"github.com/Gerard-007/ajor_app/pkg/payment"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const maxKYCDocumentSize = 5 << 20
+
+var kycDocumentExtensions = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".pdf": true}
+
+func GetKYCStatusHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		status, err := services.GetKYCStatus(c.Request.Context(), db, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch KYC status"})
+			return
+		}
+		c.JSON(http.StatusOK, status)
+	}
+}
+
+// RequestKYCUpgradeHandler takes a multipart form with the tier and one
+// file per document type, e.g. id_document and proof_of_address.
+func RequestKYCUpgradeHandler(db *mongo.Database, pg payment.PaymentGateway) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		tier, err := strconv.Atoi(c.PostForm("tier"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Tier is required"})
+			return
+		}
+
+		dir := os.Getenv("KYC_DOCUMENT_DIR")
+		if dir == "" {
+			dir = filepath.Join("uploads", "kyc")
+		}
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create directory for documents"})
+			return
+		}
+
+		var documents []models.KYCDocument
+		removeDocuments := func() {
+			for _, document := range documents {
+				os.Remove(document.Path)
+			}
+		}
+		for _, documentType := range []models.KYCDocumentType{models.KYCDocumentID, models.KYCDocumentProofOfAddress} {
+			file, err := c.FormFile(string(documentType))
+			if err != nil {
+				continue
+			}
+			ext := strings.ToLower(filepath.Ext(file.Filename))
+			if !kycDocumentExtensions[ext] {
+				removeDocuments()
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Documents must be JPEG, PNG or PDF files"})
+				return
+			}
+			if file.Size > maxKYCDocumentSize {
+				removeDocuments()
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Documents must be 5MB or smaller"})
+				return
+			}
+			// Stored under a generated name so uploads cannot pick their own path
+			path := filepath.Join(dir, fmt.Sprintf("%s-%s-%s%s", userID.Hex(), documentType, primitive.NewObjectID().Hex(), ext))
+			if err := c.SaveUploadedFile(file, path); err != nil {
+				removeDocuments()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save document"})
+				return
+			}
+			documents = append(documents, models.KYCDocument{
+				Type:       documentType,
+				FileName:   filepath.Base(file.Filename),
+				Path:       path,
+				UploadedAt: time.Now(),
+			})
+		}
+
+		request, err := services.RequestKYCUpgrade(c.Request.Context(), db, pg, userID, models.KYCTier(tier), documents)
+		if err != nil {
+			removeDocuments()
+			switch {
+			case strings.Contains(err.Error(), "already pending"):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			case strings.Contains(err.Error(), "not verified"):
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			case strings.Contains(err.Error(), "KYC tier"):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			case strings.Contains(err.Error(), "failed to verify BVN"):
+				c.JSON(http.StatusBadGateway, gin.H{"error": "Could not verify BVN, try again later"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to request KYC upgrade"})
+			}
+			return
+		}
+		c.JSON(http.StatusCreated, request)
+	}
+}
+
+func GetKYCRequestsHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		requests, err := services.GetKYCRequests(c.Request.Context(), db, actorID, models.KYCRequestStatus(c.Query("status")))
+		if err != nil {
+			if err.Error() == "permission denied" {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Only compliance reviewers can view KYC requests"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch KYC requests"})
+			return
+		}
+		c.JSON(http.StatusOK, requests)
+	}
+}
+
+func GetKYCDocumentHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		requestID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid KYC request ID"})
+			return
+		}
+		document, err := services.GetKYCDocument(c.Request.Context(), db, actorID, requestID, models.KYCDocumentType(c.Param("type")))
+		if err != nil {
+			switch {
+			case err.Error() == "permission denied":
+				c.JSON(http.StatusForbidden, gin.H{"error": "Only compliance reviewers can view KYC documents"})
+			case strings.Contains(err.Error(), "not found"):
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch document"})
+			}
+			return
+		}
+		c.FileAttachment(document.Path, document.FileName)
+	}
+}
+
+func ReviewKYCRequestHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		requestID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid KYC request ID"})
+			return
+		}
+		var request struct {
+			Approve *bool  `json:"approve" binding:"required"`
+			Note    string `json:"note"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Approve is required"})
+			return
+		}
+		if !*request.Approve && strings.TrimSpace(request.Note) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "A note is required when rejecting a request"})
+			return
+		}
+
+		kycRequest, err := services.ReviewKYCRequest(c.Request.Context(), db, actorID, requestID, *request.Approve, request.Note)
+		if err != nil {
+			switch {
+			case err.Error() == "permission denied", strings.Contains(err.Error(), "your own"):
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			case strings.Contains(err.Error(), "not found"):
+				c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			case strings.Contains(err.Error(), "already reviewed"), strings.Contains(err.Error(), "BVN not verified"):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to review KYC request"})
+			}
+			return
+		}
+		c.JSON(http.StatusOK, kycRequest)
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Gerard-007/ajor_app/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func ApplyForLoanHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		contributionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contribution ID"})
+			return
+		}
+		var request struct {
+			Amount       float64 `json:"amount"`
+			Installments int     `json:"installments"`
+			Reason       string  `json:"reason"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		loan, err := services.ApplyForLoan(c.Request.Context(), db, contributionID, userID, request.Amount, request.Installments, request.Reason)
+		if err != nil {
+			if strings.Contains(err.Error(), "not in contribution") {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
+			if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "loan") || strings.Contains(err.Error(), "no group members available") {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply for loan"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"message": "Loan request sent for approval", "loan": loan})
+	}
+}
+
+func GetContributionLoansHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		contributionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contribution ID"})
+			return
+		}
+		loans, err := services.GetContributionLoans(c.Request.Context(), db, contributionID, userID)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "unauthorized") {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get loans"})
+			return
+		}
+		c.JSON(http.StatusOK, loans)
+	}
+}
+
+func GetLoanHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		loanID, err := primitive.ObjectIDFromHex(c.Param("loan_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid loan ID"})
+			return
+		}
+		loan, err := services.GetLoan(c.Request.Context(), db, loanID, userID)
+		if err != nil {
+			if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "unauthorized") {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get loan"})
+			return
+		}
+		c.JSON(http.StatusOK, loan)
+	}
+}
+
+func RepayLoanHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		loanID, err := primitive.ObjectIDFromHex(c.Param("loan_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid loan ID"})
+			return
+		}
+		var request struct {
+			Amount float64 `json:"amount"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		loan, err := services.RepayLoan(c.Request.Context(), db, loanID, userID, request.Amount)
+		if err != nil {
+			if strings.Contains(err.Error(), "only the borrower") || strings.Contains(err.Error(), "KYC limit") || strings.Contains(err.Error(), "not verified") {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
+			if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "not active") || strings.Contains(err.Error(), "must be positive") || strings.Contains(err.Error(), "insufficient balance") || strings.Contains(err.Error(), "exceeds outstanding") {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to repay loan"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Loan repayment recorded successfully", "loan": loan})
+	}
+}
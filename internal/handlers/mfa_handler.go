@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Gerard-007/ajor_app/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func GetMFAStatusHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		status, err := services.GetMFAStatus(c.Request.Context(), db, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch two-factor status"})
+			return
+		}
+		c.JSON(http.StatusOK, status)
+	}
+}
+
+func SetupMFAHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		enrollment, err := services.SetupMFA(c.Request.Context(), db, userID)
+		if err != nil {
+			if strings.Contains(err.Error(), "already enabled") {
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set up two-factor authentication"})
+			return
+		}
+		c.JSON(http.StatusOK, enrollment)
+	}
+}
+
+func EnableMFAHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		sessionID, err := getAuthSessionID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		var request struct {
+			Code string `json:"code" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Code is required"})
+			return
+		}
+		codes, err := services.EnableMFA(c.Request.Context(), db, userID, sessionID, request.Code)
+		if err != nil {
+			switch {
+			case strings.Contains(err.Error(), "already enabled"):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			case strings.Contains(err.Error(), "invalid code"), strings.Contains(err.Error(), "not set up"):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+			}
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message":        "Two-factor authentication enabled. Store these recovery codes somewhere safe; they will not be shown again.",
+			"recovery_codes": codes,
+		})
+	}
+}
+
+func DisableMFAHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		var request struct {
+			Password string `json:"password" binding:"required"`
+			Code     string `json:"code" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Password and code are required"})
+			return
+		}
+		err = services.DisableMFA(c.Request.Context(), db, userID, request.Password, request.Code)
+		if err != nil {
+			switch {
+			case strings.Contains(err.Error(), "required for your account"):
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			case strings.Contains(err.Error(), "incorrect"), strings.Contains(err.Error(), "invalid code"):
+				c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			case strings.Contains(err.Error(), "not enabled"), strings.Contains(err.Error(), "not set up"):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+			}
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+	}
+}
+
+func RegenerateRecoveryCodesHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		var request struct {
+			Code string `json:"code" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Code is required"})
+			return
+		}
+		codes, err := services.RegenerateRecoveryCodes(c.Request.Context(), db, userID, request.Code)
+		if err != nil {
+			switch {
+			case strings.Contains(err.Error(), "invalid code"):
+				c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			case strings.Contains(err.Error(), "not enabled"), strings.Contains(err.Error(), "not set up"):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to regenerate recovery codes"})
+			}
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+	}
+}
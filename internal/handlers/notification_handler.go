@@ -1,13 +1,20 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/Gerard-007/ajor_app/internal/models"
 	"github.com/Gerard-007/ajor_app/internal/services"
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// GetUserNotificationsHandler pages through the user's inbox. Query
+// parameters: type, contribution_id, read (true or false), cursor and limit.
 func GetUserNotificationsHandler(db *mongo.Database) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, err := getAuthUserID(c)
@@ -15,11 +22,202 @@ func GetUserNotificationsHandler(db *mongo.Database) gin.HandlerFunc {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
-		notifications, err := services.GetUserNotifications(c.Request.Context(), db, userID)
+		query, err := bindNotificationQuery(c)
 		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		page, err := services.GetUserNotifications(c.Request.Context(), db, userID, query)
+		if err != nil {
+			if err.Error() == "invalid cursor" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notifications"})
 			return
 		}
-		c.JSON(http.StatusOK, notifications)
+		c.JSON(http.StatusOK, page)
+	}
+}
+
+func bindNotificationQuery(c *gin.Context) (services.NotificationQuery, error) {
+	query := services.NotificationQuery{
+		Type:   models.NotificationType(c.Query("type")),
+		Cursor: c.Query("cursor"),
+	}
+	if value := c.Query("contribution_id"); value != "" {
+		contributionID, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			return query, errors.New("Invalid contribution ID")
+		}
+		query.ContributionID = &contributionID
+	}
+	if value := c.Query("read"); value != "" {
+		read, err := strconv.ParseBool(value)
+		if err != nil {
+			return query, errors.New("read must be true or false")
+		}
+		query.Read = &read
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return query, errors.New("limit must be a positive number")
+		}
+		query.Limit = limit
+	}
+	return query, nil
+}
+
+func GetUnreadNotificationCountHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		count, err := services.GetUnreadNotificationCount(c.Request.Context(), db, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count notifications"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"unread": count})
+	}
+}
+
+func MarkNotificationReadHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		notificationID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+			return
+		}
+		if err := services.MarkNotificationRead(c.Request.Context(), db, notificationID, userID); err != nil {
+			if err.Error() == "notification not found" {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark notification read"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Notification marked read"})
+	}
+}
+
+// MarkAllNotificationsReadHandler marks the whole inbox read, or only the
+// notifications matching the type and contribution_id query parameters.
+func MarkAllNotificationsReadHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		query, err := bindNotificationQuery(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		marked, err := services.MarkAllNotificationsRead(c.Request.Context(), db, userID, query)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark notifications read"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Notifications marked read", "marked": marked})
+	}
+}
+
+func DeleteNotificationHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		notificationID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+			return
+		}
+		if err := services.DeleteNotification(c.Request.Context(), db, notificationID, userID); err != nil {
+			if err.Error() == "notification not found" {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete notification"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Notification deleted"})
+	}
+}
+
+func GetNotificationDeliveriesHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		notificationID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+			return
+		}
+		deliveries, err := services.GetNotificationDeliveries(c.Request.Context(), db, notificationID, userID)
+		if err != nil {
+			if err.Error() == "notification not found" {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get deliveries"})
+			return
+		}
+		c.JSON(http.StatusOK, deliveries)
+	}
+}
+
+func GetNotificationPreferencesHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		preferences, err := services.GetNotificationPreferences(c.Request.Context(), db, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notification preferences"})
+			return
+		}
+		c.JSON(http.StatusOK, preferences)
+	}
+}
+
+func UpdateNotificationPreferencesHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		var request models.NotificationPreferences
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		preferences, err := services.UpdateNotificationPreferences(c.Request.Context(), db, userID, &request)
+		if err != nil {
+			if strings.Contains(err.Error(), "invalid") || strings.Contains(err.Error(), "cannot be muted") {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification preferences"})
+			return
+		}
+		c.JSON(http.StatusOK, preferences)
 	}
 }
\ No newline at end of file
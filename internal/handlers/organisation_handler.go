@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/services"
+	"github.com/Gerard-007/ajor_app/pkg/payment"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// writeOrganisationError maps errors from the organisation and API key
+// services.
+func writeOrganisationError(c *gin.Context, err error, fallback string) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "only"):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "already"):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "required"), strings.Contains(err.Error(), "invalid"),
+		strings.Contains(err.Error(), "not in organisation staff"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+func CreateOrganisationHandler(db *mongo.Database, pg payment.PaymentGateway) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		var request struct {
+			Name string `json:"name"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		organisation, err := services.CreateOrganisation(c.Request.Context(), db, pg, userID, request.Name)
+		if err != nil {
+			writeOrganisationError(c, err, "Failed to create organisation")
+			return
+		}
+		c.JSON(http.StatusCreated, organisation)
+	}
+}
+
+func GetUserOrganisationsHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		organisations, err := services.GetUserOrganisations(c.Request.Context(), db, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get organisations"})
+			return
+		}
+		c.JSON(http.StatusOK, organisations)
+	}
+}
+
+func GetOrganisationHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		organisationID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organisation ID"})
+			return
+		}
+		organisation, err := services.GetOrganisation(c.Request.Context(), db, organisationID, userID)
+		if err != nil {
+			writeOrganisationError(c, err, "Failed to get organisation")
+			return
+		}
+		c.JSON(http.StatusOK, organisation)
+	}
+}
+
+func JoinOrganisationHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		var request struct {
+			StaffCode string `json:"staff_code"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		organisation, err := services.JoinOrganisationStaff(c.Request.Context(), db, userID, request.StaffCode)
+		if err != nil {
+			writeOrganisationError(c, err, "Failed to join organisation")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Joined the staff of " + organisation.Name})
+	}
+}
+
+// RemoveOrganisationStaffHandler lets staff leave an organisation and its
+// owner remove them.
+func RemoveOrganisationStaffHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actorID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		organisationID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organisation ID"})
+			return
+		}
+		userID, err := primitive.ObjectIDFromHex(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		if err := services.RemoveOrganisationStaff(c.Request.Context(), db, organisationID, userID, actorID); err != nil {
+			writeOrganisationError(c, err, "Failed to remove staff member")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Staff member removed"})
+	}
+}
+
+func LinkOrganisationContributionHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		organisationID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organisation ID"})
+			return
+		}
+		contributionID, err := primitive.ObjectIDFromHex(c.Param("contribution_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contribution ID"})
+			return
+		}
+		if err := services.LinkOrganisationContribution(c.Request.Context(), db, organisationID, contributionID, userID); err != nil {
+			writeOrganisationError(c, err, "Failed to link contribution")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Contribution linked to organisation"})
+	}
+}
+
+func CreateAPIKeyHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		organisationID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organisation ID"})
+			return
+		}
+		var request struct {
+			Name             string               `json:"name"`
+			Scopes           []models.APIKeyScope `json:"scopes"`
+			AllowedIPs       []string             `json:"allowed_ips"`
+			RequireSignature bool                 `json:"require_signature"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		created, err := services.CreateAPIKey(c.Request.Context(), db, organisationID, userID, request.Name, request.Scopes, request.AllowedIPs, request.RequireSignature)
+		if err != nil {
+			writeOrganisationError(c, err, "Failed to create API key")
+			return
+		}
+		c.JSON(http.StatusCreated, created)
+	}
+}
+
+func GetAPIKeysHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		organisationID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organisation ID"})
+			return
+		}
+		keys, err := services.GetAPIKeys(c.Request.Context(), db, organisationID, userID)
+		if err != nil {
+			writeOrganisationError(c, err, "Failed to get API keys")
+			return
+		}
+		c.JSON(http.StatusOK, keys)
+	}
+}
+
+func GetAPIKeyUsageHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		organisationID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organisation ID"})
+			return
+		}
+		keyID, err := primitive.ObjectIDFromHex(c.Param("key_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+			return
+		}
+		usage, err := services.GetAPIKeyUsage(c.Request.Context(), db, organisationID, keyID, userID)
+		if err != nil {
+			writeOrganisationError(c, err, "Failed to get API key usage")
+			return
+		}
+		c.JSON(http.StatusOK, usage)
+	}
+}
+
+func RevokeAPIKeyHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		organisationID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organisation ID"})
+			return
+		}
+		keyID, err := primitive.ObjectIDFromHex(c.Param("key_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+			return
+		}
+		if err := services.RevokeAPIKey(c.Request.Context(), db, organisationID, keyID, userID); err != nil {
+			writeOrganisationError(c, err, "Failed to revoke API key")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+	}
+}
+
+func SetOrganisationWebhookHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		organisationID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organisation ID"})
+			return
+		}
+		var request struct {
+			URL string `json:"url"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		secret, err := services.SetOrganisationWebhook(c.Request.Context(), db, organisationID, userID, request.URL)
+		if err != nil {
+			writeOrganisationError(c, err, "Failed to set webhook")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"url": strings.TrimSpace(request.URL), "signing_secret": secret})
+	}
+}
+
+func RemoveOrganisationWebhookHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		organisationID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid organisation ID"})
+			return
+		}
+		if err := services.RemoveOrganisationWebhook(c.Request.Context(), db, organisationID, userID); err != nil {
+			writeOrganisationError(c, err, "Failed to remove webhook")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Webhook removed"})
+	}
+}
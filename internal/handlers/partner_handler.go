@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Gerard-007/ajor_app/internal/services"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// getAuthOrganisationID returns the organisation whose API key
+// authenticated the request.
+func getAuthOrganisationID(c *gin.Context) (primitive.ObjectID, error) {
+	organisationIDStr, exists := c.Get("organisationID")
+	if !exists {
+		return primitive.NilObjectID, errors.New("organisation not authenticated")
+	}
+	return primitive.ObjectIDFromHex(organisationIDStr.(string))
+}
+
+// writePartnerError maps errors from acting on staff through the partner
+// API.
+func writePartnerError(c *gin.Context, err error, fallback string) {
+	switch {
+	case strings.Contains(err.Error(), "not in organisation staff"),
+		strings.Contains(err.Error(), "not linked"),
+		strings.Contains(err.Error(), "not in contribution"),
+		strings.Contains(err.Error(), "not verified"),
+		strings.Contains(err.Error(), "KYC limit"),
+		strings.Contains(err.Error(), "reliability score"):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "already"):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case strings.Contains(err.Error(), "not found"),
+		strings.Contains(err.Error(), "required"),
+		strings.Contains(err.Error(), "insufficient"),
+		strings.Contains(err.Error(), "guarantor"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+func PartnerGetStaffHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		organisationID, err := getAuthOrganisationID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		staff, err := services.GetOrganisationStaff(c.Request.Context(), db, organisationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get staff"})
+			return
+		}
+		c.JSON(http.StatusOK, staff)
+	}
+}
+
+func PartnerGetContributionsHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		organisationID, err := getAuthOrganisationID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		contributions, err := services.GetOrganisationContributions(c.Request.Context(), db, organisationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get contributions"})
+			return
+		}
+		c.JSON(http.StatusOK, contributions)
+	}
+}
+
+func PartnerGetWalletHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		organisationID, err := getAuthOrganisationID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		wallet, err := services.GetOrganisationWallet(c.Request.Context(), db, organisationID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get wallet"})
+			return
+		}
+		c.JSON(http.StatusOK, wallet)
+	}
+}
+
+func PartnerEnrolStaffHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		organisationID, err := getAuthOrganisationID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		contributionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contribution ID"})
+			return
+		}
+		var request struct {
+			UserID       string   `json:"user_id"`
+			GuarantorIDs []string `json:"guarantor_ids"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		userID, err := primitive.ObjectIDFromHex(request.UserID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		var guarantorIDs []primitive.ObjectID
+		for _, id := range request.GuarantorIDs {
+			guarantorID, err := primitive.ObjectIDFromHex(id)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid guarantor ID"})
+				return
+			}
+			guarantorIDs = append(guarantorIDs, guarantorID)
+		}
+
+		pending, err := services.EnrolStaff(c.Request.Context(), db, organisationID, contributionID, userID, guarantorIDs)
+		if err != nil {
+			writePartnerError(c, err, "Failed to enrol staff member")
+			return
+		}
+		if pending {
+			c.JSON(http.StatusAccepted, gin.H{"message": "Enrolment sent to the guarantors for approval"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Staff member enrolled"})
+	}
+}
+
+func PartnerPayContributionHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		organisationID, err := getAuthOrganisationID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		contributionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contribution ID"})
+			return
+		}
+		var request struct {
+			UserID    string `json:"user_id"`
+			Reference string `json:"reference"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+		userID, err := primitive.ObjectIDFromHex(request.UserID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		transaction, err := services.PayStaffContribution(c.Request.Context(), db, organisationID, contributionID, userID, request.Reference)
+		if err != nil {
+			writePartnerError(c, err, "Failed to pay contribution")
+			return
+		}
+		c.JSON(http.StatusCreated, transaction)
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Gerard-007/ajor_app/internal/services"
+	"github.com/Gerard-007/ajor_app/pkg/sms"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SetTransactionPINHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		var request struct {
+			PIN string `json:"pin" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "PIN is required"})
+			return
+		}
+		err = services.SetTransactionPIN(c.Request.Context(), db, userID, request.PIN)
+		if err != nil {
+			switch {
+			case strings.Contains(err.Error(), "already set"):
+				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			case strings.Contains(err.Error(), "digits"):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set transaction PIN"})
+			}
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"message": "Transaction PIN set successfully"})
+	}
+}
+
+func ChangeTransactionPINHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		var request struct {
+			CurrentPIN string `json:"current_pin" binding:"required"`
+			NewPIN     string `json:"new_pin" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Current and new PIN are required"})
+			return
+		}
+		err = services.ChangeTransactionPIN(c.Request.Context(), db, userID, request.CurrentPIN, request.NewPIN)
+		if err != nil {
+			switch {
+			case strings.Contains(err.Error(), "locked"):
+				c.JSON(http.StatusLocked, gin.H{"error": err.Error()})
+			case strings.Contains(err.Error(), "incorrect"), strings.Contains(err.Error(), "not set"):
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			case strings.Contains(err.Error(), "digits"):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change transaction PIN"})
+			}
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Transaction PIN changed successfully"})
+	}
+}
+
+func SendPINResetHandler(db *mongo.Database, provider sms.Provider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		err = services.RequestPINReset(c.Request.Context(), db, provider, userID)
+		if err != nil {
+			switch {
+			case strings.Contains(err.Error(), "please wait"):
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			case strings.Contains(err.Error(), "not verified"), strings.Contains(err.Error(), "not found"):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send reset code"})
+			}
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Reset code sent"})
+	}
+}
+
+func ResetTransactionPINHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		var request struct {
+			Code   string `json:"code" binding:"required"`
+			NewPIN string `json:"new_pin" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Code and new PIN are required"})
+			return
+		}
+		err = services.ResetTransactionPIN(c.Request.Context(), db, userID, request.Code, request.NewPIN)
+		if err != nil {
+			switch {
+			case strings.Contains(err.Error(), "too many attempts"):
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			case strings.Contains(err.Error(), "code"), strings.Contains(err.Error(), "digits"):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset transaction PIN"})
+			}
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Transaction PIN reset successfully"})
+	}
+}
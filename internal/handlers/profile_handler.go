@@ -57,17 +57,12 @@ func UpdateUserProfileHandler(db *mongo.Database) gin.HandlerFunc {
 			return
 		}
 
-		// Check if user is admin
-		isAdmin, exists := c.Get("isAdmin")
-		if !exists {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Admin status not found"})
-			return
-		}
-
-		// Non-admins can only update their own profile
-		if !isAdmin.(bool) && authUserID != userID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to update this profile"})
-			return
+		// Only admins who can write users may update someone else's profile
+		if authUserID != userID {
+			if err := services.RequirePermission(c.Request.Context(), db, authUserID, models.PermUsersWrite); err != nil {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to update this profile"})
+				return
+			}
 		}
 
 		// Bind JSON input
@@ -83,6 +78,10 @@ func UpdateUserProfileHandler(db *mongo.Database) gin.HandlerFunc {
 		// Update profile
 		err = services.UpdateUserProfile(db, userID, &profileUpdate)
 		if err != nil {
+			if err.Error() == "unsupported locale" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
 			return
 		}
@@ -113,17 +112,12 @@ func UpdateUserProfilePictureHandler(db *mongo.Database) gin.HandlerFunc {
 			return
 		}
 
-		// Check if user is admin
-		isAdmin, exists := c.Get("isAdmin")
-		if !exists {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Admin status not found"})
-			return
-		}
-
-		// Non-admins can only update their own profile picture
-		if !isAdmin.(bool) && authUserID != userID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to update this profile picture"})
-			return
+		// Only admins who can write users may update someone else's profile picture
+		if authUserID != userID {
+			if err := services.RequirePermission(c.Request.Context(), db, authUserID, models.PermUsersWrite); err != nil {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Unauthorized to update this profile picture"})
+				return
+			}
 		}
 
 		file, err := c.FormFile("profile_pic")
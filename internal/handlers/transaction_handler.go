@@ -44,11 +44,30 @@ func GetUserTransactionsHandler(db *mongo.Database) gin.HandlerFunc {
 			return
 		}
 
-		isAdmin, _ := c.Get("isAdmin")
-		isAdminBool := isAdmin.(bool)
+		transactions, err := services.GetUserTransactions(c.Request.Context(), db, userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to fetch transactions: %v", err)})
+			return
+		}
+
+		c.JSON(http.StatusOK, transactions)
+	}
+}
 
-		transactions, err := services.GetUserTransactions(c.Request.Context(), db, userID, isAdminBool)
+func GetAllTransactionsHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
 		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		transactions, err := services.GetAllTransactions(c.Request.Context(), db, userID)
+		if err != nil {
+			if err.Error() == "permission denied" {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can view all transactions"})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to fetch transactions: %v", err)})
 			return
 		}
@@ -70,9 +89,6 @@ func GetContributionTransactionsHandler(db *mongo.Database) gin.HandlerFunc {
 			return
 		}
 
-		isAdmin, _ := c.Get("isAdmin")
-		isAdminBool := isAdmin.(bool)
-
 		contributionIDStr := c.Param("id")
 		contributionID, err := primitive.ObjectIDFromHex(contributionIDStr)
 		if err != nil {
@@ -80,7 +96,7 @@ func GetContributionTransactionsHandler(db *mongo.Database) gin.HandlerFunc {
 			return
 		}
 
-		transactions, err := services.GetContributionTransactions(c.Request.Context(), db, contributionID, userID, isAdminBool)
+		transactions, err := services.GetContributionTransactions(c.Request.Context(), db, contributionID, userID)
 		if err != nil {
 			switch err.Error() {
 			case "contribution not found":
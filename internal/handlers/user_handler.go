@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/Gerard-007/ajor_app/internal/services"
+	"github.com/Gerard-007/ajor_app/pkg/payment"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -21,29 +22,26 @@ func GetUserByIdHandler(db *mongo.Database) gin.HandlerFunc {
 			return
 		}
 
-		// Get authenticated user ID and admin status
-		authUserIDStr, _ := c.Get("userID")
-		isAdmin, _ := c.Get("isAdmin")
-		authUserID, err := primitive.ObjectIDFromHex(authUserIDStr.(string))
+		// Get authenticated user ID
+		authUserID, err := getAuthUserID(c)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
+			c.JSON(http.StatusUnauthorized, gin.H{
 				"status": "error",
-				"error":  "Invalid authenticated user ID",
-			})
-			return
-		}
-
-		// Authorization check: user can only access their own data unless admin
-		if !isAdmin.(bool) && authUserID != userID {
-			c.JSON(http.StatusForbidden, gin.H{
-				"status": "error",
-				"error":  "Unauthorized access",
+				"error":  err.Error(),
 			})
 			return
 		}
 
-		user, err := services.GetUserByID(db, userID)
+		// Users can only access their own data unless they can read users
+		user, err := services.GetUserByID(db, authUserID, userID)
 		if err != nil {
+			if err.Error() == "permission denied" {
+				c.JSON(http.StatusForbidden, gin.H{
+					"status": "error",
+					"error":  "Unauthorized access",
+				})
+				return
+			}
 			c.JSON(http.StatusNotFound, gin.H{
 				"status": "error",
 				"error":  err.Error(),
@@ -55,22 +53,20 @@ func GetUserByIdHandler(db *mongo.Database) gin.HandlerFunc {
 	}
 }
 
-func GetAllUsersHandler(db *mongo.Collection) gin.HandlerFunc {
+func GetAllUsersHandler(db *mongo.Database) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Check if user is admin
-		isAdmin, exists := c.Get("isAdmin")
-		if !exists {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Admin status not found"})
-			return
-		}
-		isAdminBool, ok := isAdmin.(bool)
-		if !ok || !isAdminBool {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can view all users"})
+		authUserID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
 
-		users, err := services.GetAllUsers(db)
+		users, err := services.GetAllUsers(c.Request.Context(), db, authUserID)
 		if err != nil {
+			if err.Error() == "permission denied" {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can view all users"})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users: " + err.Error()})
 			return
 		}
@@ -100,23 +96,6 @@ func UpdateUserHandler(db *mongo.Database) gin.HandlerFunc {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid authenticated user ID"})
 			return
 		}
-		isAdmin, exists := c.Get("isAdmin")
-		if !exists {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Admin status not found"})
-			return
-		}
-		isAdminBool, ok := isAdmin.(bool)
-		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid admin status"})
-			return
-		}
-
-		// Non-admins can only update their own user
-		if !isAdminBool && authUserID != id {
-			c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to update this user"})
-			return
-		}
-
 		// Parse user data from request body
 		var userUpdate services.UserUpdate
 		if err := c.ShouldBindJSON(&userUpdate); err != nil {
@@ -124,13 +103,18 @@ func UpdateUserHandler(db *mongo.Database) gin.HandlerFunc {
 			return
 		}
 
-		updatedUser, err := services.UpdateUser(db, id, &userUpdate, isAdminBool)
+		// Users can only update themselves unless they can write users
+		updatedUser, err := services.UpdateUser(db, authUserID, id, &userUpdate)
 		if err != nil {
+			if err.Error() == "permission denied" {
+				c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to update this user"})
+				return
+			}
 			if err.Error() == "user not found" {
 				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 				return
 			}
-			if err.Error() == "email already exists" || err.Error() == "username already exists" {
+			if err.Error() == "email already exists" || err.Error() == "username already exists" || err.Error() == "phone already exists" {
 				c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 				return
 			}
@@ -142,7 +126,9 @@ func UpdateUserHandler(db *mongo.Database) gin.HandlerFunc {
 	}
 }
 
-func DeleteUserHandler(db *mongo.Database) gin.HandlerFunc {
+// DeleteUserHandler closes a user's account on an admin's behalf. The body
+// may carry a bank_account to pay any remaining balance out to.
+func DeleteUserHandler(db *mongo.Database, pg payment.PaymentGateway) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Parse user ID
 		userIDStr := c.Param("id")
@@ -152,24 +138,30 @@ func DeleteUserHandler(db *mongo.Database) gin.HandlerFunc {
 			return
 		}
 
-		// Check if user is admin
-		isAdmin, exists := c.Get("isAdmin")
-		if !exists || !isAdmin.(bool) {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can delete users"})
+		authUserID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
 
-		// Delete user and profile
-		err = services.DeleteUser(db, userID)
-		if err != nil {
-			if err == mongo.ErrNoDocuments {
-				c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		var req closeAccountRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 				return
 			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		}
+
+		closed, err := services.CloseAccount(c.Request.Context(), db, pg, authUserID, userID, "", req.BankAccount)
+		if err != nil {
+			writeCloseAccountError(c, err)
+			return
+		}
+		if !closed {
+			c.JSON(http.StatusAccepted, gin.H{"message": "Account closure started; it completes once the balance payout settles"})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "User and profile deleted successfully"})
+		c.JSON(http.StatusOK, gin.H{"message": "User account closed successfully"})
 	}
 }
\ No newline at end of file
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Gerard-007/ajor_app/internal/services"
+	"github.com/Gerard-007/ajor_app/pkg/sms"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func SendPhoneVerificationHandler(db *mongo.Database, provider sms.Provider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		err = services.SendPhoneVerification(c.Request.Context(), db, provider, userID)
+		if err != nil {
+			switch {
+			case strings.Contains(err.Error(), "please wait"):
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			case strings.Contains(err.Error(), "already verified"), strings.Contains(err.Error(), "not found"):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification code"})
+			}
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Verification code sent"})
+	}
+}
+
+func VerifyPhoneHandler(db *mongo.Database) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := getAuthUserID(c)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		var request struct {
+			Code string `json:"code" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Code is required"})
+			return
+		}
+		err = services.VerifyPhone(c.Request.Context(), db, userID, request.Code)
+		if err != nil {
+			switch {
+			case strings.Contains(err.Error(), "too many attempts"):
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			case strings.Contains(err.Error(), "code"), strings.Contains(err.Error(), "already verified"), strings.Contains(err.Error(), "not found"):
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify phone number"})
+			}
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Phone number verified successfully"})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/Gerard-007/ajor_app/internal/models"
 	"github.com/Gerard-007/ajor_app/internal/repository"
@@ -77,6 +78,10 @@ func FundWalletHandler(db *mongo.Database, pg payment.PaymentGateway) gin.Handle
 
 		err = services.FundWallet(c.Request.Context(), db, userID, input.Amount, pg)
 		if err != nil {
+			if strings.Contains(err.Error(), "KYC limit") || strings.Contains(err.Error(), "not verified") {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to fund wallet: %v", err)})
 			return
 		}
@@ -101,20 +106,6 @@ func GetContributionWalletHandler(db *mongo.Database, pg payment.PaymentGateway)
 			return
 		}
 
-		// Extract and validate isAdmin from context
-		isAdmin, exists := c.Get("isAdmin")
-		if !exists {
-			log.Println("isAdmin not found in context")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
-			return
-		}
-		isAdminBool, ok := isAdmin.(bool)
-		if !ok {
-			log.Println("Invalid isAdmin type in context")
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
-			return
-		}
-
 		// Extract and validate contribution ID from URL
 		contributionIDStr := c.Param("id")
 		if contributionIDStr == "" {
@@ -130,7 +121,7 @@ func GetContributionWalletHandler(db *mongo.Database, pg payment.PaymentGateway)
 		}
 
 		// Fetch wallet using service
-		wallet, err := services.GetContributionWallet(c.Request.Context(), db, pg, contributionID, userID, isAdminBool)
+		wallet, err := services.GetContributionWallet(c.Request.Context(), db, pg, contributionID, userID)
 		if err != nil {
 			log.Printf("Failed to fetch contribution wallet: %v", err)
 			switch err.Error() {
@@ -165,12 +156,6 @@ func DeleteWalletHandler(db *mongo.Database, pg payment.PaymentGateway) gin.Hand
 			return
 		}
 
-		isAdmin, exists := c.Get("isAdmin")
-		if !exists {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Admin status not found"})
-			return
-		}
-
 		user, err := repository.GetUserByID(db.Collection("users"), userID)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
@@ -186,9 +171,11 @@ func DeleteWalletHandler(db *mongo.Database, pg payment.PaymentGateway) gin.Hand
 		// Check if wallet belongs to a contribution
 		var contribution models.Contribution
 		err = db.Collection("contributions").FindOne(c.Request.Context(), bson.M{"wallet_id": wallet.ID}).Decode(&contribution)
-		if err == nil && contribution.GroupAdmin != userID && !isAdmin.(bool) {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Only group admin or system admin can delete contribution wallet"})
-			return
+		if err == nil && contribution.GroupAdmin != userID {
+			if err := services.RequirePermission(c.Request.Context(), db, userID, models.PermWalletsWrite); err != nil {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Only group admin or system admin can delete contribution wallet"})
+				return
+			}
 		}
 		if err != nil && err != mongo.ErrNoDocuments {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to check contribution: %v", err)})
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BankAccount is where money leaving the platform is paid to.
+type BankAccount struct {
+	BankCode      string `json:"bank_code"`
+	AccountNumber string `json:"account_number"`
+}
+
+// AccountClosure is a closure waiting for the bank payout of the account's
+// balance to settle. The account is closed once it goes through, and
+// reopened if it fails.
+type AccountClosure struct {
+	RequestedAt  time.Time          `json:"requested_at" bson:"requested_at"`
+	RequestedBy  primitive.ObjectID `json:"requested_by" bson:"requested_by"`
+	WithdrawalID primitive.ObjectID `json:"withdrawal_id" bson:"withdrawal_id"`
+}
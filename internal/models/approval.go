@@ -12,6 +12,7 @@ const (
 	ApprovalPending  ApprovalStatus = "pending"
 	ApprovalApproved ApprovalStatus = "approved"
 	ApprovalRejected ApprovalStatus = "rejected"
+	ApprovalClosed   ApprovalStatus = "closed" // decided by the other approvers
 )
 
 type Approval struct {
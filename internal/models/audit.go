@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AuditEventType string
+
+const (
+	AuditLoginLockout   AuditEventType = "login_lockout"
+	AuditPINLockout     AuditEventType = "pin_lockout"
+	AuditRolesChanged   AuditEventType = "roles_changed"
+	AuditKYCReviewed    AuditEventType = "kyc_reviewed"
+	AuditAccountClosed  AuditEventType = "account_closed"
+	AuditAPIKeyCreated  AuditEventType = "api_key_created"
+	AuditAPIKeyRevoked  AuditEventType = "api_key_revoked"
+	AuditJobTriggered   AuditEventType = "job_triggered"
+	AuditWebhookSet     AuditEventType = "webhook_set"
+	AuditWebhookRemoved AuditEventType = "webhook_removed"
+)
+
+// AuditEvent records a security-relevant event for later review.
+type AuditEvent struct {
+	ID        primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
+	Type      AuditEventType         `json:"type" bson:"type"`
+	UserID    *primitive.ObjectID    `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	ActorID   *primitive.ObjectID    `json:"actor_id,omitempty" bson:"actor_id,omitempty"` // who made the change, when not the user themselves
+	Subject   string                 `json:"subject,omitempty" bson:"subject,omitempty"`   // what the event is about when there is no user, e.g. a login email
+	IP        string                 `json:"ip,omitempty" bson:"ip,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty" bson:"details,omitempty"`
+	CreatedAt time.Time              `json:"created_at" bson:"created_at"`
+}
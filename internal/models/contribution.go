@@ -8,12 +8,21 @@ import (
 
 type ContributionCycle string
 type ContributionType string
+type CycleUnit string
 
 const (
-	CycleDaily   ContributionCycle = "daily"
-	CycleWeekly  ContributionCycle = "weekly"
-	CycleMonthly ContributionCycle = "monthly"
-	CycleYearly  ContributionCycle = "yearly"
+	CycleDaily      ContributionCycle = "daily"
+	CycleWeekly     ContributionCycle = "weekly"
+	CycleMonthly    ContributionCycle = "monthly"
+	CycleYearly     ContributionCycle = "yearly"
+	CycleInterval   ContributionCycle = "interval"     // every CycleInterval days or weeks
+	CycleDayOfMonth ContributionCycle = "day_of_month" // on CycleMonthDay of each month
+	CycleWeekday    ContributionCycle = "weekday"      // on CycleWeekday, every CycleInterval weeks
+)
+
+const (
+	CycleUnitDay  CycleUnit = "day"
+	CycleUnitWeek CycleUnit = "week"
 )
 
 const (
@@ -26,17 +35,54 @@ type Contribution struct {
 	Name                    string               `json:"name" bson:"name"`
 	Description             string               `json:"description" bson:"description"`
 	Cycle                   ContributionCycle    `json:"cycle" bson:"cycle"`
+	CycleInterval           int                  `json:"cycle_interval,omitempty" bson:"cycle_interval,omitempty"`
+	CycleUnit               CycleUnit            `json:"cycle_unit,omitempty" bson:"cycle_unit,omitempty"`
+	CycleMonthDay           int                  `json:"cycle_month_day,omitempty" bson:"cycle_month_day,omitempty"`
+	CycleWeekday            string               `json:"cycle_weekday,omitempty" bson:"cycle_weekday,omitempty"`
+	CycleStartDate          time.Time            `json:"cycle_start_date" bson:"cycle_start_date"`
+	Timezone                string               `json:"timezone" bson:"timezone"`
+	RollHolidays            bool                 `json:"roll_holidays" bson:"roll_holidays"`
 	Amount                  float64              `json:"amount" bson:"amount"`
 	CycleCount              int                  `json:"cycle_count" bson:"cycle_count"`
 	CollectionDay           string               `json:"collection_day" bson:"collection_day"`
 	CollectionDeadline      time.Time            `json:"collection_deadline" bson:"collection_deadline"`
 	Type                    ContributionType     `json:"type" bson:"type"`
 	PenaltyAmount           float64              `json:"penalty_amount" bson:"penalty_amount"`
+	LoanInterestRate        float64              `json:"loan_interest_rate" bson:"loan_interest_rate"`
+	LoanLimitPercent        float64              `json:"loan_limit_percent" bson:"loan_limit_percent"`
+	MinReliabilityScore     float64              `json:"min_reliability_score" bson:"min_reliability_score"`
+	GuarantorsRequired      int                  `json:"guarantors_required" bson:"guarantors_required"`
+	GuarantorClaimAfter     int                  `json:"guarantor_claim_after" bson:"guarantor_claim_after"` // missed cycles before a guarantor can be claimed against
 	YetToCollectMembers     []primitive.ObjectID `json:"yet_to_collect_members" bson:"yet_to_collect_members"`
 	AlreadyCollectedMembers []primitive.ObjectID `json:"already_collected_members" bson:"already_collected_members"`
 	GroupAdmin              primitive.ObjectID   `json:"group_admin" bson:"group_admin"`
+	OrganisationID          *primitive.ObjectID  `json:"organisation_id,omitempty" bson:"organisation_id,omitempty"` // set when an organisation enrols and pays for members
 	WalletID                primitive.ObjectID   `json:"wallet_id" bson:"wallet_id"`
 	InviteCode              string               `json:"invite_code" bson:"invite_code"`
 	CreatedAt               time.Time            `json:"created_at" bson:"created_at"`
 	UpdatedAt               time.Time            `json:"updated_at" bson:"updated_at"`
-}
\ No newline at end of file
+}
+
+// ContributionUpdate holds the settings a group admin may change. Fields
+// left out of the request are nil and keep their current value.
+type ContributionUpdate struct {
+	Name                *string            `json:"name"`
+	Description         *string            `json:"description"`
+	Cycle               *ContributionCycle `json:"cycle"`
+	CycleInterval       *int               `json:"cycle_interval"`
+	CycleUnit           *CycleUnit         `json:"cycle_unit"`
+	CycleMonthDay       *int               `json:"cycle_month_day"`
+	CycleWeekday        *string            `json:"cycle_weekday"`
+	CycleStartDate      *time.Time         `json:"cycle_start_date"`
+	Timezone            *string            `json:"timezone"`
+	RollHolidays        *bool              `json:"roll_holidays"`
+	Amount              *float64           `json:"amount"`
+	CycleCount          *int               `json:"cycle_count"`
+	Type                *ContributionType  `json:"type"`
+	PenaltyAmount       *float64           `json:"penalty_amount"`
+	LoanInterestRate    *float64           `json:"loan_interest_rate"`
+	LoanLimitPercent    *float64           `json:"loan_limit_percent"`
+	MinReliabilityScore *float64           `json:"min_reliability_score"`
+	GuarantorsRequired  *int               `json:"guarantors_required"`
+	GuarantorClaimAfter *int               `json:"guarantor_claim_after"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type GuaranteeStatus string
+
+const (
+	GuaranteePending  GuaranteeStatus = "pending"
+	GuaranteeAccepted GuaranteeStatus = "accepted"
+	GuaranteeDeclined GuaranteeStatus = "declined"
+	GuaranteeClaimed  GuaranteeStatus = "claimed"
+)
+
+// Guarantee is a platform user's promise to cover a member's missed
+// contributions. ActiveFrom is set once the member is admitted to the group,
+// and arrears are counted from then.
+type Guarantee struct {
+	ID                 primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	ContributionID     primitive.ObjectID  `json:"contribution_id" bson:"contribution_id"`
+	MemberID           primitive.ObjectID  `json:"member_id" bson:"member_id"`
+	GuarantorID        primitive.ObjectID  `json:"guarantor_id" bson:"guarantor_id"`
+	Status             GuaranteeStatus     `json:"status" bson:"status"`
+	ActiveFrom         *time.Time          `json:"active_from,omitempty" bson:"active_from,omitempty"`
+	ClaimTransactionID *primitive.ObjectID `json:"claim_transaction_id,omitempty" bson:"claim_transaction_id,omitempty"`
+	ClaimedAmount      float64             `json:"claimed_amount" bson:"claimed_amount"`
+	CreatedAt          time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt          time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+// Arrears is how far a member is behind on a contribution since a given date.
+type Arrears struct {
+	ContributionID primitive.ObjectID `json:"contribution_id"`
+	MemberID       primitive.ObjectID `json:"member_id"`
+	Since          time.Time          `json:"since"`
+	CyclesDue      int                `json:"cycles_due"`
+	CyclesPaid     int                `json:"cycles_paid"`
+	CyclesMissed   int                `json:"cycles_missed"`
+	AmountOwed     float64            `json:"amount_owed"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Holiday is a public holiday on the platform calendar. Date is a calendar
+// day in YYYY-MM-DD form so it matches regardless of timezone.
+type Holiday struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Date      string             `json:"date" bson:"date"`
+	Name      string             `json:"name" bson:"name"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
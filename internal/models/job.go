@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type JobTrigger string
+
+const (
+	JobTriggerSchedule JobTrigger = "schedule"
+	JobTriggerCatchUp  JobTrigger = "catch_up" // a scheduled run missed while no instance was up
+	JobTriggerManual   JobTrigger = "manual"
+)
+
+type JobRunStatus string
+
+const (
+	JobRunRunning   JobRunStatus = "running"
+	JobRunSucceeded JobRunStatus = "succeeded"
+	JobRunFailed    JobRunStatus = "failed"    // still failing after the last retry
+	JobRunAbandoned JobRunStatus = "abandoned" // the instance running it stopped
+)
+
+// JobRun records one run of a background job. Errors holds the error of
+// each failed attempt and of items that failed without stopping the run.
+type JobRun struct {
+	ID          primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	Job         string              `json:"job" bson:"job"`
+	Instance    string              `json:"instance" bson:"instance"`
+	Trigger     JobTrigger          `json:"trigger" bson:"trigger"`
+	TriggeredBy *primitive.ObjectID `json:"triggered_by,omitempty" bson:"triggered_by,omitempty"`
+	ScheduledAt *time.Time          `json:"scheduled_at,omitempty" bson:"scheduled_at,omitempty"`
+	Status      JobRunStatus        `json:"status" bson:"status"`
+	Attempts    int                 `json:"attempts" bson:"attempts"`
+	Items       int                 `json:"items" bson:"items"`
+	Errors      []string            `json:"errors,omitempty" bson:"errors,omitempty"`
+	StartedAt   time.Time           `json:"started_at" bson:"started_at"`
+	FinishedAt  *time.Time          `json:"finished_at,omitempty" bson:"finished_at,omitempty"`
+}
+
+// JobLease lets one instance at a time run a job. LastScheduledAt is the
+// latest scheduled time a run was started for, so the same tick is not run
+// again by another instance, and missed ticks can be caught up.
+type JobLease struct {
+	Job             string     `json:"job" bson:"_id"`
+	Owner           string     `json:"owner" bson:"owner"`
+	ExpiresAt       time.Time  `json:"expires_at" bson:"expires_at"`
+	LastScheduledAt *time.Time `json:"last_scheduled_at,omitempty" bson:"last_scheduled_at,omitempty"`
+}
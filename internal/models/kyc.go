@@ -0,0 +1,100 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// KYCTier is how far a user's identity has been verified. Higher tiers
+// may hold and move more money.
+type KYCTier int
+
+type KYCRequestStatus string
+type KYCDocumentType string
+
+const (
+	KYCTierNone KYCTier = 0 // registered, phone not yet verified
+	KYCTier1    KYCTier = 1 // phone verified
+	KYCTier2    KYCTier = 2 // BVN validated
+	KYCTier3    KYCTier = 3 // ID document and address verified
+)
+
+const (
+	KYCRequestPending  KYCRequestStatus = "pending"
+	KYCRequestApproved KYCRequestStatus = "approved"
+	KYCRequestRejected KYCRequestStatus = "rejected"
+)
+
+const (
+	KYCDocumentID             KYCDocumentType = "id_document"
+	KYCDocumentProofOfAddress KYCDocumentType = "proof_of_address"
+)
+
+// KYCLimits caps what a tier may do, in naira. Zero means no limit.
+type KYCLimits struct {
+	MaxBalance      float64 `json:"max_balance"`
+	DailyInflow     float64 `json:"daily_inflow"`
+	DailyOutflow    float64 `json:"daily_outflow"`
+	MaxContribution float64 `json:"max_contribution"`
+}
+
+// KYCTierLimits lists the limits of each tier users can transact at.
+var KYCTierLimits = map[KYCTier]KYCLimits{
+	KYCTier1: {MaxBalance: 300000, DailyInflow: 50000, DailyOutflow: 50000, MaxContribution: 50000},
+	KYCTier2: {MaxBalance: 500000, DailyInflow: 200000, DailyOutflow: 200000, MaxContribution: 200000},
+	KYCTier3: {MaxBalance: 0, DailyInflow: 5000000, DailyOutflow: 5000000, MaxContribution: 5000000},
+}
+
+// KYCRequiredDocuments lists the documents an upgrade to each tier needs.
+// Tier 2 needs none; instead the BVN given at registration must be
+// verified with the payment provider.
+var KYCRequiredDocuments = map[KYCTier][]KYCDocumentType{
+	KYCTier2: {},
+	KYCTier3: {KYCDocumentID, KYCDocumentProofOfAddress},
+}
+
+// EffectiveKYCTier returns the user's tier. Users whose phone is not
+// verified, including after changing it, cannot transact at any tier, and
+// users whose BVN is not verified stay at tier 1.
+func (u *User) EffectiveKYCTier() KYCTier {
+	if !u.Verified {
+		return KYCTierNone
+	}
+	if u.KYCTier < KYCTier1 || u.BVNVerifiedAt == nil {
+		return KYCTier1
+	}
+	return u.KYCTier
+}
+
+type KYCDocument struct {
+	Type       KYCDocumentType `json:"type" bson:"type"`
+	FileName   string          `json:"file_name" bson:"file_name"`
+	Path       string          `json:"-" bson:"path"`
+	UploadedAt time.Time       `json:"uploaded_at" bson:"uploaded_at"`
+}
+
+// KYCRequest is a user's request to move up a tier, waiting for a
+// compliance review.
+type KYCRequest struct {
+	ID         primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	UserID     primitive.ObjectID  `json:"user_id" bson:"user_id"`
+	Tier       KYCTier             `json:"tier" bson:"tier"`
+	Documents  []KYCDocument       `json:"documents" bson:"documents"`
+	Status     KYCRequestStatus    `json:"status" bson:"status"`
+	ReviewerID *primitive.ObjectID `json:"reviewer_id,omitempty" bson:"reviewer_id,omitempty"`
+	ReviewNote string              `json:"review_note,omitempty" bson:"review_note,omitempty"`
+	ReviewedAt *time.Time          `json:"reviewed_at,omitempty" bson:"reviewed_at,omitempty"`
+	CreatedAt  time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+// KYCStatus is a user's tier, its limits and how much of today's limits
+// they have used.
+type KYCStatus struct {
+	Tier           KYCTier     `json:"tier"`
+	Limits         KYCLimits   `json:"limits"`
+	InflowToday    float64     `json:"inflow_today"`
+	OutflowToday   float64     `json:"outflow_today"`
+	PendingRequest *KYCRequest `json:"pending_request,omitempty"`
+}
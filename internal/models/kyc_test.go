@@ -0,0 +1,30 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEffectiveKYCTier(t *testing.T) {
+	verifiedAt := time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		user User
+		want KYCTier
+	}{
+		{"phone not verified", User{KYCTier: KYCTier3, BVNVerifiedAt: &verifiedAt}, KYCTierNone},
+		{"new verified user", User{Verified: true}, KYCTier1},
+		{"tier 2 with a verified BVN", User{Verified: true, KYCTier: KYCTier2, BVNVerifiedAt: &verifiedAt}, KYCTier2},
+		{"tier 2 without a verified BVN", User{Verified: true, KYCTier: KYCTier2}, KYCTier1},
+		{"tier 3 with a verified BVN", User{Verified: true, KYCTier: KYCTier3, BVNVerifiedAt: &verifiedAt}, KYCTier3},
+		{"tier 3 without a verified BVN", User{Verified: true, KYCTier: KYCTier3}, KYCTier1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.user.EffectiveKYCTier())
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type LoanStatus string
+type InstallmentStatus string
+
+const (
+	LoanPending  LoanStatus = "pending"
+	LoanActive   LoanStatus = "active"
+	LoanRejected LoanStatus = "rejected"
+	LoanRepaid   LoanStatus = "repaid"
+)
+
+const (
+	InstallmentPending InstallmentStatus = "pending"
+	InstallmentPaid    InstallmentStatus = "paid"
+	InstallmentOverdue InstallmentStatus = "overdue"
+)
+
+// LoanInstallment is one scheduled repayment. Interest is the flat interest
+// share of the installment; LateInterest accrues while it is overdue.
+type LoanInstallment struct {
+	Number                int               `json:"number" bson:"number"`
+	DueDate               time.Time         `json:"due_date" bson:"due_date"`
+	Principal             float64           `json:"principal" bson:"principal"`
+	Interest              float64           `json:"interest" bson:"interest"`
+	LateInterest          float64           `json:"late_interest" bson:"late_interest"`
+	LateInterestAccruedAt *time.Time        `json:"-" bson:"late_interest_accrued_at,omitempty"` // late interest is charged up to here
+	Paid                  float64           `json:"paid" bson:"paid"`
+	Status                InstallmentStatus `json:"status" bson:"status"`
+	PaidAt                *time.Time        `json:"paid_at,omitempty" bson:"paid_at,omitempty"`
+}
+
+// Loan is money lent from a contribution wallet to one of its members.
+// InterestRate is a flat monthly percentage agreed by the group.
+type Loan struct {
+	ID               primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ContributionID   primitive.ObjectID `json:"contribution_id" bson:"contribution_id"`
+	BorrowerID       primitive.ObjectID `json:"borrower_id" bson:"borrower_id"`
+	TransactionID    primitive.ObjectID `json:"transaction_id" bson:"transaction_id"`
+	Principal        float64            `json:"principal" bson:"principal"`
+	InterestRate     float64            `json:"interest_rate" bson:"interest_rate"`
+	InstallmentCount int                `json:"installment_count" bson:"installment_count"`
+	Reason           string             `json:"reason" bson:"reason"`
+	Schedule         []LoanInstallment  `json:"schedule" bson:"schedule"`
+	AccruedInterest  float64            `json:"accrued_interest" bson:"accrued_interest"`
+	AmountRepaid     float64            `json:"amount_repaid" bson:"amount_repaid"`
+	Outstanding      float64            `json:"outstanding" bson:"outstanding"`
+	Overdue          bool               `json:"overdue" bson:"overdue"`
+	Status           LoanStatus         `json:"status" bson:"status"`
+	DisbursedAt      *time.Time         `json:"disbursed_at,omitempty" bson:"disbursed_at,omitempty"`
+	CreatedAt        time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at" bson:"updated_at"`
+}
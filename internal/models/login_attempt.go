@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// LoginAttempts tracks failed password logins for an email address,
+// whether or not an account uses it, so lockouts do not reveal which
+// accounts exist.
+type LoginAttempts struct {
+	Email          string     `json:"email" bson:"_id"`
+	FailedAttempts int        `json:"failed_attempts" bson:"failed_attempts"`
+	LockedUntil    *time.Time `json:"locked_until,omitempty" bson:"locked_until,omitempty"`
+	LastFailedAt   time.Time  `json:"last_failed_at" bson:"last_failed_at"`
+	LastIP         string     `json:"last_ip" bson:"last_ip"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MFA is a user's TOTP enrollment, keyed by user ID. It is created
+// disabled at setup and enabled once the user proves their app works.
+type MFA struct {
+	UserID             primitive.ObjectID `json:"user_id" bson:"_id"`
+	Secret             string             `json:"-" bson:"secret"`
+	Enabled            bool               `json:"enabled" bson:"enabled"`
+	RecoveryCodeHashes []string           `json:"-" bson:"recovery_code_hashes"`
+	LastUsedStep       int64              `json:"-" bson:"last_used_step"` // stops a code being replayed within its window
+	EnabledAt          *time.Time         `json:"enabled_at,omitempty" bson:"enabled_at,omitempty"`
+	CreatedAt          time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt          time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// MFAChallenge is a password login waiting for its second factor. Only a
+// hash of the challenge token handed to the client is stored.
+type MFAChallenge struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID     primitive.ObjectID `json:"user_id" bson:"user_id"`
+	TokenHash  string             `json:"-" bson:"token_hash"`
+	DeviceName string             `json:"device_name" bson:"device_name"`
+	IP         string             `json:"ip" bson:"ip"`
+	Attempts   int                `json:"attempts" bson:"attempts"`
+	ExpiresAt  time.Time          `json:"expires_at" bson:"expires_at"`
+	UsedAt     *time.Time         `json:"used_at,omitempty" bson:"used_at,omitempty"`
+	CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// MFAStatus describes a user's two-factor setup.
+type MFAStatus struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// MFAEnrollment is returned when a user starts TOTP setup.
+type MFAEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+// LoginResult is either a finished sign-in or a pending second step.
+type LoginResult struct {
+	Tokens      *AuthTokens
+	MFARequired bool
+	MFAToken    string
+	ExpiresIn   int64
+}
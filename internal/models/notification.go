@@ -3,6 +3,7 @@ package models
 import (
 	"time"
 
+	"github.com/Gerard-007/ajor_app/pkg/messages"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -15,11 +16,48 @@ const (
 )
 
 type Notification struct {
+	ID             primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	UserID         primitive.ObjectID   `json:"user_id" bson:"user_id"`
+	ContributionID primitive.ObjectID   `json:"contribution_id" bson:"contribution_id,omitempty"`
+	Message        string               `json:"message" bson:"message"`
+	Template       string               `json:"template,omitempty" bson:"template,omitempty"` // key in pkg/messages the message was rendered from
+	Key            string               `json:"-" bson:"key,omitempty"`                       // set to send a notification at most once, however often it is created
+	Params         messages.Params      `json:"-" bson:"-"`
+	Type           NotificationType     `json:"type" bson:"type"`
+	Category       NotificationCategory `json:"category" bson:"category"`
+	Read           bool                 `json:"read" bson:"read"`
+	ReadAt         *time.Time           `json:"read_at,omitempty" bson:"read_at,omitempty"`
+	CreatedAt      time.Time            `json:"created_at" bson:"created_at"`
+}
+
+// NotificationPage is one page of a user's inbox, newest first. NextCursor
+// fetches the page after it and is empty on the last page.
+type NotificationPage struct {
+	Notifications []*Notification `json:"notifications"`
+	NextCursor    string          `json:"next_cursor,omitempty"`
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending DeliveryStatus = "pending"
+	DeliverySent    DeliveryStatus = "sent"
+	DeliveryFailed  DeliveryStatus = "failed"  // gave up after the last retry
+	DeliverySkipped DeliveryStatus = "skipped" // the user has no address on the channel
+)
+
+// NotificationDelivery tracks sending a notification on one channel.
+// Deliveries held for quiet hours or a digest are pending until then.
+type NotificationDelivery struct {
 	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	NotificationID primitive.ObjectID `json:"notification_id" bson:"notification_id"`
 	UserID         primitive.ObjectID `json:"user_id" bson:"user_id"`
-	ContributionID primitive.ObjectID `json:"contribution_id" bson:"contribution_id,omitempty"`
-	Message        string             `json:"message" bson:"message"`
-	Type           NotificationType   `json:"type" bson:"type"`
-	Read           bool               `json:"read" bson:"read"`
+	Channel        string             `json:"channel" bson:"channel"`
+	Status         DeliveryStatus     `json:"status" bson:"status"`
+	Digest         bool               `json:"digest,omitempty" bson:"digest,omitempty"` // sent with the user's daily digest
+	Attempts       int                `json:"attempts" bson:"attempts"`
+	LastError      string             `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	NextAttemptAt  time.Time          `json:"next_attempt_at" bson:"next_attempt_at"`
+	SentAt         *time.Time         `json:"sent_at,omitempty" bson:"sent_at,omitempty"`
 	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
-}
\ No newline at end of file
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// NotificationCategory groups notifications so users can choose how each
+// kind reaches them.
+type NotificationCategory string
+
+const (
+	CategoryPaymentReminders NotificationCategory = "payment_reminders"
+	CategoryPayouts          NotificationCategory = "payouts"
+	CategoryMembership       NotificationCategory = "membership"
+	// CategorySecurity alerts cannot be muted: they ignore quiet hours and
+	// digests and always go out on at least one channel.
+	CategorySecurity NotificationCategory = "security"
+)
+
+// NotificationCategories lists every category users can set preferences for.
+var NotificationCategories = []NotificationCategory{
+	CategoryPaymentReminders,
+	CategoryPayouts,
+	CategoryMembership,
+	CategorySecurity,
+}
+
+// QuietHours is a daily window, as HH:MM in the user's timezone, during
+// which notifications are held back until it ends. Start after End spans
+// midnight.
+type QuietHours struct {
+	Start string `json:"start" bson:"start"`
+	End   string `json:"end" bson:"end"`
+}
+
+// CategoryPreference sets the channels a category is delivered on. With
+// Digest set, its notifications are sent together once a day instead.
+type CategoryPreference struct {
+	Channels []string `json:"channels" bson:"channels"`
+	Digest   bool     `json:"digest" bson:"digest"`
+}
+
+// NotificationPreferences control how notifications reach a user outside
+// the inbox, which always gets every notification.
+type NotificationPreferences struct {
+	UserID     primitive.ObjectID                          `json:"user_id" bson:"_id"`
+	Timezone   string                                      `json:"timezone" bson:"timezone"`
+	QuietHours *QuietHours                                 `json:"quiet_hours,omitempty" bson:"quiet_hours,omitempty"`
+	DigestTime string                                      `json:"digest_time" bson:"digest_time"`
+	Categories map[NotificationCategory]CategoryPreference `json:"categories" bson:"categories"`
+	UpdatedAt  time.Time                                   `json:"updated_at" bson:"updated_at"`
+}
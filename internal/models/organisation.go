@@ -0,0 +1,100 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type APIKeyScope string
+
+// Scopes an organisation API key can be granted.
+const (
+	APIScopeStaffRead         APIKeyScope = "staff:read"
+	APIScopeContributionsRead APIKeyScope = "contributions:read"
+	APIScopeMembersWrite      APIKeyScope = "members:write"
+	APIScopePaymentsWrite     APIKeyScope = "payments:write"
+	APIScopeWalletRead        APIKeyScope = "wallet:read"
+)
+
+var APIKeyScopes = []APIKeyScope{
+	APIScopeStaffRead,
+	APIScopeContributionsRead,
+	APIScopeMembersWrite,
+	APIScopePaymentsWrite,
+	APIScopeWalletRead,
+}
+
+// Organisation is a cooperative or employer that enrols its staff into
+// contributions and funds them from its own wallet, e.g. by payroll.
+// Users join its staff with the staff code, which is their consent to be
+// enrolled and paid for.
+type Organisation struct {
+	ID        primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	Name      string               `json:"name" bson:"name"`
+	OwnerID   primitive.ObjectID   `json:"owner_id" bson:"owner_id"`
+	StaffCode string               `json:"staff_code" bson:"staff_code"`
+	Staff     []primitive.ObjectID `json:"staff" bson:"staff"`
+	WalletID  primitive.ObjectID   `json:"wallet_id" bson:"wallet_id"`
+	// WebhookURL receives the organisation's wallet events, signed with
+	// WebhookSecret, which is kept encrypted.
+	WebhookURL    string    `json:"webhook_url,omitempty" bson:"webhook_url,omitempty"`
+	WebhookSecret string    `json:"-" bson:"webhook_secret,omitempty"`
+	CreatedAt     time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+// StaffMember is what an organisation's systems see of its staff.
+type StaffMember struct {
+	UserID   primitive.ObjectID `json:"user_id"`
+	Username string             `json:"username"`
+	Verified bool               `json:"verified"`
+	KYCTier  KYCTier            `json:"kyc_tier"`
+}
+
+// APIKey lets an organisation's own systems call the partner API. Only a
+// hash of the key is stored; the signing secret is kept encrypted because
+// request signatures have to be recomputed from it.
+type APIKey struct {
+	ID               primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	OrganisationID   primitive.ObjectID `json:"organisation_id" bson:"organisation_id"`
+	Name             string             `json:"name" bson:"name"`
+	Prefix           string             `json:"prefix" bson:"prefix"` // public part of the key, used to look it up
+	KeyHash          string             `json:"-" bson:"key_hash"`
+	SigningSecret    string             `json:"-" bson:"signing_secret"`
+	Scopes           []APIKeyScope      `json:"scopes" bson:"scopes"`
+	AllowedIPs       []string           `json:"allowed_ips" bson:"allowed_ips"` // IPs or CIDR ranges; empty allows any
+	RequireSignature bool               `json:"require_signature" bson:"require_signature"`
+	UsageCount       int64              `json:"usage_count" bson:"usage_count"`
+	LastUsedAt       *time.Time         `json:"last_used_at,omitempty" bson:"last_used_at,omitempty"`
+	LastUsedIP       string             `json:"last_used_ip,omitempty" bson:"last_used_ip,omitempty"`
+	CreatedBy        primitive.ObjectID `json:"created_by" bson:"created_by"`
+	CreatedAt        time.Time          `json:"created_at" bson:"created_at"`
+	RevokedAt        *time.Time         `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+}
+
+// HasScope reports whether the key was granted the scope.
+func (k *APIKey) HasScope(scope APIKeyScope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyUsage counts the requests made with a key on one day.
+type APIKeyUsage struct {
+	ID       string             `json:"-" bson:"_id"`
+	APIKeyID primitive.ObjectID `json:"api_key_id" bson:"api_key_id"`
+	Date     string             `json:"date" bson:"date"` // YYYY-MM-DD in UTC
+	Count    int64              `json:"count" bson:"count"`
+}
+
+// CreatedAPIKey is returned once, when a key is created. The key and the
+// signing secret cannot be retrieved again.
+type CreatedAPIKey struct {
+	APIKey        *APIKey `json:"api_key"`
+	Key           string  `json:"key"`
+	SigningSecret string  `json:"signing_secret"`
+}
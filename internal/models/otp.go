@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OTPPurpose string
+
+const (
+	OTPPhoneVerification OTPPurpose = "phone_verification"
+	OTPPINReset          OTPPurpose = "pin_reset"
+)
+
+// OTP is the one active code for a user and purpose. Only an HMAC of the
+// code is stored; sending a new code replaces the old one.
+type OTP struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID      primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Purpose     OTPPurpose         `json:"purpose" bson:"purpose"`
+	Destination string             `json:"destination" bson:"destination"`
+	CodeHash    string             `json:"-" bson:"code_hash"`
+	Attempts    int                `json:"attempts" bson:"attempts"`
+	ExpiresAt   time.Time          `json:"expires_at" bson:"expires_at"`
+	SentAt      time.Time          `json:"sent_at" bson:"sent_at"`
+	ConsumedAt  *time.Time         `json:"consumed_at,omitempty" bson:"consumed_at,omitempty"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+}
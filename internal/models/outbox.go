@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OutboxKind string
+
+const (
+	OutboxKindNotification OutboxKind = "notification"
+	OutboxKindEvent        OutboxKind = "event"
+	OutboxKindWebhook      OutboxKind = "webhook"
+)
+
+type OutboxStatus string
+
+const (
+	OutboxPending   OutboxStatus = "pending"
+	OutboxPublished OutboxStatus = "published"
+	OutboxFailed    OutboxStatus = "failed" // gave up after the last retry
+)
+
+// OutboxEntry is a side effect of a change, written in the same database
+// transaction as the change so that it happens if and only if the change
+// is committed. The relay publishes it afterwards, at least once; its ID
+// lets whoever receives it recognise a repeat.
+type OutboxEntry struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Kind          OutboxKind         `json:"kind" bson:"kind"`
+	Notification  *Notification      `json:"notification,omitempty" bson:"notification,omitempty"`
+	Event         *OutboxEvent       `json:"event,omitempty" bson:"event,omitempty"`
+	Webhook       *OutboxWebhook     `json:"webhook,omitempty" bson:"webhook,omitempty"`
+	Status        OutboxStatus       `json:"status" bson:"status"`
+	Attempts      int                `json:"attempts" bson:"attempts"`
+	LastError     string             `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	NextAttemptAt time.Time          `json:"next_attempt_at" bson:"next_attempt_at"`
+	PublishedAt   *time.Time         `json:"published_at,omitempty" bson:"published_at,omitempty"`
+	CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// OutboxEvent is a real-time event waiting to be published. Data is kept
+// as JSON so it reaches clients exactly as it was queued.
+type OutboxEvent struct {
+	Type           string               `json:"type" bson:"type"`
+	UserIDs        []primitive.ObjectID `json:"user_ids,omitempty" bson:"user_ids,omitempty"`
+	ContributionID primitive.ObjectID   `json:"contribution_id,omitempty" bson:"contribution_id,omitempty"`
+	Data           string               `json:"data,omitempty" bson:"data,omitempty"`
+}
+
+// OutboxWebhook is an event waiting to be posted to an organisation's
+// webhook.
+type OutboxWebhook struct {
+	OrganisationID primitive.ObjectID `json:"organisation_id" bson:"organisation_id"`
+	Type           string             `json:"type" bson:"type"`
+	Data           string             `json:"data,omitempty" bson:"data,omitempty"`
+}
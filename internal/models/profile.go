@@ -12,6 +12,7 @@ type Profile struct {
 	Bio         string             `json:"bio" bson:"bio"`
 	Location    string             `json:"location" bson:"location"`
 	ProfilePic  string             `json:"profile_pic" bson:"profile_pic"`
+	Locale      string             `json:"locale" bson:"locale"` // language of notifications; empty means English
 	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
 }
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReliabilityScore summarises a user's payment history across every
+// contribution. Score runs from 0 to 100; users with no history get a
+// neutral score.
+type ReliabilityScore struct {
+	UserID             primitive.ObjectID `json:"user_id" bson:"_id"`
+	OnTimePayments     int                `json:"on_time_payments" bson:"on_time_payments"`
+	LatePayments       int                `json:"late_payments" bson:"late_payments"`
+	Penalties          int                `json:"penalties" bson:"penalties"`
+	CompletedRotations int                `json:"completed_rotations" bson:"completed_rotations"`
+	Defaults           int                `json:"defaults" bson:"defaults"`
+	Score              float64            `json:"score" bson:"score"`
+	UpdatedAt          time.Time          `json:"updated_at" bson:"updated_at"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ReminderKind string
+
+const (
+	ReminderBeforeDeadline ReminderKind = "before_deadline" // Offset before the deadline
+	ReminderOverdue        ReminderKind = "overdue"
+	ReminderEscalation     ReminderKind = "escalation" // sent to the group admin
+)
+
+// ContributionReminder records that a member was reminded about one round
+// of a contribution, the round being identified by its deadline. Each kind
+// and offset is sent at most once per member per round.
+type ContributionReminder struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ContributionID primitive.ObjectID `json:"contribution_id" bson:"contribution_id"`
+	MemberID       primitive.ObjectID `json:"member_id" bson:"member_id"`
+	Deadline       time.Time          `json:"deadline" bson:"deadline"`
+	Kind           ReminderKind       `json:"kind" bson:"kind"`
+	Offset         string             `json:"offset,omitempty" bson:"offset"`
+	SentAt         time.Time          `json:"sent_at" bson:"sent_at"`
+}
@@ -0,0 +1,72 @@
+package models
+
+// Role is a platform administration role. Roles are separate from group
+// admin rights, which come from running a contribution.
+type Role string
+
+// Permission is a single platform administration right.
+type Permission string
+
+const (
+	RoleSupportAgent      Role = "support_agent"      // read-only access for answering user queries
+	RoleFinanceOperator   Role = "finance_operator"   // money movement and the holiday calendar
+	RoleComplianceOfficer Role = "compliance_officer" // personal data, audit trail and KYC reviews
+	RoleSuperAdmin        Role = "super_admin"        // everything, including assigning roles
+)
+
+const (
+	PermUsersRead         Permission = "users:read"
+	PermUsersWrite        Permission = "users:write"
+	PermContributionsRead Permission = "contributions:read"
+	PermTransactionsRead  Permission = "transactions:read"
+	PermWalletsRead       Permission = "wallets:read"
+	PermWalletsWrite      Permission = "wallets:write"
+	PermHolidaysWrite     Permission = "holidays:write"
+	PermPIIRead           Permission = "pii:read"
+	PermAuditRead         Permission = "audit:read"
+	PermRolesAssign       Permission = "roles:assign"
+	PermKYCReview         Permission = "kyc:review"
+	PermJobsRead          Permission = "jobs:read"
+	PermJobsRun           Permission = "jobs:run"
+)
+
+// RolePermissions lists what each role may do.
+var RolePermissions = map[Role][]Permission{
+	RoleSupportAgent: {
+		PermUsersRead, PermContributionsRead, PermTransactionsRead, PermWalletsRead,
+	},
+	RoleFinanceOperator: {
+		PermUsersRead, PermContributionsRead, PermTransactionsRead, PermWalletsRead,
+		PermWalletsWrite, PermHolidaysWrite, PermJobsRead,
+	},
+	RoleComplianceOfficer: {
+		PermUsersRead, PermContributionsRead, PermTransactionsRead, PermWalletsRead,
+		PermPIIRead, PermAuditRead, PermKYCReview,
+	},
+	RoleSuperAdmin: {
+		PermUsersRead, PermUsersWrite, PermContributionsRead, PermTransactionsRead,
+		PermWalletsRead, PermWalletsWrite, PermHolidaysWrite, PermPIIRead,
+		PermAuditRead, PermRolesAssign, PermKYCReview, PermJobsRead, PermJobsRun,
+	},
+}
+
+// EffectiveRoles returns the user's roles. Accounts flagged IsAdmin before
+// roles existed count as super admins until they are given roles.
+func (u *User) EffectiveRoles() []Role {
+	if len(u.Roles) == 0 && u.IsAdmin {
+		return []Role{RoleSuperAdmin}
+	}
+	return u.Roles
+}
+
+// HasPermission reports whether any of the user's roles grants p.
+func (u *User) HasPermission(p Permission) bool {
+	for _, role := range u.EffectiveRoles() {
+		for _, granted := range RolePermissions[role] {
+			if granted == p {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserHasPermission(t *testing.T) {
+	tests := []struct {
+		name       string
+		user       User
+		permission Permission
+		want       bool
+	}{
+		{"no roles", User{}, PermUsersRead, false},
+		{"support agent reads users", User{Roles: []Role{RoleSupportAgent}}, PermUsersRead, true},
+		{"support agent cannot move money", User{Roles: []Role{RoleSupportAgent}}, PermWalletsWrite, false},
+		{"support agent cannot see PII", User{Roles: []Role{RoleSupportAgent}}, PermPIIRead, false},
+		{"finance operator moves money", User{Roles: []Role{RoleFinanceOperator}}, PermWalletsWrite, true},
+		{"finance operator cannot assign roles", User{Roles: []Role{RoleFinanceOperator}}, PermRolesAssign, false},
+		{"compliance officer reviews KYC", User{Roles: []Role{RoleComplianceOfficer}}, PermKYCReview, true},
+		{"compliance officer cannot run jobs", User{Roles: []Role{RoleComplianceOfficer}}, PermJobsRun, false},
+		{"roles combine", User{Roles: []Role{RoleSupportAgent, RoleComplianceOfficer}}, PermAuditRead, true},
+		{"super admin assigns roles", User{Roles: []Role{RoleSuperAdmin}}, PermRolesAssign, true},
+		{"legacy admin is a super admin", User{IsAdmin: true}, PermRolesAssign, true},
+		{"roles replace the legacy flag", User{IsAdmin: true, Roles: []Role{RoleSupportAgent}}, PermRolesAssign, false},
+		{"unknown role grants nothing", User{Roles: []Role{"janitor"}}, PermUsersRead, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.user.HasPermission(tt.permission))
+		})
+	}
+}
+
+func TestSuperAdminHasEveryPermission(t *testing.T) {
+	for role, permissions := range RolePermissions {
+		for _, permission := range permissions {
+			t.Run(string(role)+"/"+string(permission), func(t *testing.T) {
+				assert.Contains(t, RolePermissions[RoleSuperAdmin], permission)
+			})
+		}
+	}
+}
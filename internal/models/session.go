@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session is one signed-in device. Only a hash of its current refresh token
+// is stored; each refresh replaces it and keeps the old hash so that a
+// rotated-out token can be recognised when it is presented again.
+type Session struct {
+	ID                 primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID             primitive.ObjectID `json:"user_id" bson:"user_id"`
+	RefreshTokenHash   string             `json:"-" bson:"refresh_token_hash"`
+	RotatedTokenHashes []string           `json:"-" bson:"rotated_token_hashes,omitempty"`
+	DeviceName         string             `json:"device_name" bson:"device_name"`
+	IP                 string             `json:"ip" bson:"ip"`
+	LastSeenAt         time.Time          `json:"last_seen_at" bson:"last_seen_at"`
+	ExpiresAt          time.Time          `json:"expires_at" bson:"expires_at"`
+	RevokedAt          *time.Time         `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+	RevokedReason      string             `json:"revoked_reason,omitempty" bson:"revoked_reason,omitempty"`
+	Current            bool               `json:"current" bson:"-"`
+	CreatedAt          time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt          time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// AuthTokens is what a client receives when it signs in or refreshes.
+type AuthTokens struct {
+	AccessToken  string             `json:"access_token"`
+	RefreshToken string             `json:"refresh_token"`
+	ExpiresIn    int64              `json:"expires_in"` // seconds until the access token expires
+	SessionID    primitive.ObjectID `json:"session_id"`
+}
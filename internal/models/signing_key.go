@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// SigningKey is a stored key access tokens are signed with. The private
+// key is PKCS #8 DER, base64 encoded and envelope-encrypted like other
+// sensitive fields; it never leaves the server.
+type SigningKey struct {
+	ID          string     `json:"kid" bson:"_id"`
+	Algorithm   string     `json:"alg" bson:"algorithm"`
+	PrivateKey  string     `json:"-" bson:"private_key"`
+	CreatedAt   time.Time  `json:"created_at" bson:"created_at"`
+	ActivatesAt time.Time  `json:"activates_at" bson:"activates_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" bson:"expires_at,omitempty"`
+}
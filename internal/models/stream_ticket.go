@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StreamTicket lets a client that cannot set headers, such as a browser
+// opening an EventSource or WebSocket, open the event stream once without
+// putting its access token in the URL. Only a hash of the ticket is stored.
+type StreamTicket struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID         primitive.ObjectID `json:"user_id" bson:"user_id"`
+	SessionID      primitive.ObjectID `json:"session_id" bson:"session_id"`
+	TicketHash     string             `json:"-" bson:"ticket_hash"`
+	TokenExpiresAt time.Time          `json:"token_expires_at" bson:"token_expires_at"` // the stream ends when the access token it was issued for expires
+	ExpiresAt      time.Time          `json:"expires_at" bson:"expires_at"`
+	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
+}
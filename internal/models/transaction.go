@@ -11,9 +11,13 @@ type TransactionStatus string
 type PaymentMethod string
 
 const (
-	TransactionContribution TransactionType = "contribution"
-	TransactionPayout       TransactionType = "payout"
-	TransactionWallet       TransactionType = "wallet"
+	TransactionContribution   TransactionType = "contribution"
+	TransactionPayout         TransactionType = "payout"
+	TransactionWallet         TransactionType = "wallet"
+	TransactionLoan           TransactionType = "loan"
+	TransactionLoanRepayment  TransactionType = "loan_repayment"
+	TransactionGuaranteeClaim TransactionType = "guarantee_claim"
+	TransactionWithdrawal     TransactionType = "withdrawal"
 )
 
 const (
@@ -26,6 +30,7 @@ const (
 	PaymentBankTransfer PaymentMethod = "bank_transfer"
 	PaymentMobileMoney  PaymentMethod = "mobile_money"
 	PaymentCash         PaymentMethod = "cash"
+	PaymentPayroll      PaymentMethod = "payroll"
 	PaymentWallet         PaymentMethod = "wallet"
 )
 
@@ -39,5 +44,9 @@ type Transaction struct {
 	PaymentMethod  PaymentMethod      `json:"payment_method" bson:"payment_method"`
 	Status         TransactionStatus  `json:"status" bson:"status"`
 	ContributionID primitive.ObjectID `json:"contribution_id" bson:"contribution_id"`
+	Late           bool               `json:"late,omitempty" bson:"late,omitempty"`
+	Penalty        float64            `json:"penalty,omitempty" bson:"penalty,omitempty"`
+	Reference      string             `json:"reference,omitempty" bson:"reference,omitempty"`     // caller's reference, e.g. a payroll run
+	TransferID     string             `json:"transfer_id,omitempty" bson:"transfer_id,omitempty"` // payment provider's ID of a bank payout
 	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
 }
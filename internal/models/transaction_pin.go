@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TransactionPIN is the bcrypt hash of the PIN a user enters to authorise
+// debits, with the state of its failed-attempt lockout.
+type TransactionPIN struct {
+	UserID         primitive.ObjectID `json:"user_id" bson:"_id"`
+	PINHash        string             `json:"-" bson:"pin_hash"`
+	FailedAttempts int                `json:"failed_attempts" bson:"failed_attempts"`
+	LockedUntil    *time.Time         `json:"locked_until,omitempty" bson:"locked_until,omitempty"`
+	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at" bson:"updated_at"`
+}
@@ -7,26 +7,38 @@ import (
 )
 
 type User struct {
-	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Username  string             `bson:"username" json:"username,omitempty"`
-	Email     string             `json:"email" bson:"email"`
-	Password  string             `json:"password" bson:"password"`
-	IsAdmin   bool               `json:"is_admin" bson:"is_admin"`
-	Phone     string             `json:"phone" bson:"phone"`
-	BVN       string             `json:"bvn" bson:"bvn,omitempty"`
-	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Username      string             `bson:"username" json:"username,omitempty"`
+	Email         string             `json:"email" bson:"email"`
+	Password      string             `json:"password" bson:"password"`
+	IsAdmin       bool               `json:"is_admin" bson:"is_admin"` // superseded by Roles; see EffectiveRoles
+	Roles         []Role             `json:"roles" bson:"roles,omitempty"`
+	Phone         string             `json:"phone" bson:"phone"`             // encrypted at rest; see repository.SealUserPII
+	PhoneIndex    string             `json:"-" bson:"phone_index,omitempty"` // blind index for lookups by phone
+	Verified      bool               `json:"verified" bson:"verified"`
+	BVN           string             `json:"bvn" bson:"bvn,omitempty"`                                   // encrypted at rest
+	BVNIndex      string             `json:"-" bson:"bvn_index,omitempty"`                               // blind index for lookups by BVN
+	KYCTier       KYCTier            `json:"kyc_tier" bson:"kyc_tier"`                                   // see EffectiveKYCTier
+	BVNVerifiedAt *time.Time         `json:"bvn_verified_at,omitempty" bson:"bvn_verified_at,omitempty"` // when the BVN was matched with the payment provider
+	ClosedAt      *time.Time         `json:"closed_at,omitempty" bson:"closed_at,omitempty"`
+	Closure       *AccountClosure    `json:"closure,omitempty" bson:"closure,omitempty"` // set while the account is being closed
+	CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at" bson:"updated_at"`
 }
 
 type UserResponse struct {
-	ID        primitive.ObjectID `json:"_id"`
-	Username  string             `json:"username"`
-	Email     string             `json:"email"`
-	IsAdmin   bool               `json:"is_admin"`
-	Phone     string             `json:"phone"`
-	BVN       string             `json:"bvn"`
-	CreatedAt time.Time          `json:"created_at"`
-	UpdatedAt time.Time          `json:"updated_at"`
-	Profile   *Profile           `json:"profile"`
-	Wallet    *Wallet            `json:"wallet"`
+	ID          primitive.ObjectID `json:"_id"`
+	Username    string             `json:"username"`
+	Email       string             `json:"email"`
+	IsAdmin     bool               `json:"is_admin"`
+	Roles       []Role             `json:"roles"`
+	Phone       string             `json:"phone"`
+	Verified    bool               `json:"verified"`
+	BVN         string             `json:"bvn"`
+	KYCTier     KYCTier            `json:"kyc_tier"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	Profile     *Profile           `json:"profile"`
+	Wallet      *Wallet            `json:"wallet"`
+	Reliability *ReliabilityScore  `json:"reliability"`
 }
\ No newline at end of file
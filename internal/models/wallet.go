@@ -11,6 +11,7 @@ type WalletType string
 const (
 	WalletTypeUser         WalletType = "user"
 	WalletTypeContribution WalletType = "contribution"
+	WalletTypeOrganisation WalletType = "organisation"
 )
 
 type Wallet struct {
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AnonymiseUser strips a closed account of its contact details and
+// credentials. The BVN and KYC tier are kept, encrypted, since financial
+// records must stay attributable to a verified identity.
+func AnonymiseUser(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, closedAt time.Time) error {
+	placeholder := "closed-" + userID.Hex()
+	update := bson.M{
+		"$set": bson.M{
+			"username":   placeholder,
+			"email":      placeholder + "@closed.invalid",
+			"password":   "",
+			"phone":      "",
+			"verified":   false,
+			"is_admin":   false,
+			"roles":      []models.Role{},
+			"closed_at":  closedAt,
+			"updated_at": closedAt,
+		},
+		"$unset": bson.M{"phone_index": "", "closure": ""},
+	}
+	result, err := db.Collection("users").UpdateOne(ctx, bson.M{"_id": userID, "closed_at": bson.M{"$exists": false}}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("account already closed")
+	}
+	return nil
+}
+
+// StartAccountClosure marks an open account as being closed. It fails if
+// the account is already closed or being closed.
+func StartAccountClosure(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, closure *models.AccountClosure) error {
+	filter := bson.M{
+		"_id":       userID,
+		"closed_at": bson.M{"$exists": false},
+		"closure":   bson.M{"$exists": false},
+	}
+	update := bson.M{"$set": bson.M{"closure": closure, "updated_at": time.Now()}}
+	result, err := db.Collection("users").UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("account already closed or being closed")
+	}
+	return nil
+}
+
+// CancelAccountClosure reopens an account that was being closed.
+func CancelAccountClosure(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) error {
+	_, err := db.Collection("users").UpdateOne(ctx,
+		bson.M{"_id": userID, "closed_at": bson.M{"$exists": false}},
+		bson.M{"$unset": bson.M{"closure": ""}, "$set": bson.M{"updated_at": time.Now()}},
+	)
+	return err
+}
+
+// GetUserClosingWith returns the user whose closure waits on the given
+// withdrawal, or nil if there is none.
+func GetUserClosingWith(ctx context.Context, db *mongo.Database, withdrawalID primitive.ObjectID) (*models.User, error) {
+	var user models.User
+	err := db.Collection("users").FindOne(ctx, bson.M{"closure.withdrawal_id": withdrawalID}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := openUserPII(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetClosingUsers returns every account that is being closed.
+func GetClosingUsers(ctx context.Context, db *mongo.Database) ([]*models.User, error) {
+	cursor, err := db.Collection("users").Find(ctx, bson.M{"closure": bson.M{"$exists": true}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []*models.User
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return nil, err
+		}
+		if err := openUserPII(&user); err != nil {
+			return nil, err
+		}
+		users = append(users, &user)
+	}
+	return users, cursor.Err()
+}
+
+// DeleteUserPersonalData removes the records of a closed account that are
+// not needed for financial record keeping.
+func DeleteUserPersonalData(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, email string) error {
+	byUser := bson.M{"user_id": userID}
+	byID := bson.M{"_id": userID}
+	deletions := []struct {
+		collection string
+		filter     bson.M
+	}{
+		{"profiles", byUser},
+		{"notifications", byUser},
+		{"notification_deliveries", byUser},
+		{"otps", byUser},
+		{"password_resets", byUser},
+		{"mfa_challenges", byUser},
+		{"contribution_reminders", bson.M{"member_id": userID}},
+		{"notification_preferences", byID},
+		{"mfa", byID},
+		{"transaction_pins", byID},
+		{"reliability_scores", byID},
+		{"login_attempts", bson.M{"_id": email}},
+	}
+	for _, deletion := range deletions {
+		if _, err := db.Collection(deletion.collection).DeleteMany(ctx, deletion.filter); err != nil {
+			return fmt.Errorf("failed to delete %s: %v", deletion.collection, err)
+		}
+	}
+	_, err := db.Collection("organisations").UpdateMany(ctx, bson.M{"staff": userID}, bson.M{"$pull": bson.M{"staff": userID}})
+	return err
+}
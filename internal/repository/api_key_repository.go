@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func CreateAPIKey(ctx context.Context, db *mongo.Database, key *models.APIKey) error {
+	key.CreatedAt = time.Now()
+	result, err := db.Collection("api_keys").InsertOne(ctx, key)
+	if err != nil {
+		return err
+	}
+	key.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetAPIKeyByPrefix finds a key, revoked or not, by the public part of it.
+func GetAPIKeyByPrefix(ctx context.Context, db *mongo.Database, prefix string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := db.Collection("api_keys").FindOne(ctx, bson.M{"prefix": prefix}).Decode(&key)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("api key not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func GetAPIKeys(ctx context.Context, db *mongo.Database, organisationID primitive.ObjectID) ([]*models.APIKey, error) {
+	var keys []*models.APIKey
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := db.Collection("api_keys").Find(ctx, bson.M{"organisation_id": organisationID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey revokes one of the organisation's keys.
+func RevokeAPIKey(ctx context.Context, db *mongo.Database, organisationID, keyID primitive.ObjectID) error {
+	result, err := db.Collection("api_keys").UpdateOne(ctx,
+		bson.M{"_id": keyID, "organisation_id": organisationID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("api key not found")
+	}
+	return nil
+}
+
+// RecordAPIKeyUsage counts a request made with the key, both in total and
+// for the day.
+func RecordAPIKeyUsage(ctx context.Context, db *mongo.Database, keyID primitive.ObjectID, ip string, at time.Time) error {
+	_, err := db.Collection("api_keys").UpdateOne(ctx,
+		bson.M{"_id": keyID},
+		bson.M{
+			"$inc": bson.M{"usage_count": 1},
+			"$set": bson.M{"last_used_at": at, "last_used_ip": ip},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	date := at.UTC().Format("2006-01-02")
+	_, err = db.Collection("api_key_usage").UpdateOne(ctx,
+		bson.M{"_id": keyID.Hex() + ":" + date},
+		bson.M{
+			"$inc":         bson.M{"count": 1},
+			"$setOnInsert": bson.M{"api_key_id": keyID, "date": date},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// GetAPIKeyUsage returns the key's daily request counts since the given
+// date, oldest first.
+func GetAPIKeyUsage(ctx context.Context, db *mongo.Database, keyID primitive.ObjectID, since time.Time) ([]*models.APIKeyUsage, error) {
+	usage := []*models.APIKeyUsage{}
+	filter := bson.M{"api_key_id": keyID, "date": bson.M{"$gte": since.UTC().Format("2006-01-02")}}
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}})
+	cursor, err := db.Collection("api_key_usage").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err := cursor.All(ctx, &usage); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// EnsureOrganisationIndexes creates the unique indexes API keys and staff
+// codes are looked up by, and the one that stops a payment reference being
+// paid twice.
+func EnsureOrganisationIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("api_keys").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "prefix", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("organisations").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "staff_code", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("request_signatures").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key_id", Value: 1}, {Key: "signature", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("transactions").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "from_wallet", Value: 1}, {Key: "reference", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"reference": bson.M{"$exists": true}}),
+	})
+	return err
+}
+
+// RecordRequestSignature remembers a signature used with an API key until
+// expiresAt. Recording the same one again fails with a duplicate key error.
+func RecordRequestSignature(ctx context.Context, db *mongo.Database, keyID primitive.ObjectID, signature string, expiresAt time.Time) error {
+	_, err := db.Collection("request_signatures").InsertOne(ctx, bson.M{
+		"key_id":     keyID,
+		"signature":  signature,
+		"expires_at": expiresAt,
+	})
+	return err
+}
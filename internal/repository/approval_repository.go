@@ -19,8 +19,11 @@ func CreateApproval(ctx context.Context, db *mongo.Database, approval *models.Ap
 	return err
 }
 
+// UpdateApproval records the decision on a pending approval. It fails if
+// the approval has already been decided, so that only one of concurrent
+// decisions takes effect.
 func UpdateApproval(ctx context.Context, db *mongo.Database, approvalID primitive.ObjectID, status models.ApprovalStatus) error {
-	filter := bson.M{"_id": approvalID}
+	filter := bson.M{"_id": approvalID, "status": models.ApprovalPending}
 	update := bson.M{
 		"$set": bson.M{
 			"status":     status,
@@ -32,7 +35,7 @@ func UpdateApproval(ctx context.Context, db *mongo.Database, approvalID primitiv
 		return err
 	}
 	if result.MatchedCount == 0 {
-		return errors.New("approval not found")
+		return errors.New("approval already processed")
 	}
 	return nil
 }
@@ -55,4 +58,38 @@ func GetPendingApprovals(ctx context.Context, db *mongo.Database, approverID pri
 		approvals = append(approvals, &approval)
 	}
 	return approvals, nil
-}
\ No newline at end of file
+}
+
+func GetApprovalsByTransaction(ctx context.Context, db *mongo.Database, transactionID primitive.ObjectID) ([]*models.Approval, error) {
+	var approvals []*models.Approval
+	cursor, err := db.Collection("approvals").Find(ctx, bson.M{"transaction_id": transactionID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var approval models.Approval
+		if err := cursor.Decode(&approval); err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, &approval)
+	}
+	return approvals, cursor.Err()
+}
+
+// CloseApprovals marks every still-pending approval for a transaction as
+// closed once the transaction has been decided.
+func CloseApprovals(ctx context.Context, db *mongo.Database, transactionID primitive.ObjectID) error {
+	filter := bson.M{
+		"transaction_id": transactionID,
+		"status":         models.ApprovalPending,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     models.ApprovalClosed,
+			"updated_at": time.Now(),
+		},
+	}
+	_, err := db.Collection("approvals").UpdateMany(ctx, filter, update)
+	return err
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func CreateAuditEvent(ctx context.Context, db *mongo.Database, event *models.AuditEvent) error {
+	event.ID = primitive.NewObjectID()
+	event.CreatedAt = time.Now()
+	_, err := db.Collection("audit_events").InsertOne(ctx, event)
+	return err
+}
+
+// GetAuditEvents returns the most recent events, optionally of one type.
+func GetAuditEvents(ctx context.Context, db *mongo.Database, eventType models.AuditEventType, limit int64) ([]*models.AuditEvent, error) {
+	filter := bson.M{}
+	if eventType != "" {
+		filter["type"] = eventType
+	}
+	opts := options.Find().SetSort(bson.M{"created_at": -1}).SetLimit(limit)
+	cursor, err := db.Collection("audit_events").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	var events []*models.AuditEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
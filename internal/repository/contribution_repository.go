@@ -122,20 +122,46 @@ func GetContributionsByUser(ctx context.Context, db *mongo.Database, userID prim
 	return contributions, nil
 }
 
+// GetContributionsByGroupAdmin lists the contributions the user administers.
+func GetContributionsByGroupAdmin(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) ([]*models.Contribution, error) {
+	var contributions []*models.Contribution
+	cursor, err := db.Collection("contributions").Find(ctx, bson.M{"group_admin": userID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err := cursor.All(ctx, &contributions); err != nil {
+		return nil, err
+	}
+	return contributions, nil
+}
+
 func UpdateContribution(ctx context.Context, db *mongo.Database, id primitive.ObjectID, contribution *models.Contribution) error {
 	filter := bson.M{"_id": id}
 	update := bson.M{
 		"$set": bson.M{
-			"name":                contribution.Name,
-			"description":         contribution.Description,
-			"cycle":               contribution.Cycle,
-			"amount":              contribution.Amount,
-			"cycle_count":         contribution.CycleCount,
-			"collection_day":      contribution.CollectionDay,
-			"collection_deadline": contribution.CollectionDeadline,
-			"type":                contribution.Type,
-			"penalty_amount":      contribution.PenaltyAmount,
-			"updated_at":          time.Now(),
+			"name":                  contribution.Name,
+			"description":           contribution.Description,
+			"cycle":                 contribution.Cycle,
+			"cycle_interval":        contribution.CycleInterval,
+			"cycle_unit":            contribution.CycleUnit,
+			"cycle_month_day":       contribution.CycleMonthDay,
+			"cycle_weekday":         contribution.CycleWeekday,
+			"cycle_start_date":      contribution.CycleStartDate,
+			"timezone":              contribution.Timezone,
+			"roll_holidays":         contribution.RollHolidays,
+			"amount":                contribution.Amount,
+			"cycle_count":           contribution.CycleCount,
+			"collection_day":        contribution.CollectionDay,
+			"collection_deadline":   contribution.CollectionDeadline,
+			"type":                  contribution.Type,
+			"penalty_amount":        contribution.PenaltyAmount,
+			"loan_interest_rate":    contribution.LoanInterestRate,
+			"loan_limit_percent":    contribution.LoanLimitPercent,
+			"min_reliability_score": contribution.MinReliabilityScore,
+			"guarantors_required":   contribution.GuarantorsRequired,
+			"guarantor_claim_after": contribution.GuarantorClaimAfter,
+			"updated_at":            time.Now(),
 		},
 	}
 	result, err := db.Collection("contributions").UpdateOne(ctx, filter, update)
@@ -215,3 +241,69 @@ func MarkMemberCollected(ctx context.Context, db *mongo.Database, contributionID
 	}
 	return nil
 }
+
+func UpdateCollectionDeadline(ctx context.Context, db *mongo.Database, contributionID primitive.ObjectID, deadline time.Time) error {
+	filter := bson.M{"_id": contributionID}
+	update := bson.M{
+		"$set": bson.M{
+			"collection_deadline": deadline,
+			"updated_at":          time.Now(),
+		},
+	}
+	result, err := db.Collection("contributions").UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("contribution not found")
+	}
+	return nil
+}
+
+func GetContributionsWithDeadlineBefore(ctx context.Context, db *mongo.Database, before time.Time) ([]*models.Contribution, error) {
+	var contributions []*models.Contribution
+	cursor, err := db.Collection("contributions").Find(ctx, bson.M{"collection_deadline": bson.M{"$lt": before}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var contribution models.Contribution
+		if err := cursor.Decode(&contribution); err != nil {
+			return nil, err
+		}
+		contributions = append(contributions, &contribution)
+	}
+	return contributions, cursor.Err()
+}
+
+// SetContributionOrganisation links a contribution to the organisation
+// that enrols and pays for its members.
+func SetContributionOrganisation(ctx context.Context, db *mongo.Database, contributionID, organisationID primitive.ObjectID) error {
+	result, err := db.Collection("contributions").UpdateOne(ctx,
+		bson.M{"_id": contributionID},
+		bson.M{"$set": bson.M{"organisation_id": organisationID, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("contribution not found")
+	}
+	return nil
+}
+
+// GetContributionsByOrganisation lists the contributions linked to the
+// organisation.
+func GetContributionsByOrganisation(ctx context.Context, db *mongo.Database, organisationID primitive.ObjectID) ([]*models.Contribution, error) {
+	var contributions []*models.Contribution
+	cursor, err := db.Collection("contributions").Find(ctx, bson.M{"organisation_id": organisationID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err := cursor.All(ctx, &contributions); err != nil {
+		return nil, err
+	}
+	return contributions, nil
+}
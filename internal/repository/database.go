@@ -35,4 +35,24 @@ func InitDatabase() (*mongo.Database, error) {
 
 	database := client.Database("ajor_app_db")
 	return database, nil
-}
\ No newline at end of file
+}
+
+// WithTransaction runs fn in a transaction, committing what it wrote
+// through the context it is given only if it returns nil. Transactions
+// need MongoDB to run as a replica set; a single node one will do. If ctx
+// is already in a transaction fn joins it, and it is committed with the
+// outer one.
+func WithTransaction(ctx context.Context, db *mongo.Database, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+	session, err := db.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(ctx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(ctx)
+	})
+	return err
+}
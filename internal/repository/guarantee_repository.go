@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func CreateGuarantee(ctx context.Context, db *mongo.Database, guarantee *models.Guarantee) error {
+	guarantee.CreatedAt = time.Now()
+	guarantee.UpdatedAt = time.Now()
+	result, err := db.Collection("guarantees").InsertOne(ctx, guarantee)
+	if err != nil {
+		return err
+	}
+	guarantee.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func GetGuaranteeByID(ctx context.Context, db *mongo.Database, id primitive.ObjectID) (*models.Guarantee, error) {
+	var guarantee models.Guarantee
+	err := db.Collection("guarantees").FindOne(ctx, bson.M{"_id": id}).Decode(&guarantee)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("guarantee not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &guarantee, nil
+}
+
+func GetGuaranteeByClaimTransaction(ctx context.Context, db *mongo.Database, transactionID primitive.ObjectID) (*models.Guarantee, error) {
+	var guarantee models.Guarantee
+	err := db.Collection("guarantees").FindOne(ctx, bson.M{"claim_transaction_id": transactionID}).Decode(&guarantee)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("guarantee not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &guarantee, nil
+}
+
+func GetGuarantees(ctx context.Context, db *mongo.Database, filter bson.M) ([]*models.Guarantee, error) {
+	var guarantees []*models.Guarantee
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := db.Collection("guarantees").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var guarantee models.Guarantee
+		if err := cursor.Decode(&guarantee); err != nil {
+			return nil, err
+		}
+		guarantees = append(guarantees, &guarantee)
+	}
+	return guarantees, cursor.Err()
+}
+
+func UpdateGuarantee(ctx context.Context, db *mongo.Database, guarantee *models.Guarantee) error {
+	guarantee.UpdatedAt = time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"status":               guarantee.Status,
+			"active_from":          guarantee.ActiveFrom,
+			"claim_transaction_id": guarantee.ClaimTransactionID,
+			"claimed_amount":       guarantee.ClaimedAmount,
+			"updated_at":           guarantee.UpdatedAt,
+		},
+	}
+	result, err := db.Collection("guarantees").UpdateOne(ctx, bson.M{"_id": guarantee.ID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("guarantee not found")
+	}
+	return nil
+}
+
+// ActivateGuarantees starts the liability period of every accepted guarantee
+// for a member once they are admitted to the contribution.
+func ActivateGuarantees(ctx context.Context, db *mongo.Database, contributionID, memberID primitive.ObjectID, at time.Time) error {
+	filter := bson.M{
+		"contribution_id": contributionID,
+		"member_id":       memberID,
+		"status":          models.GuaranteeAccepted,
+	}
+	update := bson.M{"$set": bson.M{"active_from": at, "updated_at": time.Now()}}
+	_, err := db.Collection("guarantees").UpdateMany(ctx, filter, update)
+	return err
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func UpsertHoliday(ctx context.Context, db *mongo.Database, holiday *models.Holiday) error {
+	now := time.Now()
+	filter := bson.M{"date": holiday.Date}
+	update := bson.M{
+		"$set":         bson.M{"name": holiday.Name, "updated_at": now},
+		"$setOnInsert": bson.M{"created_at": now},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	return db.Collection("holidays").FindOneAndUpdate(ctx, filter, update, opts).Decode(holiday)
+}
+
+func GetHolidays(ctx context.Context, db *mongo.Database) ([]*models.Holiday, error) {
+	var holidays []*models.Holiday
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}})
+	cursor, err := db.Collection("holidays").Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var holiday models.Holiday
+		if err := cursor.Decode(&holiday); err != nil {
+			return nil, err
+		}
+		holidays = append(holidays, &holiday)
+	}
+	return holidays, cursor.Err()
+}
+
+func DeleteHoliday(ctx context.Context, db *mongo.Database, id primitive.ObjectID) error {
+	result, err := db.Collection("holidays").DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return errors.New("holiday not found")
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// jobRunRetention is how long run records are kept.
+const jobRunRetention = 30 * 24 * time.Hour
+
+func EnsureJobIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("job_runs").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "job", Value: 1}, {Key: "started_at", Value: -1}}},
+		{
+			Keys:    bson.D{{Key: "started_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(jobRunRetention.Seconds())),
+		},
+	})
+	return err
+}
+
+// AcquireJobLease takes the job's lease for owner until now+ttl if no one
+// holds it. With scheduledAt set it also fails if a run has already been
+// started for that scheduled time or a later one, and records it as the
+// latest.
+func AcquireJobLease(ctx context.Context, db *mongo.Database, job, owner string, now time.Time, ttl time.Duration, scheduledAt *time.Time) (bool, error) {
+	conditions := []bson.M{
+		{"_id": job},
+		{"expires_at": bson.M{"$lte": now}},
+	}
+	set := bson.M{"owner": owner, "expires_at": now.Add(ttl)}
+	if scheduledAt != nil {
+		conditions = append(conditions, bson.M{"$or": []bson.M{
+			{"last_scheduled_at": bson.M{"$exists": false}},
+			{"last_scheduled_at": bson.M{"$lt": *scheduledAt}},
+		}})
+		set["last_scheduled_at"] = *scheduledAt
+	}
+	result, err := db.Collection("job_leases").UpdateOne(ctx, bson.M{"$and": conditions}, bson.M{"$set": set}, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// Someone else holds the lease, or has run this scheduled time
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0 || result.UpsertedCount > 0, nil
+}
+
+// RenewJobLease extends a lease owner still holds. It reports false if the
+// lease has been lost.
+func RenewJobLease(ctx context.Context, db *mongo.Database, job, owner string, expiresAt time.Time) (bool, error) {
+	result, err := db.Collection("job_leases").UpdateOne(ctx,
+		bson.M{"_id": job, "owner": owner},
+		bson.M{"$set": bson.M{"expires_at": expiresAt}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// ReleaseJobLease frees a lease owner holds so the job can run again.
+func ReleaseJobLease(ctx context.Context, db *mongo.Database, job, owner string, now time.Time) error {
+	_, err := db.Collection("job_leases").UpdateOne(ctx,
+		bson.M{"_id": job, "owner": owner},
+		bson.M{"$set": bson.M{"expires_at": now}})
+	return err
+}
+
+func GetJobLease(ctx context.Context, db *mongo.Database, job string) (*models.JobLease, error) {
+	var lease models.JobLease
+	err := db.Collection("job_leases").FindOne(ctx, bson.M{"_id": job}).Decode(&lease)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("job lease not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &lease, nil
+}
+
+func GetJobLeases(ctx context.Context, db *mongo.Database) ([]*models.JobLease, error) {
+	var leases []*models.JobLease
+	cursor, err := db.Collection("job_leases").Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var lease models.JobLease
+		if err := cursor.Decode(&lease); err != nil {
+			return nil, err
+		}
+		leases = append(leases, &lease)
+	}
+	return leases, cursor.Err()
+}
+
+func CreateJobRun(ctx context.Context, db *mongo.Database, run *models.JobRun) error {
+	result, err := db.Collection("job_runs").InsertOne(ctx, run)
+	if err != nil {
+		return err
+	}
+	run.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func UpdateJobRun(ctx context.Context, db *mongo.Database, run *models.JobRun) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":      run.Status,
+			"attempts":    run.Attempts,
+			"items":       run.Items,
+			"errors":      run.Errors,
+			"finished_at": run.FinishedAt,
+		},
+	}
+	_, err := db.Collection("job_runs").UpdateOne(ctx, bson.M{"_id": run.ID}, update)
+	return err
+}
+
+// AbandonJobRuns marks runs of a job still recorded as running as
+// abandoned. It is called by the new lease holder, so any such run belongs
+// to an instance that stopped before finishing it.
+func AbandonJobRuns(ctx context.Context, db *mongo.Database, job string, now time.Time) (int64, error) {
+	result, err := db.Collection("job_runs").UpdateMany(ctx,
+		bson.M{"job": job, "status": models.JobRunRunning},
+		bson.M{"$set": bson.M{"status": models.JobRunAbandoned, "finished_at": now}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// GetJobRuns returns up to limit runs matching filter, newest first.
+func GetJobRuns(ctx context.Context, db *mongo.Database, filter bson.M, limit int64) ([]*models.JobRun, error) {
+	runs := []*models.JobRun{}
+	opts := options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}}).SetLimit(limit)
+	cursor, err := db.Collection("job_runs").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var run models.JobRun
+		if err := cursor.Decode(&run); err != nil {
+			return nil, err
+		}
+		runs = append(runs, &run)
+	}
+	return runs, cursor.Err()
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func CreateKYCRequest(ctx context.Context, db *mongo.Database, request *models.KYCRequest) error {
+	request.CreatedAt = time.Now()
+	request.UpdatedAt = time.Now()
+	result, err := db.Collection("kyc_requests").InsertOne(ctx, request)
+	if err != nil {
+		return err
+	}
+	request.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func GetKYCRequestByID(ctx context.Context, db *mongo.Database, id primitive.ObjectID) (*models.KYCRequest, error) {
+	var request models.KYCRequest
+	err := db.Collection("kyc_requests").FindOne(ctx, bson.M{"_id": id}).Decode(&request)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("kyc request not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// GetPendingKYCRequest returns the user's request waiting for review.
+func GetPendingKYCRequest(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) (*models.KYCRequest, error) {
+	var request models.KYCRequest
+	filter := bson.M{"user_id": userID, "status": models.KYCRequestPending}
+	err := db.Collection("kyc_requests").FindOne(ctx, filter).Decode(&request)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("kyc request not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// GetKYCRequests lists requests oldest first, so reviewers work through
+// the queue in order.
+func GetKYCRequests(ctx context.Context, db *mongo.Database, filter bson.M) ([]*models.KYCRequest, error) {
+	var requests []*models.KYCRequest
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := db.Collection("kyc_requests").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var request models.KYCRequest
+		if err := cursor.Decode(&request); err != nil {
+			return nil, err
+		}
+		requests = append(requests, &request)
+	}
+	return requests, cursor.Err()
+}
+
+// ReviewKYCRequest records a reviewer's decision. It fails if the request
+// was already reviewed, so two reviewers cannot both decide it.
+func ReviewKYCRequest(ctx context.Context, db *mongo.Database, id, reviewerID primitive.ObjectID, status models.KYCRequestStatus, note string) error {
+	now := time.Now()
+	filter := bson.M{"_id": id, "status": models.KYCRequestPending}
+	update := bson.M{"$set": bson.M{
+		"status":      status,
+		"reviewer_id": reviewerID,
+		"review_note": note,
+		"reviewed_at": now,
+		"updated_at":  now,
+	}}
+	result, err := db.Collection("kyc_requests").UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("kyc request already reviewed")
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func CreateLoan(ctx context.Context, db *mongo.Database, loan *models.Loan) error {
+	loan.CreatedAt = time.Now()
+	loan.UpdatedAt = time.Now()
+	result, err := db.Collection("loans").InsertOne(ctx, loan)
+	if err != nil {
+		return err
+	}
+	loan.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func GetLoanByID(ctx context.Context, db *mongo.Database, id primitive.ObjectID) (*models.Loan, error) {
+	var loan models.Loan
+	err := db.Collection("loans").FindOne(ctx, bson.M{"_id": id}).Decode(&loan)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("loan not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &loan, nil
+}
+
+func GetLoanByTransactionID(ctx context.Context, db *mongo.Database, transactionID primitive.ObjectID) (*models.Loan, error) {
+	var loan models.Loan
+	err := db.Collection("loans").FindOne(ctx, bson.M{"transaction_id": transactionID}).Decode(&loan)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("loan not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &loan, nil
+}
+
+func GetLoans(ctx context.Context, db *mongo.Database, filter bson.M) ([]*models.Loan, error) {
+	var loans []*models.Loan
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := db.Collection("loans").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var loan models.Loan
+		if err := cursor.Decode(&loan); err != nil {
+			return nil, err
+		}
+		loans = append(loans, &loan)
+	}
+	return loans, cursor.Err()
+}
+
+func UpdateLoan(ctx context.Context, db *mongo.Database, loan *models.Loan) error {
+	loan.UpdatedAt = time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"schedule":         loan.Schedule,
+			"accrued_interest": loan.AccruedInterest,
+			"amount_repaid":    loan.AmountRepaid,
+			"outstanding":      loan.Outstanding,
+			"overdue":          loan.Overdue,
+			"status":           loan.Status,
+			"disbursed_at":     loan.DisbursedAt,
+			"updated_at":       loan.UpdatedAt,
+		},
+	}
+	result, err := db.Collection("loans").UpdateOne(ctx, bson.M{"_id": loan.ID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("loan not found")
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func GetLoginAttempts(ctx context.Context, db *mongo.Database, email string) (*models.LoginAttempts, error) {
+	var attempts models.LoginAttempts
+	err := db.Collection("login_attempts").FindOne(ctx, bson.M{"_id": email}).Decode(&attempts)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("login attempts not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attempts, nil
+}
+
+// RecordLoginFailure counts a failed login and returns the updated record.
+func RecordLoginFailure(ctx context.Context, db *mongo.Database, email, ip string) (*models.LoginAttempts, error) {
+	update := bson.M{
+		"$inc": bson.M{"failed_attempts": 1},
+		"$set": bson.M{"last_failed_at": time.Now(), "last_ip": ip},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var attempts models.LoginAttempts
+	if err := db.Collection("login_attempts").FindOneAndUpdate(ctx, bson.M{"_id": email}, update, opts).Decode(&attempts); err != nil {
+		return nil, err
+	}
+	return &attempts, nil
+}
+
+func LockLogin(ctx context.Context, db *mongo.Database, email string, until time.Time) error {
+	_, err := db.Collection("login_attempts").UpdateOne(ctx, bson.M{"_id": email}, bson.M{"$set": bson.M{"locked_until": until}})
+	return err
+}
+
+func ClearLoginAttempts(ctx context.Context, db *mongo.Database, email string) error {
+	_, err := db.Collection("login_attempts").DeleteOne(ctx, bson.M{"_id": email})
+	return err
+}
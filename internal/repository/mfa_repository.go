@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func GetMFA(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) (*models.MFA, error) {
+	var mfa models.MFA
+	err := db.Collection("mfa").FindOne(ctx, bson.M{"_id": userID}).Decode(&mfa)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("two-factor authentication not set up")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &mfa, nil
+}
+
+// SaveMFASetup starts or restarts enrollment with a new secret. It never
+// touches an enabled enrollment.
+func SaveMFASetup(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, secret string) error {
+	now := time.Now()
+	filter := bson.M{"_id": userID, "enabled": bson.M{"$ne": true}}
+	update := bson.M{
+		"$set": bson.M{
+			"secret":               secret,
+			"enabled":              false,
+			"recovery_code_hashes": []string{},
+			"last_used_step":       0,
+			"updated_at":           now,
+		},
+		"$setOnInsert": bson.M{"created_at": now},
+	}
+	_, err := db.Collection("mfa").UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return errors.New("two-factor authentication already enabled")
+	}
+	return err
+}
+
+func EnableMFA(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, step int64, recoveryCodeHashes []string) error {
+	now := time.Now()
+	update := bson.M{"$set": bson.M{
+		"enabled":              true,
+		"enabled_at":           now,
+		"last_used_step":       step,
+		"recovery_code_hashes": recoveryCodeHashes,
+		"updated_at":           now,
+	}}
+	_, err := db.Collection("mfa").UpdateOne(ctx, bson.M{"_id": userID}, update)
+	return err
+}
+
+func DeleteMFA(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) error {
+	_, err := db.Collection("mfa").DeleteOne(ctx, bson.M{"_id": userID})
+	return err
+}
+
+// UseMFAStep records the TOTP step just accepted. It fails if that step or
+// a later one was already used, so each code works once.
+func UseMFAStep(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, step int64) error {
+	filter := bson.M{"_id": userID, "last_used_step": bson.M{"$lt": step}}
+	update := bson.M{"$set": bson.M{"last_used_step": step, "updated_at": time.Now()}}
+	result, err := db.Collection("mfa").UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("code already used")
+	}
+	return nil
+}
+
+// UseRecoveryCode removes a recovery code hash. It fails if the code was
+// not one of the user's remaining codes.
+func UseRecoveryCode(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, codeHash string) error {
+	filter := bson.M{"_id": userID, "recovery_code_hashes": codeHash}
+	update := bson.M{
+		"$pull": bson.M{"recovery_code_hashes": codeHash},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+	result, err := db.Collection("mfa").UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("invalid recovery code")
+	}
+	return nil
+}
+
+func SetRecoveryCodes(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, recoveryCodeHashes []string) error {
+	update := bson.M{"$set": bson.M{"recovery_code_hashes": recoveryCodeHashes, "updated_at": time.Now()}}
+	_, err := db.Collection("mfa").UpdateOne(ctx, bson.M{"_id": userID}, update)
+	return err
+}
+
+func CreateMFAChallenge(ctx context.Context, db *mongo.Database, challenge *models.MFAChallenge) error {
+	challenge.ID = primitive.NewObjectID()
+	challenge.CreatedAt = time.Now()
+	_, err := db.Collection("mfa_challenges").InsertOne(ctx, challenge)
+	return err
+}
+
+func GetMFAChallengeByTokenHash(ctx context.Context, db *mongo.Database, tokenHash string) (*models.MFAChallenge, error) {
+	var challenge models.MFAChallenge
+	err := db.Collection("mfa_challenges").FindOne(ctx, bson.M{"token_hash": tokenHash}).Decode(&challenge)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("mfa challenge not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+func IncrementMFAChallengeAttempts(ctx context.Context, db *mongo.Database, id primitive.ObjectID) error {
+	_, err := db.Collection("mfa_challenges").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{"attempts": 1}})
+	return err
+}
+
+// UseMFAChallenge marks a challenge completed. It fails if a concurrent
+// request completed it first.
+func UseMFAChallenge(ctx context.Context, db *mongo.Database, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id, "used_at": bson.M{"$exists": false}}
+	result, err := db.Collection("mfa_challenges").UpdateOne(ctx, filter, bson.M{"$set": bson.M{"used_at": time.Now()}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("mfa challenge already used")
+	}
+	return nil
+}
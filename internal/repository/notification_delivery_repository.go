@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func GetNotificationByID(ctx context.Context, db *mongo.Database, id primitive.ObjectID) (*models.Notification, error) {
+	var notification models.Notification
+	err := db.Collection("notifications").FindOne(ctx, bson.M{"_id": id}).Decode(&notification)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("notification not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+func CreateNotificationDeliveries(ctx context.Context, db *mongo.Database, deliveries []*models.NotificationDelivery) error {
+	documents := make([]interface{}, len(deliveries))
+	for i, delivery := range deliveries {
+		delivery.CreatedAt = time.Now()
+		documents[i] = delivery
+	}
+	result, err := db.Collection("notification_deliveries").InsertMany(ctx, documents)
+	if err != nil {
+		return err
+	}
+	for i, id := range result.InsertedIDs {
+		deliveries[i].ID = id.(primitive.ObjectID)
+	}
+	return nil
+}
+
+// ClaimDueDelivery takes the next pending delivery that is due, optionally
+// only for one notification, and holds it for the lease so no other worker
+// sends it meanwhile. It counts the attempt and returns nil when nothing is
+// due.
+func ClaimDueDelivery(ctx context.Context, db *mongo.Database, notificationID *primitive.ObjectID, now time.Time, lease time.Duration) (*models.NotificationDelivery, error) {
+	filter := bson.M{"status": models.DeliveryPending, "next_attempt_at": bson.M{"$lte": now}}
+	if notificationID != nil {
+		filter["notification_id"] = *notificationID
+	}
+	update := bson.M{
+		"$set": bson.M{"next_attempt_at": now.Add(lease)},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var delivery models.NotificationDelivery
+	err := db.Collection("notification_deliveries").FindOneAndUpdate(ctx, filter, update, opts).Decode(&delivery)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ClaimDueDigestDelivery claims the next due digest delivery of a user on
+// a channel, so it can be sent in the same digest as one already claimed.
+func ClaimDueDigestDelivery(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, channel string, now time.Time, lease time.Duration) (*models.NotificationDelivery, error) {
+	filter := bson.M{
+		"user_id":         userID,
+		"channel":         channel,
+		"digest":          true,
+		"status":          models.DeliveryPending,
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+	update := bson.M{
+		"$set": bson.M{"next_attempt_at": now.Add(lease)},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var delivery models.NotificationDelivery
+	err := db.Collection("notification_deliveries").FindOneAndUpdate(ctx, filter, update, opts).Decode(&delivery)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// UpdateDeliveryStatus records the outcome of an attempt. A pending
+// delivery is retried at nextAttemptAt.
+func UpdateDeliveryStatus(ctx context.Context, db *mongo.Database, id primitive.ObjectID, status models.DeliveryStatus, lastError string, nextAttemptAt time.Time) error {
+	set := bson.M{"status": status, "last_error": lastError, "next_attempt_at": nextAttemptAt}
+	if status == models.DeliverySent {
+		set["sent_at"] = time.Now()
+	}
+	_, err := db.Collection("notification_deliveries").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	return err
+}
+
+func GetNotificationDeliveries(ctx context.Context, db *mongo.Database, notificationID primitive.ObjectID) ([]*models.NotificationDelivery, error) {
+	deliveries := []*models.NotificationDelivery{}
+	cursor, err := db.Collection("notification_deliveries").Find(ctx, bson.M{"notification_id": notificationID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// EnsureNotificationDeliveryIndexes creates the indexes due deliveries, a
+// notification's deliveries and a user's digest are found by.
+func EnsureNotificationDeliveryIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("notification_deliveries").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "notification_id", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "channel", Value: 1}, {Key: "digest", Value: 1}}},
+	})
+	return err
+}
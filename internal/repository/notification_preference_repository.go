@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func GetNotificationPreferences(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) (*models.NotificationPreferences, error) {
+	var preferences models.NotificationPreferences
+	err := db.Collection("notification_preferences").FindOne(ctx, bson.M{"_id": userID}).Decode(&preferences)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("notification preferences not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &preferences, nil
+}
+
+// SaveNotificationPreferences replaces a user's preferences, creating them
+// if needed.
+func SaveNotificationPreferences(ctx context.Context, db *mongo.Database, preferences *models.NotificationPreferences) error {
+	preferences.UpdatedAt = time.Now()
+	opts := options.Replace().SetUpsert(true)
+	_, err := db.Collection("notification_preferences").ReplaceOne(ctx, bson.M{"_id": preferences.UserID}, preferences, opts)
+	return err
+}
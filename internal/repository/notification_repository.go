@@ -2,34 +2,115 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/Gerard-007/ajor_app/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func CreateNotification(ctx context.Context, db *mongo.Database, notification *models.Notification) error {
 	collection := db.Collection("notifications")
 	notification.CreatedAt = time.Now()
-	_, err := collection.InsertOne(ctx, notification)
-	return err
+	result, err := collection.InsertOne(ctx, notification)
+	if err != nil {
+		return err
+	}
+	notification.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
 }
 
+// GetUserNotifications returns all of a user's notifications, newest first.
 func GetUserNotifications(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) ([]*models.Notification, error) {
-	var notifications []*models.Notification
-	cursor, err := db.Collection("notifications").Find(ctx, bson.M{"user_id": userID})
+	return GetNotifications(ctx, db, bson.M{"user_id": userID}, 0)
+}
+
+// GetNotifications returns notifications matching the filter, newest first,
+// at most limit of them unless limit is 0.
+func GetNotifications(ctx context.Context, db *mongo.Database, filter bson.M, limit int64) ([]*models.Notification, error) {
+	notifications := []*models.Notification{}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(limit)
+	cursor, err := db.Collection("notifications").Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
-	for cursor.Next(ctx) {
-		var notification models.Notification
-		if err := cursor.Decode(&notification); err != nil {
-			return nil, err
-		}
-		notifications = append(notifications, &notification)
+	if err := cursor.All(ctx, &notifications); err != nil {
+		return nil, err
 	}
 	return notifications, nil
+}
+
+func CountNotifications(ctx context.Context, db *mongo.Database, filter bson.M) (int64, error) {
+	return db.Collection("notifications").CountDocuments(ctx, filter)
+}
+
+// MarkNotificationRead marks one of the user's notifications read. Marking
+// a read notification again keeps its original read time.
+func MarkNotificationRead(ctx context.Context, db *mongo.Database, id, userID primitive.ObjectID) error {
+	filter := bson.M{"_id": id, "user_id": userID}
+	result, err := db.Collection("notifications").UpdateOne(ctx, bson.M{"_id": id, "user_id": userID, "read": false}, bson.M{
+		"$set": bson.M{"read": true, "read_at": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		count, err := db.Collection("notifications").CountDocuments(ctx, filter)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("notification not found")
+		}
+	}
+	return nil
+}
+
+// MarkNotificationsRead marks the user's unread notifications matching the
+// filter read and returns how many there were.
+func MarkNotificationsRead(ctx context.Context, db *mongo.Database, filter bson.M) (int64, error) {
+	filter["read"] = false
+	result, err := db.Collection("notifications").UpdateMany(ctx, filter, bson.M{
+		"$set": bson.M{"read": true, "read_at": time.Now()},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// DeleteNotification removes one of the user's notifications along with
+// its deliveries, so none still pending are sent.
+func DeleteNotification(ctx context.Context, db *mongo.Database, id, userID primitive.ObjectID) error {
+	result, err := db.Collection("notifications").DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return errors.New("notification not found")
+	}
+	_, err = db.Collection("notification_deliveries").DeleteMany(ctx, bson.M{"notification_id": id})
+	return err
+}
+
+// EnsureNotificationIndexes creates the indexes the inbox is paged,
+// filtered and counted by, and the one that keeps keyed notifications
+// unique.
+func EnsureNotificationIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("notifications").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "read", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "type", Value: 1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "contribution_id", Value: 1}, {Key: "_id", Value: -1}}},
+		{
+			Keys: bson.D{{Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"key": bson.M{"$exists": true}}),
+		},
+	})
+	return err
 }
\ No newline at end of file
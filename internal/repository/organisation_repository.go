@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func CreateOrganisation(ctx context.Context, db *mongo.Database, organisation *models.Organisation) error {
+	organisation.CreatedAt = time.Now()
+	organisation.UpdatedAt = time.Now()
+	if organisation.Staff == nil {
+		organisation.Staff = []primitive.ObjectID{}
+	}
+	result, err := db.Collection("organisations").InsertOne(ctx, organisation)
+	if err != nil {
+		return err
+	}
+	organisation.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func GetOrganisationByID(ctx context.Context, db *mongo.Database, id primitive.ObjectID) (*models.Organisation, error) {
+	return findOrganisation(ctx, db, bson.M{"_id": id})
+}
+
+func GetOrganisationByStaffCode(ctx context.Context, db *mongo.Database, staffCode string) (*models.Organisation, error) {
+	return findOrganisation(ctx, db, bson.M{"staff_code": staffCode})
+}
+
+func findOrganisation(ctx context.Context, db *mongo.Database, filter bson.M) (*models.Organisation, error) {
+	var organisation models.Organisation
+	err := db.Collection("organisations").FindOne(ctx, filter).Decode(&organisation)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("organisation not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &organisation, nil
+}
+
+// GetOrganisationsByOwner lists the organisations the user owns.
+func GetOrganisationsByOwner(ctx context.Context, db *mongo.Database, ownerID primitive.ObjectID) ([]*models.Organisation, error) {
+	var organisations []*models.Organisation
+	cursor, err := db.Collection("organisations").Find(ctx, bson.M{"owner_id": ownerID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err := cursor.All(ctx, &organisations); err != nil {
+		return nil, err
+	}
+	return organisations, nil
+}
+
+func AddOrganisationStaff(ctx context.Context, db *mongo.Database, organisationID, userID primitive.ObjectID) error {
+	_, err := db.Collection("organisations").UpdateOne(ctx,
+		bson.M{"_id": organisationID},
+		bson.M{
+			"$addToSet": bson.M{"staff": userID},
+			"$set":      bson.M{"updated_at": time.Now()},
+		},
+	)
+	return err
+}
+
+func RemoveOrganisationStaff(ctx context.Context, db *mongo.Database, organisationID, userID primitive.ObjectID) error {
+	_, err := db.Collection("organisations").UpdateOne(ctx,
+		bson.M{"_id": organisationID},
+		bson.M{
+			"$pull": bson.M{"staff": userID},
+			"$set":  bson.M{"updated_at": time.Now()},
+		},
+	)
+	return err
+}
+
+// SetOrganisationWebhook sets where the organisation's webhook events are
+// posted and the encrypted secret they are signed with. An empty url
+// removes the webhook.
+func SetOrganisationWebhook(ctx context.Context, db *mongo.Database, organisationID primitive.ObjectID, url, secret string) error {
+	update := bson.M{
+		"$set": bson.M{"webhook_url": url, "webhook_secret": secret, "updated_at": time.Now()},
+	}
+	if url == "" {
+		update = bson.M{
+			"$unset": bson.M{"webhook_url": "", "webhook_secret": ""},
+			"$set":   bson.M{"updated_at": time.Now()},
+		}
+	}
+	result, err := db.Collection("organisations").UpdateOne(ctx, bson.M{"_id": organisationID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("organisation not found")
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SaveOTP replaces the user's code for the purpose with a new one.
+func SaveOTP(ctx context.Context, db *mongo.Database, otp *models.OTP) error {
+	otp.CreatedAt = time.Now()
+	filter := bson.M{"user_id": otp.UserID, "purpose": otp.Purpose}
+	opts := options.FindOneAndReplace().SetUpsert(true).SetReturnDocument(options.After)
+	var saved models.OTP
+	if err := db.Collection("otps").FindOneAndReplace(ctx, filter, otp, opts).Decode(&saved); err != nil {
+		return err
+	}
+	otp.ID = saved.ID
+	return nil
+}
+
+func GetOTP(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, purpose models.OTPPurpose) (*models.OTP, error) {
+	var otp models.OTP
+	err := db.Collection("otps").FindOne(ctx, bson.M{"user_id": userID, "purpose": purpose}).Decode(&otp)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("otp not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &otp, nil
+}
+
+func IncrementOTPAttempts(ctx context.Context, db *mongo.Database, id primitive.ObjectID) error {
+	_, err := db.Collection("otps").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{"attempts": 1}})
+	return err
+}
+
+// ConsumeOTP marks a code used. It fails if the code was consumed by a
+// concurrent request first.
+func ConsumeOTP(ctx context.Context, db *mongo.Database, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id, "consumed_at": bson.M{"$exists": false}}
+	result, err := db.Collection("otps").UpdateOne(ctx, filter, bson.M{"$set": bson.M{"consumed_at": time.Now()}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("code already used")
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// outboxRetention is how long published entries are kept. Pending and
+// failed entries have no published_at and are kept until dealt with.
+const outboxRetention = 7 * 24 * time.Hour
+
+func EnsureOutboxIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("outbox").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "published_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(outboxRetention.Seconds())),
+		},
+	})
+	return err
+}
+
+// CreateOutboxEntries queues entries to be published. Called with the
+// context of a transaction, they are only queued if it commits.
+func CreateOutboxEntries(ctx context.Context, db *mongo.Database, entries []*models.OutboxEntry) error {
+	documents := make([]interface{}, len(entries))
+	now := time.Now()
+	for i, entry := range entries {
+		if entry.ID.IsZero() {
+			entry.ID = primitive.NewObjectID()
+		}
+		entry.Status = models.OutboxPending
+		entry.NextAttemptAt = now
+		entry.CreatedAt = now
+		documents[i] = entry
+	}
+	_, err := db.Collection("outbox").InsertMany(ctx, documents)
+	return err
+}
+
+// ClaimDueOutboxEntry takes the oldest pending entry that is due and holds
+// it for the lease so no other relay publishes it meanwhile. It counts the
+// attempt and returns nil when nothing is due.
+func ClaimDueOutboxEntry(ctx context.Context, db *mongo.Database, now time.Time, lease time.Duration) (*models.OutboxEntry, error) {
+	filter := bson.M{"status": models.OutboxPending, "next_attempt_at": bson.M{"$lte": now}}
+	update := bson.M{
+		"$set": bson.M{"next_attempt_at": now.Add(lease)},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var entry models.OutboxEntry
+	err := db.Collection("outbox").FindOneAndUpdate(ctx, filter, update, opts).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// UpdateOutboxStatus records the outcome of an attempt. A pending entry is
+// retried at nextAttemptAt.
+func UpdateOutboxStatus(ctx context.Context, db *mongo.Database, id primitive.ObjectID, status models.OutboxStatus, lastError string, nextAttemptAt time.Time) error {
+	set := bson.M{"status": status, "last_error": lastError, "next_attempt_at": nextAttemptAt}
+	if status == models.OutboxPublished {
+		set["published_at"] = time.Now()
+	}
+	_, err := db.Collection("outbox").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	return err
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func CreatePasswordReset(ctx context.Context, db *mongo.Database, reset *models.PasswordReset) error {
+	reset.CreatedAt = time.Now()
+	result, err := db.Collection("password_resets").InsertOne(ctx, reset)
+	if err != nil {
+		return err
+	}
+	reset.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func GetPasswordResetByTokenHash(ctx context.Context, db *mongo.Database, tokenHash string) (*models.PasswordReset, error) {
+	var reset models.PasswordReset
+	err := db.Collection("password_resets").FindOne(ctx, bson.M{"token_hash": tokenHash}).Decode(&reset)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("password reset not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reset, nil
+}
+
+// GetLatestPasswordReset returns the most recent reset issued to a user.
+func GetLatestPasswordReset(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) (*models.PasswordReset, error) {
+	var reset models.PasswordReset
+	opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	err := db.Collection("password_resets").FindOne(ctx, bson.M{"user_id": userID}, opts).Decode(&reset)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("password reset not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &reset, nil
+}
+
+// UsePasswordReset marks a reset used. It fails if a concurrent request
+// used it first.
+func UsePasswordReset(ctx context.Context, db *mongo.Database, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id, "used_at": bson.M{"$exists": false}}
+	result, err := db.Collection("password_resets").UpdateOne(ctx, filter, bson.M{"$set": bson.M{"used_at": time.Now()}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("reset token already used")
+	}
+	return nil
+}
+
+// ExpireUserPasswordResets invalidates every unused reset token of a user.
+func ExpireUserPasswordResets(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) error {
+	filter := bson.M{"user_id": userID, "used_at": bson.M{"$exists": false}}
+	_, err := db.Collection("password_resets").UpdateMany(ctx, filter, bson.M{"$set": bson.M{"expires_at": time.Now()}})
+	return err
+}
@@ -35,6 +35,7 @@ func UpdateUserProfile(collection *mongo.Collection, userID primitive.ObjectID,
 			"bio":         profileUpdate.Bio,
 			"location":    profileUpdate.Location,
 			"profile_pic": profileUpdate.ProfilePic,
+			"locale":      profileUpdate.Locale,
 			"updated_at":  time.Now(),
 		},
 	}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func GetReliabilityScore(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) (*models.ReliabilityScore, error) {
+	var score models.ReliabilityScore
+	err := db.Collection("reliability_scores").FindOne(ctx, bson.M{"_id": userID}).Decode(&score)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("reliability score not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &score, nil
+}
+
+// SaveReliabilityScore replaces a user's score document, creating it if needed.
+func SaveReliabilityScore(ctx context.Context, db *mongo.Database, score *models.ReliabilityScore) error {
+	score.UpdatedAt = time.Now()
+	opts := options.Replace().SetUpsert(true)
+	_, err := db.Collection("reliability_scores").ReplaceOne(ctx, bson.M{"_id": score.UserID}, score, opts)
+	return err
+}
+
+// IncrementReliability adds to a user's counters and returns the updated document.
+func IncrementReliability(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, counters bson.M) (*models.ReliabilityScore, error) {
+	update := bson.M{
+		"$inc": counters,
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var score models.ReliabilityScore
+	err := db.Collection("reliability_scores").FindOneAndUpdate(ctx, bson.M{"_id": userID}, update, opts).Decode(&score)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("reliability score not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &score, nil
+}
+
+func UpdateReliabilityScoreValue(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, value float64) error {
+	_, err := db.Collection("reliability_scores").UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": bson.M{"score": value}})
+	return err
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureReminderIndexes makes a reminder unique per member, round, kind and
+// offset, so instances racing to send it record it only once.
+func EnsureReminderIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("contribution_reminders").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "contribution_id", Value: 1},
+			{Key: "deadline", Value: 1},
+			{Key: "member_id", Value: 1},
+			{Key: "kind", Value: 1},
+			{Key: "offset", Value: 1},
+		},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// RecordReminder claims a reminder before it is sent. It fails with
+// "reminder already sent" if it has been claimed before.
+func RecordReminder(ctx context.Context, db *mongo.Database, reminder *models.ContributionReminder) error {
+	reminder.SentAt = time.Now()
+	result, err := db.Collection("contribution_reminders").InsertOne(ctx, reminder)
+	if mongo.IsDuplicateKeyError(err) {
+		return errors.New("reminder already sent")
+	}
+	if err != nil {
+		return err
+	}
+	reminder.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// GetRemindersForRounds returns the reminders sent for the given rounds of a
+// contribution.
+func GetRemindersForRounds(ctx context.Context, db *mongo.Database, contributionID primitive.ObjectID, deadlines []time.Time) ([]*models.ContributionReminder, error) {
+	var reminders []*models.ContributionReminder
+	filter := bson.M{"contribution_id": contributionID, "deadline": bson.M{"$in": deadlines}}
+	cursor, err := db.Collection("contribution_reminders").Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var reminder models.ContributionReminder
+		if err := cursor.Decode(&reminder); err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, &reminder)
+	}
+	return reminders, cursor.Err()
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func CreateSession(ctx context.Context, db *mongo.Database, session *models.Session) error {
+	session.CreatedAt = time.Now()
+	session.UpdatedAt = time.Now()
+	result, err := db.Collection("sessions").InsertOne(ctx, session)
+	if err != nil {
+		return err
+	}
+	session.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func GetSessionByID(ctx context.Context, db *mongo.Database, id primitive.ObjectID) (*models.Session, error) {
+	var session models.Session
+	err := db.Collection("sessions").FindOne(ctx, bson.M{"_id": id}).Decode(&session)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("session not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetActiveSessions returns a user's sessions that are neither revoked nor expired.
+func GetActiveSessions(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) ([]*models.Session, error) {
+	filter := bson.M{
+		"user_id":    userID,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "last_seen_at", Value: -1}})
+	cursor, err := db.Collection("sessions").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	sessions := []*models.Session{}
+	for cursor.Next(ctx) {
+		var session models.Session
+		if err := cursor.Decode(&session); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, &session)
+	}
+	return sessions, cursor.Err()
+}
+
+// rotatedTokenHashLimit is how many rotated-out refresh token hashes a
+// session keeps for reuse detection.
+const rotatedTokenHashLimit = 50
+
+// RotateSessionToken swaps the refresh token hash only if it still matches
+// the presented one, so two concurrent refreshes cannot both succeed.
+func RotateSessionToken(ctx context.Context, db *mongo.Database, id primitive.ObjectID, oldHash, newHash, ip string) error {
+	filter := bson.M{
+		"_id":                id,
+		"refresh_token_hash": oldHash,
+		"revoked_at":         bson.M{"$exists": false},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"refresh_token_hash": newHash,
+			"ip":                 ip,
+			"last_seen_at":       time.Now(),
+			"updated_at":         time.Now(),
+		},
+		"$push": bson.M{
+			"rotated_token_hashes": bson.M{"$each": bson.A{oldHash}, "$slice": -rotatedTokenHashLimit},
+		},
+	}
+	result, err := db.Collection("sessions").UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("refresh token already used")
+	}
+	return nil
+}
+
+func TouchSession(ctx context.Context, db *mongo.Database, id primitive.ObjectID, ip string) error {
+	update := bson.M{"$set": bson.M{"ip": ip, "last_seen_at": time.Now()}}
+	_, err := db.Collection("sessions").UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+func RevokeSession(ctx context.Context, db *mongo.Database, id primitive.ObjectID, reason string) error {
+	filter := bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"revoked_at": time.Now(), "revoked_reason": reason, "updated_at": time.Now()}}
+	_, err := db.Collection("sessions").UpdateOne(ctx, filter, update)
+	return err
+}
+
+// RevokeUserSessions revokes every active session of a user, optionally
+// keeping one.
+func RevokeUserSessions(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, reason string, except *primitive.ObjectID) (int64, error) {
+	filter := bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}}
+	if except != nil {
+		filter["_id"] = bson.M{"$ne": *except}
+	}
+	update := bson.M{"$set": bson.M{"revoked_at": time.Now(), "revoked_reason": reason, "updated_at": time.Now()}}
+	result, err := db.Collection("sessions").UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GetSigningKeys returns the keys that have not expired yet, most recently
+// activated first.
+func GetSigningKeys(ctx context.Context, db *mongo.Database, now time.Time) ([]*models.SigningKey, error) {
+	var keys []*models.SigningKey
+	filter := bson.M{"$or": []bson.M{
+		{"expires_at": bson.M{"$exists": false}},
+		{"expires_at": bson.M{"$gt": now}},
+	}}
+	opts := options.Find().SetSort(bson.D{{Key: "activates_at", Value: -1}})
+	cursor, err := db.Collection("signing_keys").Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func CreateSigningKey(ctx context.Context, db *mongo.Database, key *models.SigningKey) error {
+	_, err := db.Collection("signing_keys").InsertOne(ctx, key)
+	return err
+}
+
+// ExpireSigningKeys sets the expiry of every key other than keepID that
+// does not have one yet.
+func ExpireSigningKeys(ctx context.Context, db *mongo.Database, keepID string, at time.Time) error {
+	filter := bson.M{"_id": bson.M{"$ne": keepID}, "expires_at": bson.M{"$exists": false}}
+	_, err := db.Collection("signing_keys").UpdateMany(ctx, filter, bson.M{"$set": bson.M{"expires_at": at}})
+	return err
+}
+
+func UpdateSigningKeyPrivateKey(ctx context.Context, db *mongo.Database, id, privateKey string) error {
+	_, err := db.Collection("signing_keys").UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"private_key": privateKey}})
+	return err
+}
+
+// DeleteExpiredSigningKeys removes keys that expired before the given time.
+func DeleteExpiredSigningKeys(ctx context.Context, db *mongo.Database, before time.Time) (int64, error) {
+	result, err := db.Collection("signing_keys").DeleteMany(ctx, bson.M{"expires_at": bson.M{"$lte": before}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func EnsureStreamTicketIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("stream_tickets").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "ticket_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	return err
+}
+
+func CreateStreamTicket(ctx context.Context, db *mongo.Database, ticket *models.StreamTicket) error {
+	ticket.CreatedAt = time.Now()
+	result, err := db.Collection("stream_tickets").InsertOne(ctx, ticket)
+	if err != nil {
+		return err
+	}
+	ticket.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// UseStreamTicket removes an unexpired ticket and returns it, so that only
+// one request can use it.
+func UseStreamTicket(ctx context.Context, db *mongo.Database, ticketHash string, now time.Time) (*models.StreamTicket, error) {
+	var ticket models.StreamTicket
+	filter := bson.M{"ticket_hash": ticketHash, "expires_at": bson.M{"$gt": now}}
+	err := db.Collection("stream_tickets").FindOneAndDelete(ctx, filter).Decode(&ticket)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("stream ticket not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
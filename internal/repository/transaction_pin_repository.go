@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func GetTransactionPIN(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) (*models.TransactionPIN, error) {
+	var pin models.TransactionPIN
+	err := db.Collection("transaction_pins").FindOne(ctx, bson.M{"_id": userID}).Decode(&pin)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("transaction PIN not set")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pin, nil
+}
+
+// SaveTransactionPIN stores a new PIN hash and clears any lockout.
+func SaveTransactionPIN(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, pinHash string) error {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"pin_hash":        pinHash,
+			"failed_attempts": 0,
+			"updated_at":      now,
+		},
+		"$unset":       bson.M{"locked_until": ""},
+		"$setOnInsert": bson.M{"created_at": now},
+	}
+	opts := options.Update().SetUpsert(true)
+	_, err := db.Collection("transaction_pins").UpdateOne(ctx, bson.M{"_id": userID}, update, opts)
+	return err
+}
+
+// CountPINAttempt counts an attempt at the user's PIN as a failure before
+// it is checked, unless the PIN is locked at now, and returns the PIN as it
+// then stands. The lock is checked and the count taken in one step so that
+// concurrent guesses cannot share a count; a locked PIN is returned as it is
+// with its locked_until still ahead of now.
+func CountPINAttempt(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, now time.Time) (*models.TransactionPIN, error) {
+	filter := bson.M{
+		"_id": userID,
+		"$or": []bson.M{
+			{"locked_until": bson.M{"$exists": false}},
+			{"locked_until": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{
+		"$inc": bson.M{"failed_attempts": 1},
+		"$set": bson.M{"updated_at": now},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var pin models.TransactionPIN
+	err := db.Collection("transaction_pins").FindOneAndUpdate(ctx, filter, update, opts).Decode(&pin)
+	if err == mongo.ErrNoDocuments {
+		// Either there is no PIN or it is locked
+		return GetTransactionPIN(ctx, db, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pin, nil
+}
+
+// LockPIN locks the user's PIN until the given time. A later lock already
+// in place is kept.
+func LockPIN(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, until time.Time) error {
+	update := bson.M{
+		"$max": bson.M{"locked_until": until},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+	_, err := db.Collection("transaction_pins").UpdateOne(ctx, bson.M{"_id": userID}, update)
+	return err
+}
+
+func ClearPINFailures(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) error {
+	update := bson.M{
+		"$set":   bson.M{"failed_attempts": 0, "updated_at": time.Now()},
+		"$unset": bson.M{"locked_until": ""},
+	}
+	_, err := db.Collection("transaction_pins").UpdateOne(ctx, bson.M{"_id": userID}, update)
+	return err
+}
@@ -40,6 +40,33 @@ func UpdateTransactionStatus(ctx context.Context, db *mongo.Database, transactio
 	return nil
 }
 
+// SettleTransaction moves a pending transaction to its final status. It
+// returns mongo.ErrNoDocuments when the transaction is no longer pending, so
+// only one caller settles it.
+func SettleTransaction(ctx context.Context, db *mongo.Database, transactionID primitive.ObjectID, status models.TransactionStatus) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":     status,
+			"updated_at": time.Now(),
+		},
+	}
+	result, err := db.Collection("transactions").UpdateOne(ctx, bson.M{"_id": transactionID, "status": models.StatusPending}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SetTransactionTransferID records the payment provider's ID of the bank
+// transfer that pays a transaction out.
+func SetTransactionTransferID(ctx context.Context, db *mongo.Database, transactionID primitive.ObjectID, transferID string) error {
+	_, err := db.Collection("transactions").UpdateOne(ctx, bson.M{"_id": transactionID}, bson.M{"$set": bson.M{"transfer_id": transferID}})
+	return err
+}
+
 func GetUserTransactions(ctx context.Context, db *mongo.Database, userID, contributionID primitive.ObjectID) ([]*models.Transaction, error) {
 	var wallet models.Wallet
 	err := db.Collection("wallets").FindOne(ctx, bson.M{"owner_id": userID, "type": models.WalletTypeUser}).Decode(&wallet)
@@ -88,4 +115,35 @@ func GetTransactions(ctx context.Context, db *mongo.Database, filter bson.M) ([]
 	}
 
 	return transactions, nil
+}
+
+// SumWalletFlow adds up the successful transactions into the wallet, or out
+// of it when incoming is false, dated since the given time.
+func SumWalletFlow(ctx context.Context, db *mongo.Database, walletID primitive.ObjectID, incoming bool, since time.Time) (float64, error) {
+	walletField := "from_wallet"
+	if incoming {
+		walletField = "to_wallet"
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			walletField: walletID,
+			"status":    models.StatusSuccess,
+			"date":      bson.M{"$gte": since},
+		}}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": "$amount"}}}},
+	}
+	cursor, err := db.Collection("transactions").Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+	var result struct {
+		Total float64 `bson:"total"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&result); err != nil {
+			return 0, err
+		}
+	}
+	return result.Total, cursor.Err()
 }
\ No newline at end of file
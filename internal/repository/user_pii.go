@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/pkg/fieldcrypt"
+	"github.com/Gerard-007/ajor_app/pkg/sms"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Blind index names keep a phone number and a BVN with the same digits
+// from sharing an index.
+const (
+	phoneIndexField = "phone"
+	bvnIndexField   = "bvn"
+)
+
+// PhoneIndex returns the blind index users are looked up by phone with.
+func PhoneIndex(phone string) string {
+	return fieldcrypt.Default().BlindIndex(phoneIndexField, phone)
+}
+
+// BVNIndex returns the blind index users are looked up by BVN with.
+func BVNIndex(bvn string) string {
+	return fieldcrypt.Default().BlindIndex(bvnIndexField, bvn)
+}
+
+// SealUserPII returns a copy of the user with the phone number and BVN
+// encrypted and their blind indexes set, ready to be stored. The user
+// passed in is left in plaintext.
+func SealUserPII(user *models.User) (*models.User, error) {
+	keyring := fieldcrypt.Default()
+	sealed := *user
+	var err error
+	if sealed.Phone, err = keyring.Encrypt(user.Phone); err != nil {
+		return nil, err
+	}
+	if sealed.BVN, err = keyring.Encrypt(user.BVN); err != nil {
+		return nil, err
+	}
+	sealed.PhoneIndex = PhoneIndex(user.Phone)
+	sealed.BVNIndex = BVNIndex(user.BVN)
+	return &sealed, nil
+}
+
+// openUserPII decrypts a stored user's phone number and BVN in place.
+func openUserPII(user *models.User) error {
+	keyring := fieldcrypt.Default()
+	var err error
+	if user.Phone, err = keyring.Decrypt(user.Phone); err != nil {
+		return err
+	}
+	if user.BVN, err = keyring.Decrypt(user.BVN); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UserExistsWithPhone reports whether another user has the phone number.
+// Records saved before encryption are matched on the plaintext field, and
+// records saved before numbers were normalised on every form the number
+// may have been written in.
+func UserExistsWithPhone(ctx context.Context, db *mongo.Database, phone string, except *models.User) (bool, error) {
+	variants := sms.PhoneVariants(phone)
+	indexes := make([]string, len(variants))
+	for i, variant := range variants {
+		indexes[i] = PhoneIndex(variant)
+	}
+	return userExistsWithPII(ctx, db, "phone", indexes, variants, except)
+}
+
+// UserExistsWithBVN reports whether another user has the BVN.
+func UserExistsWithBVN(ctx context.Context, db *mongo.Database, bvn string, except *models.User) (bool, error) {
+	return userExistsWithPII(ctx, db, "bvn", []string{BVNIndex(bvn)}, []string{bvn}, except)
+}
+
+func userExistsWithPII(ctx context.Context, db *mongo.Database, field string, indexes, plaintexts []string, except *models.User) (bool, error) {
+	filter := bson.M{"$or": []bson.M{
+		{field + "_index": bson.M{"$in": indexes}},
+		{field: bson.M{"$in": plaintexts}},
+	}}
+	if except != nil {
+		filter["_id"] = bson.M{"$ne": except.ID}
+	}
+	count, err := db.Collection("users").CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// RewrapUserPII re-encrypts the phone numbers and BVNs of users stored in
+// plaintext or under a key other than the active one, normalises phone
+// numbers saved in another form, and fills in missing blind indexes. It
+// returns how many users were updated.
+func RewrapUserPII(ctx context.Context, db *mongo.Database) (int, error) {
+	keyring := fieldcrypt.Default()
+	opts := options.Find().SetProjection(bson.M{"phone": 1, "bvn": 1, "phone_index": 1, "bvn_index": 1})
+	cursor, err := db.Collection("users").Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	updated := 0
+	for cursor.Next(ctx) {
+		var stored models.User
+		if err := cursor.Decode(&stored); err != nil {
+			return updated, err
+		}
+		var phone string
+		if stored.Phone != "" {
+			if phone, err = keyring.Decrypt(stored.Phone); err != nil {
+				return updated, err
+			}
+		}
+		normalizedPhone := phone
+		if normalized, err := sms.NormalizePhone(phone); err == nil {
+			normalizedPhone = normalized
+		}
+		missingIndex := (stored.Phone != "" && stored.PhoneIndex == "") || (stored.BVN != "" && stored.BVNIndex == "")
+		if !keyring.NeedsRewrap(stored.Phone) && !keyring.NeedsRewrap(stored.BVN) && !missingIndex && normalizedPhone == phone {
+			continue
+		}
+
+		fields := bson.M{}
+		if stored.Phone != "" {
+			if fields["phone"], err = keyring.Encrypt(normalizedPhone); err != nil {
+				return updated, err
+			}
+			fields["phone_index"] = PhoneIndex(normalizedPhone)
+		}
+		if stored.BVN != "" {
+			bvn, err := keyring.Decrypt(stored.BVN)
+			if err != nil {
+				return updated, err
+			}
+			if fields["bvn"], err = keyring.Rewrap(stored.BVN); err != nil {
+				return updated, err
+			}
+			fields["bvn_index"] = BVNIndex(bvn)
+		}
+		fields["updated_at"] = time.Now()
+
+		// Skip users whose details changed since they were read
+		filter := bson.M{"_id": stored.ID, "phone": stored.Phone, "bvn": stored.BVN}
+		if stored.BVN == "" {
+			filter["bvn"] = bson.M{"$exists": false}
+		}
+		result, err := db.Collection("users").UpdateOne(ctx, filter, bson.M{"$set": fields})
+		if err != nil {
+			return updated, err
+		}
+		updated += int(result.ModifiedCount)
+	}
+	return updated, cursor.Err()
+}
+
+// EnsureUserIndexes creates the indexes used to look users up by phone
+// number and BVN.
+func EnsureUserIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("users").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "phone_index", Value: 1}}},
+		{Keys: bson.D{{Key: "bvn_index", Value: 1}}},
+	})
+	return err
+}
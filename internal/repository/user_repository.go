@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/pkg/fieldcrypt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -29,6 +30,9 @@ func GetAllUsers(db *mongo.Collection) ([]*models.User, error) {
 		if err := cursor.Decode(&user); err != nil {
 			return nil, err
 		}
+		if err := openUserPII(&user); err != nil {
+			return nil, err
+		}
 		users = append(users, &user)
 	}
 
@@ -48,8 +52,6 @@ type UserUpdate struct {
 	Email    string             `bson:"email,omitempty"`
 	Username string             `bson:"username,omitempty"`
 	Phone    string             `bson:"phone,omitempty"`
-	Verified bool               `bson:"verified,omitempty"`
-	IsAdmin  bool               `bson:"is_admin,omitempty"`
 	WalletID primitive.ObjectID `bson:"wallet_id,omitempty"`
 }
 
@@ -77,18 +79,36 @@ func UpdateUser(db *mongo.Database, id primitive.ObjectID, userUpdate *UserUpdat
 			return nil, err
 		}
 	}
+	if userUpdate.Phone != "" {
+		exists, err := UserExistsWithPhone(context.TODO(), db, userUpdate.Phone, &models.User{ID: id})
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, errors.New("phone already exists")
+		}
+	}
 
-	update := bson.M{
-		"$set": bson.M{
-			"email":      userUpdate.Email,
-			"username":   userUpdate.Username,
-			"phone":      userUpdate.Phone,
-			"verified":   userUpdate.Verified,
-			"is_admin":   userUpdate.IsAdmin,
-			"wallet_id":  userUpdate.WalletID,
-			"updated_at": time.Now(),
-		},
+	fields := bson.M{
+		"wallet_id":  userUpdate.WalletID,
+		"updated_at": time.Now(),
+	}
+	// Leave fields that were not provided unchanged
+	if userUpdate.Email != "" {
+		fields["email"] = userUpdate.Email
+	}
+	if userUpdate.Username != "" {
+		fields["username"] = userUpdate.Username
 	}
+	if userUpdate.Phone != "" {
+		phone, err := fieldcrypt.Default().Encrypt(userUpdate.Phone)
+		if err != nil {
+			return nil, err
+		}
+		fields["phone"] = phone
+		fields["phone_index"] = PhoneIndex(userUpdate.Phone)
+	}
+	update := bson.M{"$set": fields}
 
 	var updatedUser models.User
 	err := usersCollection.FindOneAndUpdate(
@@ -103,10 +123,100 @@ func UpdateUser(db *mongo.Database, id primitive.ObjectID, userUpdate *UserUpdat
 		}
 		return nil, err
 	}
+	if err := openUserPII(&updatedUser); err != nil {
+		return nil, err
+	}
 
 	return &updatedUser, nil
 }
 
+// SetUserVerified records whether the user's phone number has been confirmed.
+func SetUserVerified(db *mongo.Database, id primitive.ObjectID, verified bool) error {
+	update := bson.M{"$set": bson.M{"verified": verified, "updated_at": time.Now()}}
+	result, err := db.Collection("users").UpdateOne(context.TODO(), bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// SetUserBVNVerified records when the user's BVN was verified.
+func SetUserBVNVerified(db *mongo.Database, id primitive.ObjectID, at time.Time) error {
+	update := bson.M{"$set": bson.M{"bvn_verified_at": at, "updated_at": time.Now()}}
+	result, err := db.Collection("users").UpdateOne(context.TODO(), bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// SetUserKYCTier records the KYC tier the user was approved for.
+func SetUserKYCTier(db *mongo.Database, id primitive.ObjectID, tier models.KYCTier) error {
+	update := bson.M{"$set": bson.M{"kyc_tier": tier, "updated_at": time.Now()}}
+	result, err := db.Collection("users").UpdateOne(context.TODO(), bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// SetUserRoles replaces the user's platform roles. It also clears the
+// IsAdmin flag, which roles supersede.
+func SetUserRoles(db *mongo.Database, id primitive.ObjectID, roles []models.Role) (*models.User, error) {
+	update := bson.M{"$set": bson.M{"roles": roles, "is_admin": false, "updated_at": time.Now()}}
+	var user models.User
+	err := db.Collection("users").FindOneAndUpdate(
+		context.TODO(),
+		bson.M{"_id": id},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(bson.M{"password": 0}),
+	).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	if err := openUserPII(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CountUsersWithRole counts users holding the role, including IsAdmin
+// accounts without roles when the role is super admin.
+func CountUsersWithRole(db *mongo.Database, role models.Role) (int64, error) {
+	filter := bson.M{"roles": role}
+	if role == models.RoleSuperAdmin {
+		filter = bson.M{"$or": []bson.M{
+			{"roles": role},
+			{"is_admin": true, "roles": bson.M{"$in": []interface{}{nil, bson.A{}}}},
+		}}
+	}
+	return db.Collection("users").CountDocuments(context.TODO(), filter)
+}
+
+func UpdateUserPassword(db *mongo.Database, id primitive.ObjectID, hashedPassword string) error {
+	update := bson.M{"$set": bson.M{"password": hashedPassword, "updated_at": time.Now()}}
+	result, err := db.Collection("users").UpdateOne(context.TODO(), bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
 func GetUserByID(db *mongo.Collection, id primitive.ObjectID) (*models.User, error) {
 	var user models.User
 	err := db.FindOne(context.Background(), bson.M{"_id": id}).Decode(&user)
@@ -116,6 +226,9 @@ func GetUserByID(db *mongo.Collection, id primitive.ObjectID) (*models.User, err
 		}
 		return nil, err
 	}
+	if err := openUserPII(&user); err != nil {
+		return nil, err
+	}
 	return &user, nil
 }
 
@@ -149,37 +262,8 @@ func GetUserByEmail(db *mongo.Collection, email string) (*models.User, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &user, nil
-}
-
-func DeleteUserAndProfile(db *mongo.Database, userID primitive.ObjectID) error {
-	session, err := db.Client().StartSession()
-	if err != nil {
-		return err
-	}
-	defer session.EndSession(context.TODO())
-
-	err = mongo.WithSession(context.TODO(), session, func(sc mongo.SessionContext) error {
-		// Delete user
-		usersCollection := db.Collection("users")
-		_, err := usersCollection.DeleteOne(sc, bson.M{"_id": userID})
-		if err != nil {
-			return err
-		}
-
-		// Delete profile
-		profilesCollection := db.Collection("profiles")
-		_, err = profilesCollection.DeleteOne(sc, bson.M{"user_id": userID})
-		if err != nil {
-			return err
-		}
-
-		return session.CommitTransaction(sc)
-	})
-
-	if err != nil {
-		session.AbortTransaction(context.TODO())
-		return err
+	if err := openUserPII(&user); err != nil {
+		return nil, err
 	}
-	return nil
+	return &user, nil
 }
\ No newline at end of file
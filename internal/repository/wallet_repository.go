@@ -31,8 +31,14 @@ func CreateWallet(db *mongo.Database, wallet *models.Wallet) error {
 }
 
 func GetWalletByUserID(db *mongo.Database, owner_id primitive.ObjectID) (*models.Wallet, error) {
+	return GetWalletByUserIDContext(context.TODO(), db, owner_id)
+}
+
+// GetWalletByUserIDContext is GetWalletByUserID with a context, so a
+// transaction sees its own changes to the wallet.
+func GetWalletByUserIDContext(ctx context.Context, db *mongo.Database, ownerID primitive.ObjectID) (*models.Wallet, error) {
 	var wallet models.Wallet
-	err := db.Collection("wallets").FindOne(context.TODO(), bson.M{"owner_id": owner_id}).Decode(&wallet)
+	err := db.Collection("wallets").FindOne(ctx, bson.M{"owner_id": ownerID, "type": models.WalletTypeUser}).Decode(&wallet)
 	if err != nil {
 		return nil, err
 	}
@@ -69,6 +75,12 @@ func GetWalletByContributionID(db *mongo.Database, contributionID primitive.Obje
 }
 
 func UpdateWalletBalance(db *mongo.Database, walletID primitive.ObjectID, amount float64, isCredit bool) error {
+	return UpdateWalletBalanceContext(context.TODO(), db, walletID, amount, isCredit)
+}
+
+// UpdateWalletBalanceContext is UpdateWalletBalance with a context, so the
+// change can be part of a transaction.
+func UpdateWalletBalanceContext(ctx context.Context, db *mongo.Database, walletID primitive.ObjectID, amount float64, isCredit bool) error {
 	filter := bson.M{"_id": walletID}
 	var update bson.M
 	if isCredit {
@@ -82,7 +94,7 @@ func UpdateWalletBalance(db *mongo.Database, walletID primitive.ObjectID, amount
 			"$set": bson.M{"updated_at": time.Now()},
 		}
 	}
-	result, err := db.Collection("wallets").UpdateOne(context.TODO(), filter, update)
+	result, err := db.Collection("wallets").UpdateOne(ctx, filter, update)
 	if err != nil {
 		return err
 	}
@@ -92,6 +104,24 @@ func UpdateWalletBalance(db *mongo.Database, walletID primitive.ObjectID, amount
 	return nil
 }
 
+// DebitWallet takes amount from a wallet only if its balance covers it, so
+// concurrent debits cannot overdraw it.
+func DebitWallet(ctx context.Context, db *mongo.Database, walletID primitive.ObjectID, amount float64) error {
+	filter := bson.M{"_id": walletID, "balance": bson.M{"$gte": amount}}
+	update := bson.M{
+		"$inc": bson.M{"balance": -amount},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+	result, err := db.Collection("wallets").UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("insufficient balance")
+	}
+	return nil
+}
+
 func UpdateWalletVirtualAccount(db *mongo.Database, walletID primitive.ObjectID, virtualAccountNumber, accountID, accountBank string) error {
 	collection := db.Collection("wallets")
 	ctx := context.Background()
@@ -3,53 +3,173 @@ package routes
 import (
 	"github.com/Gerard-007/ajor_app/internal/auth"
 	"github.com/Gerard-007/ajor_app/internal/handlers"
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/pkg/jobs"
 	"github.com/Gerard-007/ajor_app/pkg/payment"
+	"github.com/Gerard-007/ajor_app/pkg/ratelimit"
+	"github.com/Gerard-007/ajor_app/pkg/sms"
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func InitRoutes(router *gin.Engine, db *mongo.Database, pg payment.PaymentGateway) {
-	usersCollection := db.Collection("users")
+func InitRoutes(router *gin.Engine, db *mongo.Database, pg payment.PaymentGateway, provider sms.Provider, limiter ratelimit.Store, scheduler *jobs.Scheduler) {
+	// Rate limits per route group, each overridable from the environment
+	publicLimit := ratelimit.PerMinuteFromEnv("RATE_LIMIT_PUBLIC_PER_MINUTE", 10)                // per IP
+	loginAccountLimit := ratelimit.PerMinuteFromEnv("RATE_LIMIT_LOGIN_ACCOUNT_PER_MINUTE", 5)    // per email
+	authenticatedLimit := ratelimit.PerMinuteFromEnv("RATE_LIMIT_AUTHENTICATED_PER_MINUTE", 120) // per user
+	sensitiveLimit := ratelimit.PerMinuteFromEnv("RATE_LIMIT_SENSITIVE_PER_MINUTE", 10)          // per user
+	partnerLimit := ratelimit.PerMinuteFromEnv("RATE_LIMIT_PARTNER_PER_MINUTE", 300)             // per API key
+
+	// Public keys for verifying access tokens
+	router.GET("/.well-known/jwks.json", handlers.JWKSHandler())
+
 	// Authentication routes
-	router.POST("/login", handlers.LoginHandler(usersCollection))
-	router.POST("/register", handlers.RegisterHandler(db, pg))
-	router.POST("/logout", handlers.LogoutHandler(db))
+	public := router.Group("/")
+	public.Use(auth.RateLimitByIP(limiter, "public", publicLimit))
+	{
+		public.POST("/login", handlers.LoginHandler(db, limiter, loginAccountLimit))
+		public.POST("/login/mfa", handlers.LoginMFAHandler(db))
+		public.POST("/register", handlers.RegisterHandler(db, pg, provider))
+		public.POST("/token/refresh", handlers.RefreshTokenHandler(db))
+		public.POST("/password/forgot", handlers.ForgotPasswordHandler(db, provider))
+		public.POST("/password/reset", handlers.ResetPasswordHandler(db))
+	}
 
 	// Authenticated routes
 	authenticated := router.Group("/")
-	authenticated.Use(auth.AuthMiddleware(db))
+	authenticated.Use(auth.AuthMiddleware(db), auth.RateLimitByAccount(limiter, "authenticated", authenticatedLimit))
+	verified := auth.RequireVerified(db)
+	pin := auth.RequireTransactionPIN(db)
+	mfa := auth.RequireMFA(db)
 	{
+		// Account security routes, which guess codes, PINs or passwords
+		sensitive := authenticated.Group("/")
+		sensitive.Use(auth.RateLimitByAccount(limiter, "sensitive", sensitiveLimit))
+		// Phone verification routes
+		sensitive.POST("/phone/verify/send", handlers.SendPhoneVerificationHandler(db, provider))
+		sensitive.POST("/phone/verify", handlers.VerifyPhoneHandler(db))
+		// Session routes
+		authenticated.POST("/logout", handlers.LogoutHandler(db))
+		authenticated.GET("/sessions", handlers.GetSessionsHandler(db))
+		authenticated.DELETE("/sessions/:id", handlers.RevokeSessionHandler(db))
+		authenticated.DELETE("/sessions", handlers.RevokeAllSessionsHandler(db))
+		authenticated.POST("/events/tickets", handlers.CreateStreamTicketHandler(db))
+		sensitive.POST("/password/change", handlers.ChangePasswordHandler(db))
+		sensitive.POST("/me/close", mfa, pin, handlers.CloseAccountHandler(db, pg))
+		sensitive.GET("/me/export", mfa, handlers.ExportUserDataHandler(db))
+		// Two-factor authentication routes
+		authenticated.GET("/mfa", handlers.GetMFAStatusHandler(db))
+		sensitive.POST("/mfa/setup", handlers.SetupMFAHandler(db))
+		sensitive.POST("/mfa/enable", handlers.EnableMFAHandler(db))
+		sensitive.POST("/mfa/disable", handlers.DisableMFAHandler(db))
+		sensitive.POST("/mfa/recovery-codes", handlers.RegenerateRecoveryCodesHandler(db))
+		// Transaction PIN routes
+		sensitive.POST("/pin", handlers.SetTransactionPINHandler(db))
+		sensitive.PUT("/pin", handlers.ChangeTransactionPINHandler(db))
+		sensitive.POST("/pin/reset/send", handlers.SendPINResetHandler(db, provider))
+		sensitive.POST("/pin/reset", handlers.ResetTransactionPINHandler(db))
 		// User routes
 		authenticated.GET("/users/:id", handlers.GetUserByIdHandler(db))
-		authenticated.GET("/admin/users", handlers.GetAllUsersHandler(usersCollection))
 		authenticated.GET("/profile/:id", handlers.GetUserProfileHandler(db))
 		authenticated.PUT("/profile/:id", handlers.UpdateUserProfileHandler(db))
 		authenticated.PUT("/users/:id", handlers.UpdateUserHandler(db))
-		authenticated.DELETE("/users/:id", handlers.DeleteUserHandler(db))
+		// KYC routes
+		authenticated.GET("/kyc", handlers.GetKYCStatusHandler(db))
+		authenticated.POST("/kyc/upgrade", verified, handlers.RequestKYCUpgradeHandler(db, pg))
 		// Contribution routes
 		authenticated.POST("/contributions", handlers.CreateContributionHandler(db, pg))
 		authenticated.GET("/contributions/:id", handlers.GetContributionHandler(db))
 		authenticated.GET("/contributions/:id/wallet", handlers.GetContributionWalletHandler(db, pg))
 		authenticated.GET("/contributions/:id/transactions", handlers.GetContributionTransactionsHandler(db))
 		authenticated.GET("/contributions", handlers.GetUserContributionsHandler(db))
-		authenticated.PUT("/contributions/:id", handlers.UpdateContributionHandler(db))
-		authenticated.POST("/contributions/join", handlers.JoinContributionHandler(db))
-		authenticated.DELETE("/contributions/:id/:user_id", handlers.RemoveMemberHandler(db))
-		authenticated.POST("/contributions/:id/contribute", handlers.RecordContributionHandler(db))
-		authenticated.POST("/contributions/:id/payout", handlers.RecordPayoutHandler(db))
+		authenticated.PUT("/contributions/:id", mfa, handlers.UpdateContributionHandler(db))
+		authenticated.POST("/contributions/join", verified, handlers.JoinContributionHandler(db))
+		authenticated.DELETE("/contributions/:id/:user_id", mfa, handlers.RemoveMemberHandler(db))
+		authenticated.POST("/contributions/:id/contribute", verified, pin, handlers.RecordContributionHandler(db))
+		authenticated.POST("/contributions/:id/payout", verified, mfa, pin, handlers.RecordPayoutHandler(db))
 		authenticated.GET("/notifications", handlers.GetUserNotificationsHandler(db))
-		authenticated.GET("/admin/contributions", handlers.GetAllContributionsHandler(db))
+		authenticated.GET("/notifications/unread-count", handlers.GetUnreadNotificationCountHandler(db))
+		authenticated.PUT("/notifications/read", handlers.MarkAllNotificationsReadHandler(db))
+		authenticated.PUT("/notifications/:id/read", handlers.MarkNotificationReadHandler(db))
+		authenticated.DELETE("/notifications/:id", handlers.DeleteNotificationHandler(db))
+		authenticated.GET("/notifications/:id/deliveries", handlers.GetNotificationDeliveriesHandler(db))
+		authenticated.GET("/me/notification-preferences", handlers.GetNotificationPreferencesHandler(db))
+		authenticated.PUT("/me/notification-preferences", handlers.UpdateNotificationPreferencesHandler(db))
 		authenticated.GET("/contributions/groups/:user_id", handlers.GetUserContributionsByUserIdHandler(db))
 		// Collection routes
 		authenticated.POST("/contributions/:id/collections", handlers.CreateCollectionHandler(db))
 		authenticated.GET("/contributions/:id/collections", handlers.GetCollectionsHandler(db))
+		// Guarantee routes
+		authenticated.GET("/guarantees", handlers.GetUserGuaranteesHandler(db))
+		authenticated.PUT("/guarantees/:guarantee_id", verified, handlers.RespondToGuaranteeHandler(db))
+		authenticated.POST("/guarantees/:guarantee_id/claim", verified, handlers.FileGuaranteeClaimHandler(db))
+		authenticated.GET("/contributions/:id/guarantees", handlers.GetContributionGuaranteesHandler(db))
+		authenticated.GET("/contributions/:id/members/:user_id/arrears", handlers.GetMemberArrearsHandler(db))
+		// Loan routes
+		authenticated.POST("/contributions/:id/loans", verified, handlers.ApplyForLoanHandler(db))
+		authenticated.GET("/contributions/:id/loans", handlers.GetContributionLoansHandler(db))
+		authenticated.GET("/loans/:loan_id", handlers.GetLoanHandler(db))
+		authenticated.POST("/loans/:loan_id/repay", verified, pin, handlers.RepayLoanHandler(db))
 		// Approval routes
-		authenticated.PUT("/approvals/:approval_id", handlers.ApprovePayoutHandler(db))
+		authenticated.PUT("/approvals/:approval_id", verified, mfa, pin, handlers.ApprovePayoutHandler(db))
 		authenticated.GET("/approvals", handlers.GetPendingApprovalsHandler(db))
+		// Holiday calendar routes
+		authenticated.GET("/holidays", handlers.GetHolidaysHandler(db))
 		// Wallet routes
 		authenticated.GET("/wallet", handlers.GetUserWalletHandler(db, pg))
-		authenticated.POST("/wallet/fund", handlers.FundWalletHandler(db, pg))
+		authenticated.POST("/wallet/fund", verified, handlers.FundWalletHandler(db, pg))
 		authenticated.GET("/wallet/transactions", handlers.GetUserTransactionsHandler(db))
-		authenticated.DELETE("/wallet", handlers.DeleteWalletHandler(db, pg))
+		authenticated.DELETE("/wallet", pin, handlers.DeleteWalletHandler(db, pg))
+		// Organisation routes
+		authenticated.POST("/organisations", verified, handlers.CreateOrganisationHandler(db, pg))
+		authenticated.GET("/organisations", handlers.GetUserOrganisationsHandler(db))
+		authenticated.POST("/organisations/join", verified, handlers.JoinOrganisationHandler(db))
+		authenticated.GET("/organisations/:id", handlers.GetOrganisationHandler(db))
+		authenticated.DELETE("/organisations/:id/staff/:user_id", handlers.RemoveOrganisationStaffHandler(db))
+		authenticated.POST("/organisations/:id/contributions/:contribution_id", mfa, handlers.LinkOrganisationContributionHandler(db))
+		authenticated.GET("/organisations/:id/api-keys", handlers.GetAPIKeysHandler(db))
+		authenticated.GET("/organisations/:id/api-keys/:key_id/usage", handlers.GetAPIKeyUsageHandler(db))
+		sensitive.POST("/organisations/:id/api-keys", mfa, handlers.CreateAPIKeyHandler(db))
+		sensitive.DELETE("/organisations/:id/api-keys/:key_id", mfa, handlers.RevokeAPIKeyHandler(db))
+		sensitive.PUT("/organisations/:id/webhook", mfa, handlers.SetOrganisationWebhookHandler(db))
+		sensitive.DELETE("/organisations/:id/webhook", mfa, handlers.RemoveOrganisationWebhookHandler(db))
+
+		// Platform administration routes; each checks its own permission
+		admin := authenticated.Group("/admin")
+		admin.Use(auth.RequireAdmin(db), mfa)
+		admin.GET("/roles", handlers.GetRolesHandler())
+		admin.GET("/users", auth.RequirePermission(db, models.PermUsersRead), handlers.GetAllUsersHandler(db))
+		admin.DELETE("/users/:id", auth.RequirePermission(db, models.PermUsersWrite), handlers.DeleteUserHandler(db, pg))
+		admin.PUT("/users/:id/roles", auth.RequirePermission(db, models.PermRolesAssign), handlers.AssignRolesHandler(db))
+		admin.GET("/contributions", auth.RequirePermission(db, models.PermContributionsRead), handlers.GetAllContributionsHandler(db))
+		admin.GET("/transactions", auth.RequirePermission(db, models.PermTransactionsRead), handlers.GetAllTransactionsHandler(db))
+		admin.POST("/holidays", auth.RequirePermission(db, models.PermHolidaysWrite), handlers.AddHolidayHandler(db))
+		admin.DELETE("/holidays/:id", auth.RequirePermission(db, models.PermHolidaysWrite), handlers.DeleteHolidayHandler(db))
+		admin.GET("/audit-events", auth.RequirePermission(db, models.PermAuditRead), handlers.GetAuditEventsHandler(db))
+		admin.GET("/kyc/requests", auth.RequirePermission(db, models.PermKYCReview), handlers.GetKYCRequestsHandler(db))
+		admin.GET("/kyc/requests/:id/documents/:type", auth.RequirePermission(db, models.PermKYCReview), handlers.GetKYCDocumentHandler(db))
+		admin.PUT("/kyc/requests/:id", auth.RequirePermission(db, models.PermKYCReview), handlers.ReviewKYCRequestHandler(db))
+		admin.GET("/jobs", auth.RequirePermission(db, models.PermJobsRead), handlers.GetJobsHandler(scheduler))
+		admin.GET("/jobs/runs", auth.RequirePermission(db, models.PermJobsRead), handlers.GetJobRunsHandler(scheduler))
+		admin.POST("/jobs/:name/run", auth.RequirePermission(db, models.PermJobsRun), handlers.RunJobHandler(scheduler))
+	}
+
+	// Real-time event stream; browsers cannot set headers on it, so they
+	// open it with a single-use ticket instead
+	stream := router.Group("/")
+	stream.Use(auth.StreamAuthMiddleware(db), auth.RateLimitByAccount(limiter, "authenticated", authenticatedLimit))
+	{
+		stream.GET("/events", handlers.EventStreamHandler(db))
+	}
+
+	// Partner routes for organisations' own systems, authenticated by API key
+	partner := router.Group("/partner")
+	partner.Use(auth.APIKeyMiddleware(db), auth.RateLimitByAPIKey(limiter, "partner", partnerLimit))
+	{
+		partner.GET("/staff", auth.RequireAPIScope(models.APIScopeStaffRead), handlers.PartnerGetStaffHandler(db))
+		partner.GET("/contributions", auth.RequireAPIScope(models.APIScopeContributionsRead), handlers.PartnerGetContributionsHandler(db))
+		partner.POST("/contributions/:id/members", auth.RequireAPIScope(models.APIScopeMembersWrite), handlers.PartnerEnrolStaffHandler(db))
+		partner.POST("/contributions/:id/payments", auth.RequireAPIScope(models.APIScopePaymentsWrite), handlers.PartnerPayContributionHandler(db))
+		partner.GET("/wallet", auth.RequireAPIScope(models.APIScopeWalletRead), handlers.PartnerGetWalletHandler(db))
 	}
 }
\ No newline at end of file
@@ -0,0 +1,382 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/messages"
+	"github.com/Gerard-007/ajor_app/pkg/payment"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// CloseAccount closes a user's account, at their own request or an
+// admin's. Users confirm with their password; admins need permission to
+// write users. Any balance is paid out first, to a bank account in the
+// name the user's BVN is registered to. Transactions, the
+// wallet, loans and KYC records are kept for financial record keeping,
+// while contact details and everything else personal is removed.
+//
+// An account with a balance stays open, signed out and unable to sign in,
+// until SettleWithdrawals learns the payout went through; it reports
+// whether the account is already closed.
+func CloseAccount(ctx context.Context, db *mongo.Database, pg payment.PaymentGateway, actorID, userID primitive.ObjectID, password string, bank *models.BankAccount) (bool, error) {
+	user, err := repository.GetUserByID(db.Collection("users"), userID)
+	if err != nil {
+		return false, errors.New("user not found")
+	}
+	if actorID == userID {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+			return false, errors.New("current password is incorrect")
+		}
+	} else if err := RequirePermission(ctx, db, actorID, models.PermUsersWrite); err != nil {
+		return false, err
+	}
+	if user.ClosedAt != nil {
+		return false, errors.New("account already closed")
+	}
+	if user.Closure != nil {
+		return false, errors.New("account closure already in progress")
+	}
+	if err := checkAccountClosable(ctx, db, userID); err != nil {
+		return false, err
+	}
+
+	wallet, err := repository.GetWalletByUserID(db, userID)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return false, err
+	}
+	if wallet == nil || wallet.Balance <= 0 {
+		return true, finishAccountClosure(ctx, db, pg, user, actorID, 0)
+	}
+
+	if bank == nil || bank.BankCode == "" || bank.AccountNumber == "" {
+		return false, fmt.Errorf("a bank account is required to pay out the remaining balance of %.2f", wallet.Balance)
+	}
+	if err := verifyPayoutAccount(ctx, db, pg, user, bank); err != nil {
+		return false, err
+	}
+	paidOut, err := payOutBalance(ctx, db, pg, user, actorID, bank)
+	if err != nil {
+		return false, err
+	}
+	if paidOut == 0 {
+		// The balance was spent meanwhile, so there is nothing to wait for
+		return true, finishAccountClosure(ctx, db, pg, user, actorID, 0)
+	}
+	if _, err := repository.RevokeUserSessions(ctx, db, userID, "account closing", nil); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+// finishAccountClosure closes an account whose balance has been paid out.
+// Personal data goes before the account is anonymised, which ends its
+// closure, so a closure that fails partway is finished on the next try.
+func finishAccountClosure(ctx context.Context, db *mongo.Database, pg payment.PaymentGateway, user *models.User, actorID primitive.ObjectID, paidOut float64) error {
+	if wallet, err := repository.GetWalletByUserID(db, user.ID); err == nil && wallet.VirtualAccountID != "" {
+		if err := pg.DeactivateVirtualAccount(ctx, wallet.VirtualAccountID); err != nil {
+			log.Printf("Failed to deactivate virtual account of closed user %s: %v", user.ID.Hex(), err)
+		}
+	}
+	if _, err := repository.RevokeUserSessions(ctx, db, user.ID, "account closed", nil); err != nil {
+		return err
+	}
+	if profile, err := repository.GetProfileByUserID(db.Collection("profiles"), user.ID); err == nil && profile.ProfilePic != "" {
+		if err := os.Remove(profile.ProfilePic); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove profile picture of closed user %s: %v", user.ID.Hex(), err)
+		}
+	}
+	if err := repository.DeleteUserPersonalData(ctx, db, user.ID, loginKey(user.Email)); err != nil {
+		return err
+	}
+	if err := repository.AnonymiseUser(ctx, db, user.ID, time.Now()); err != nil {
+		return err
+	}
+
+	event := &models.AuditEvent{
+		Type:    models.AuditAccountClosed,
+		UserID:  &user.ID,
+		Details: map[string]interface{}{"balance_paid_out": paidOut},
+	}
+	if actorID != user.ID {
+		event.ActorID = &actorID
+	}
+	recordAuditEvent(ctx, db, event)
+	return nil
+}
+
+// checkAccountClosable refuses closure while the user still has money or
+// obligations tied up in groups.
+func checkAccountClosable(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) error {
+	contributions, err := repository.GetContributionsByUser(ctx, db, userID)
+	if err != nil {
+		return err
+	}
+	if len(contributions) > 0 {
+		return errors.New("account still has active contributions; leave them or hand them over first")
+	}
+
+	loans, err := repository.GetLoans(ctx, db, bson.M{
+		"borrower_id": userID,
+		"status":      bson.M{"$in": []models.LoanStatus{models.LoanPending, models.LoanActive}},
+	})
+	if err != nil {
+		return err
+	}
+	if len(loans) > 0 {
+		return errors.New("account still has outstanding loans")
+	}
+
+	guarantees, err := repository.GetGuarantees(ctx, db, bson.M{
+		"guarantor_id": userID,
+		"status":       bson.M{"$in": []models.GuaranteeStatus{models.GuaranteePending, models.GuaranteeAccepted}},
+	})
+	if err != nil {
+		return err
+	}
+	if len(guarantees) > 0 {
+		return errors.New("account still guarantees other members")
+	}
+
+	organisations, err := repository.GetOrganisationsByOwner(ctx, db, userID)
+	if err != nil {
+		return err
+	}
+	if len(organisations) > 0 {
+		return errors.New("account still owns organisations")
+	}
+
+	if wallet, err := repository.GetWalletByUserID(db, userID); err == nil {
+		pending, err := repository.GetTransactions(ctx, db, bson.M{
+			"$or":    []bson.M{{"from_wallet": wallet.ID}, {"to_wallet": wallet.ID}},
+			"status": models.StatusPending,
+		})
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return errors.New("account still has pending transactions")
+		}
+	}
+	return nil
+}
+
+// verifyPayoutAccount checks that a bank account belongs to the user: the
+// user's BVN must be verified and the account held in the name it is
+// registered to. Whoever closes the account, its balance goes to its owner.
+func verifyPayoutAccount(ctx context.Context, db *mongo.Database, pg payment.PaymentGateway, user *models.User, bank *models.BankAccount) error {
+	details, err := verifyBVN(ctx, db, pg, user)
+	if err != nil {
+		return err
+	}
+	account, err := pg.ResolveBankAccount(ctx, bank.BankCode, bank.AccountNumber)
+	if err != nil {
+		return fmt.Errorf("failed to verify bank account: %w", err)
+	}
+	if !accountNameMatches(account.AccountName, details) {
+		return errors.New("bank account not verified: it is not in the name the BVN is registered to")
+	}
+	return nil
+}
+
+// accountNameMatches reports whether a bank account name contains both the
+// first and last name of a BVN, in any order, case and punctuation.
+func accountNameMatches(accountName string, details *payment.BVNDetails) bool {
+	words := make(map[string]bool)
+	for _, word := range nameWords(accountName) {
+		words[word] = true
+	}
+	first, last := nameWords(details.FirstName), nameWords(details.LastName)
+	if len(first) == 0 || len(last) == 0 {
+		return false
+	}
+	for _, word := range append(first, last...) {
+		if !words[word] {
+			return false
+		}
+	}
+	return true
+}
+
+func nameWords(name string) []string {
+	return strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+}
+
+// payOutBalance moves the user's whole wallet balance to a bank account and
+// returns the amount. The wallet is debited, and the account marked as
+// being closed, when the transfer is started; the withdrawal stays pending
+// until SettleWithdrawals learns from the provider whether it went
+// through, and is refunded if it did not.
+func payOutBalance(ctx context.Context, db *mongo.Database, pg payment.PaymentGateway, user *models.User, actorID primitive.ObjectID, bank *models.BankAccount) (float64, error) {
+	transaction := &models.Transaction{
+		Type:          models.TransactionWithdrawal,
+		Date:          time.Now(),
+		PaymentMethod: models.PaymentBankTransfer,
+		Status:        models.StatusPending,
+	}
+	err := inTransaction(ctx, db, func(ctx context.Context) error {
+		wallet, err := repository.GetWalletByUserIDContext(ctx, db, user.ID)
+		if err != nil {
+			return err
+		}
+		transaction.FromWallet = wallet.ID
+		transaction.Amount = wallet.Balance
+		if wallet.Balance <= 0 {
+			return nil
+		}
+		if err := checkOutflowLimit(ctx, db, user, wallet, transaction.Type, wallet.Balance); err != nil {
+			return err
+		}
+		if err := repository.DebitWallet(ctx, db, wallet.ID, wallet.Balance); err != nil {
+			return err
+		}
+		if err := repository.CreateTransaction(ctx, db, transaction); err != nil {
+			return err
+		}
+		return repository.StartAccountClosure(ctx, db, user.ID, &models.AccountClosure{
+			RequestedAt:  time.Now(),
+			RequestedBy:  actorID,
+			WithdrawalID: transaction.ID,
+		})
+	})
+	if err != nil || transaction.Amount <= 0 {
+		return 0, err
+	}
+
+	transfer, err := pg.TransferToBank(ctx, payment.BankTransfer{
+		BankCode:      bank.BankCode,
+		AccountNumber: bank.AccountNumber,
+		Amount:        transaction.Amount,
+		Currency:      "NGN",
+		Narration:     "AjoR account closure",
+		Reference:     transaction.ID.Hex(),
+	})
+	if err != nil {
+		if refundErr := settleWithdrawal(ctx, db, pg, transaction, models.StatusFailed); refundErr != nil {
+			log.Printf("Failed to refund withdrawal %s: %v", transaction.ID.Hex(), refundErr)
+		}
+		return 0, fmt.Errorf("failed to pay out remaining balance: %v", err)
+	}
+	if err := repository.SetTransactionTransferID(ctx, db, transaction.ID, transfer.TransactionID); err != nil {
+		// The money is on its way, so the withdrawal must not be refunded;
+		// it stays pending for someone to settle by hand
+		log.Printf("Failed to record transfer %s of withdrawal %s: %v", transfer.TransactionID, transaction.ID.Hex(), err)
+		return 0, err
+	}
+	return transaction.Amount, nil
+}
+
+// SettleWithdrawals asks the payment provider how pending bank payouts
+// went. Completed ones are marked successful and failed ones refunded to
+// the wallet, reopening the account they were closing; the rest are left
+// for the next run. Accounts whose payout has gone through are then
+// closed. It returns how many withdrawals were settled.
+func SettleWithdrawals(ctx context.Context, db *mongo.Database, pg payment.PaymentGateway) (int, error) {
+	pending, err := repository.GetTransactions(ctx, db, bson.M{
+		"type":        models.TransactionWithdrawal,
+		"status":      models.StatusPending,
+		"transfer_id": bson.M{"$exists": true},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	settled := 0
+	for i := range pending {
+		transaction := &pending[i]
+		transfer, err := pg.GetTransfer(ctx, transaction.TransferID)
+		if err != nil {
+			log.Printf("Failed to check transfer %s of withdrawal %s: %v", transaction.TransferID, transaction.ID.Hex(), err)
+			continue
+		}
+		var status models.TransactionStatus
+		switch transfer.Status {
+		case payment.TransferSuccessful:
+			status = models.StatusSuccess
+		case payment.TransferFailed:
+			status = models.StatusFailed
+		default:
+			continue
+		}
+		if err := settleWithdrawal(ctx, db, pg, transaction, status); err != nil {
+			log.Printf("Failed to settle withdrawal %s: %v", transaction.ID.Hex(), err)
+			continue
+		}
+		settled++
+	}
+	return settled, finishPaidOutClosures(ctx, db, pg)
+}
+
+// settleWithdrawal gives a pending withdrawal its final status. If it
+// failed the money goes back in the wallet, and an account it was closing
+// is reopened and its owner told. A withdrawal already settled elsewhere
+// is left alone.
+func settleWithdrawal(ctx context.Context, db *mongo.Database, pg payment.PaymentGateway, transaction *models.Transaction, status models.TransactionStatus) error {
+	return inTransaction(ctx, db, func(ctx context.Context) error {
+		err := repository.SettleTransaction(ctx, db, transaction.ID, status)
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		transaction.Status = status
+		if status == models.StatusSuccess {
+			return queueTransactionEvents(ctx, db, transaction)
+		}
+
+		if err := repository.UpdateWalletBalanceContext(ctx, db, transaction.FromWallet, transaction.Amount, true); err != nil {
+			return err
+		}
+		user, err := repository.GetUserClosingWith(ctx, db, transaction.ID)
+		if err != nil || user == nil {
+			return err
+		}
+		if err := repository.CancelAccountClosure(ctx, db, user.ID); err != nil {
+			return err
+		}
+		log.Printf("Reopened account %s: closure payout %s failed", user.ID.Hex(), transaction.ID.Hex())
+		return queueNotification(ctx, db, &models.Notification{
+			UserID:   user.ID,
+			Template: messages.AccountClosureFailed,
+			Params:   messages.Params{"Amount": transaction.Amount},
+			Type:     models.NotificationWarning,
+			Category: models.CategoryPayouts,
+		})
+	})
+}
+
+// finishPaidOutClosures closes every account being closed whose payout
+// has gone through.
+func finishPaidOutClosures(ctx context.Context, db *mongo.Database, pg payment.PaymentGateway) error {
+	users, err := repository.GetClosingUsers(ctx, db)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		withdrawals, err := repository.GetTransactions(ctx, db, bson.M{"_id": user.Closure.WithdrawalID})
+		if err != nil {
+			return err
+		}
+		if len(withdrawals) == 0 || withdrawals[0].Status != models.StatusSuccess {
+			continue
+		}
+		if err := finishAccountClosure(ctx, db, pg, user, user.Closure.RequestedBy, withdrawals[0].Amount); err != nil {
+			log.Printf("Failed to close account %s: %v", user.ID.Hex(), err)
+		}
+	}
+	return nil
+}
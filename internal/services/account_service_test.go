@@ -0,0 +1,32 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/Gerard-007/ajor_app/pkg/payment"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountNameMatches(t *testing.T) {
+	bvn := &payment.BVNDetails{FirstName: "Adaeze", LastName: "Okafor"}
+
+	tests := []struct {
+		name        string
+		accountName string
+		details     *payment.BVNDetails
+		want        bool
+	}{
+		{"same order", "Adaeze Okafor", bvn, true},
+		{"surname first in capitals", "OKAFOR ADAEZE", bvn, true},
+		{"with a middle name and punctuation", "OKAFOR, ADAEZE CHIOMA", bvn, true},
+		{"someone else", "Chinedu Okafor", bvn, false},
+		{"first name only", "Adaeze", bvn, false},
+		{"name merely contained in a word", "Adaezeokafor Ltd", bvn, false},
+		{"BVN without a name", "Adaeze Okafor", &payment.BVNDetails{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, accountNameMatches(tt.accountName, tt.details))
+		})
+	}
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/fieldcrypt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// apiKeyPrefix starts every key, so leaked keys are easy to spot in logs
+// and by secret scanners.
+const apiKeyPrefix = "ajor_"
+
+// signatureMaxSkew is how far a signed request's timestamp may be from the
+// server clock, and so how long its signature must be remembered.
+const signatureMaxSkew = 5 * time.Minute
+
+// CreateAPIKey issues a key for the organisation. The key and signing
+// secret are only returned here; only a hash of the key is stored.
+func CreateAPIKey(ctx context.Context, db *mongo.Database, organisationID, actorID primitive.ObjectID, name string, scopes []models.APIKeyScope, allowedIPs []string, requireSignature bool) (*models.CreatedAPIKey, error) {
+	if _, err := getOwnedOrganisation(ctx, db, organisationID, actorID); err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	if len(scopes) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !isValidAPIKeyScope(scope) {
+			return nil, fmt.Errorf("invalid scope: %s", scope)
+		}
+	}
+	for _, allowed := range allowedIPs {
+		if net.ParseIP(allowed) == nil {
+			if _, _, err := net.ParseCIDR(allowed); err != nil {
+				return nil, fmt.Errorf("invalid IP address or range: %s", allowed)
+			}
+		}
+	}
+	if allowedIPs == nil {
+		allowedIPs = []string{}
+	}
+
+	prefix, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomHex(24)
+	if err != nil {
+		return nil, err
+	}
+	signingSecret, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	sealedSecret, err := fieldcrypt.Default().Encrypt(signingSecret)
+	if err != nil {
+		return nil, err
+	}
+	rawKey := apiKeyPrefix + prefix + "_" + secret
+
+	key := &models.APIKey{
+		OrganisationID:   organisationID,
+		Name:             name,
+		Prefix:           prefix,
+		KeyHash:          hashAPIKey(rawKey),
+		SigningSecret:    sealedSecret,
+		Scopes:           scopes,
+		AllowedIPs:       allowedIPs,
+		RequireSignature: requireSignature,
+		CreatedBy:        actorID,
+	}
+	if err := repository.CreateAPIKey(ctx, db, key); err != nil {
+		return nil, err
+	}
+
+	recordAuditEvent(ctx, db, &models.AuditEvent{
+		Type:    models.AuditAPIKeyCreated,
+		UserID:  &actorID,
+		Subject: organisationID.Hex(),
+		Details: map[string]interface{}{"api_key_id": key.ID.Hex(), "name": name, "scopes": scopes},
+	})
+	return &models.CreatedAPIKey{APIKey: key, Key: rawKey, SigningSecret: signingSecret}, nil
+}
+
+func isValidAPIKeyScope(scope models.APIKeyScope) bool {
+	for _, s := range models.APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashAPIKey hashes a key for storage. Keys are long and random, so a
+// plain SHA-256 is enough and keeps every request's check cheap.
+func hashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func GetAPIKeys(ctx context.Context, db *mongo.Database, organisationID, actorID primitive.ObjectID) ([]*models.APIKey, error) {
+	if _, err := getOwnedOrganisation(ctx, db, organisationID, actorID); err != nil {
+		return nil, err
+	}
+	return repository.GetAPIKeys(ctx, db, organisationID)
+}
+
+// GetAPIKeyUsage returns the key's daily request counts over the last 30
+// days.
+func GetAPIKeyUsage(ctx context.Context, db *mongo.Database, organisationID, keyID, actorID primitive.ObjectID) ([]*models.APIKeyUsage, error) {
+	keys, err := GetAPIKeys(ctx, db, organisationID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if key.ID == keyID {
+			return repository.GetAPIKeyUsage(ctx, db, keyID, time.Now().AddDate(0, 0, -30))
+		}
+	}
+	return nil, errors.New("api key not found")
+}
+
+func RevokeAPIKey(ctx context.Context, db *mongo.Database, organisationID, keyID, actorID primitive.ObjectID) error {
+	if _, err := getOwnedOrganisation(ctx, db, organisationID, actorID); err != nil {
+		return err
+	}
+	if err := repository.RevokeAPIKey(ctx, db, organisationID, keyID); err != nil {
+		return err
+	}
+	recordAuditEvent(ctx, db, &models.AuditEvent{
+		Type:    models.AuditAPIKeyRevoked,
+		UserID:  &actorID,
+		Subject: organisationID.Hex(),
+		Details: map[string]interface{}{"api_key_id": keyID.Hex()},
+	})
+	return nil
+}
+
+// AuthenticateAPIKey returns the key a request was made with, checking it
+// has not been revoked and the request comes from an allowed IP. Unknown,
+// malformed and mismatched keys all get the same error.
+func AuthenticateAPIKey(ctx context.Context, db *mongo.Database, rawKey, ip string) (*models.APIKey, error) {
+	invalid := errors.New("invalid api key")
+	parts := strings.Split(strings.TrimPrefix(rawKey, apiKeyPrefix), "_")
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) || len(parts) != 2 {
+		return nil, invalid
+	}
+	key, err := repository.GetAPIKeyByPrefix(ctx, db, parts[0])
+	if err != nil {
+		if err.Error() == "api key not found" {
+			return nil, invalid
+		}
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hashAPIKey(rawKey)), []byte(key.KeyHash)) != 1 {
+		return nil, invalid
+	}
+	if key.RevokedAt != nil {
+		return nil, errors.New("api key revoked")
+	}
+	if !ipAllowed(key.AllowedIPs, ip) {
+		return nil, errors.New("ip address not allowed")
+	}
+	return key, nil
+}
+
+func ipAllowed(allowedIPs []string, ip string) bool {
+	if len(allowedIPs) == 0 {
+		return true
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, allowed := range allowedIPs {
+		if allowedIP := net.ParseIP(allowed); allowedIP != nil {
+			if allowedIP.Equal(parsed) {
+				return true
+			}
+			continue
+		}
+		if _, network, err := net.ParseCIDR(allowed); err == nil && network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyRequestSignature checks a request's X-Signature: the hex
+// HMAC-SHA256, under the key's signing secret, of
+// "<timestamp>\n<method>\n<path and query>\n<body>". Each signature is
+// accepted once; it is remembered until its timestamp leaves the allowed
+// window, so a captured request cannot be replayed.
+func VerifyRequestSignature(ctx context.Context, db *mongo.Database, key *models.APIKey, timestamp, signature, method, uri string, body []byte) error {
+	if timestamp == "" || signature == "" {
+		return errors.New("request signature is required")
+	}
+	signedAt, err := signatureTimestamp(timestamp, time.Now())
+	if err != nil {
+		return err
+	}
+	secret, err := fieldcrypt.Default().Decrypt(key.SigningSecret)
+	if err != nil {
+		return err
+	}
+	given, err := hex.DecodeString(signature)
+	if err != nil {
+		return errors.New("invalid request signature")
+	}
+	if !hmac.Equal(given, requestSignature(secret, timestamp, method, uri, body)) {
+		return errors.New("invalid request signature")
+	}
+
+	// Stored in one spelling, so re-encoding the hex is no way around it
+	err = repository.RecordRequestSignature(ctx, db, key.ID, hex.EncodeToString(given), signedAt.Add(signatureMaxSkew))
+	if mongo.IsDuplicateKeyError(err) {
+		return errors.New("request signature already used")
+	}
+	return err
+}
+
+// signatureTimestamp parses a request's X-Timestamp and checks it is within
+// signatureMaxSkew of now.
+func signatureTimestamp(timestamp string, now time.Time) (time.Time, error) {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return time.Time{}, errors.New("invalid signature timestamp")
+	}
+	signedAt := time.Unix(seconds, 0)
+	skew := now.Sub(signedAt)
+	if skew > signatureMaxSkew || skew < -signatureMaxSkew {
+		return time.Time{}, errors.New("signature timestamp outside the allowed window")
+	}
+	return signedAt, nil
+}
+
+// requestSignature is the HMAC a partner sends as X-Signature.
+func requestSignature(secret, timestamp, method, uri string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s\n%s\n%s\n", timestamp, method, uri)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// RecordAPIKeyUsage counts a request made with the key.
+func RecordAPIKeyUsage(ctx context.Context, db *mongo.Database, keyID primitive.ObjectID, ip string) error {
+	return repository.RecordAPIKeyUsage(ctx, db, keyID, ip, time.Now())
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignatureTimestamp(t *testing.T) {
+	now := time.Unix(1750000000, 0)
+
+	tests := []struct {
+		name      string
+		timestamp string
+		wantErr   string
+	}{
+		{"now", strconv.FormatInt(now.Unix(), 10), ""},
+		{"at the edge of the window", strconv.FormatInt(now.Add(-signatureMaxSkew).Unix(), 10), ""},
+		{"too old", strconv.FormatInt(now.Add(-signatureMaxSkew-time.Second).Unix(), 10), "signature timestamp outside the allowed window"},
+		{"too far ahead", strconv.FormatInt(now.Add(signatureMaxSkew+time.Second).Unix(), 10), "signature timestamp outside the allowed window"},
+		{"not a number", "yesterday", "invalid signature timestamp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signedAt, err := signatureTimestamp(tt.timestamp, now)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.timestamp, strconv.FormatInt(signedAt.Unix(), 10))
+		})
+	}
+}
+
+func TestRequestSignature(t *testing.T) {
+	const secret = "whsec_test"
+	want := requestSignature(secret, "1750000000", "POST", "/api/v1/payroll?dry_run=true", []byte(`{"amount":100}`))
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		method    string
+		uri       string
+		body      string
+	}{
+		{"other secret", "whsec_other", "1750000000", "POST", "/api/v1/payroll?dry_run=true", `{"amount":100}`},
+		{"other timestamp", secret, "1750000001", "POST", "/api/v1/payroll?dry_run=true", `{"amount":100}`},
+		{"other method", secret, "1750000000", "PUT", "/api/v1/payroll?dry_run=true", `{"amount":100}`},
+		{"other query", secret, "1750000000", "POST", "/api/v1/payroll", `{"amount":100}`},
+		{"other body", secret, "1750000000", "POST", "/api/v1/payroll?dry_run=true", `{"amount":1000}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := requestSignature(tt.secret, tt.timestamp, tt.method, tt.uri, []byte(tt.body))
+			assert.NotEqual(t, hex.EncodeToString(want), hex.EncodeToString(got))
+		})
+	}
+	assert.Equal(t, want, requestSignature(secret, "1750000000", "POST", "/api/v1/payroll?dry_run=true", []byte(`{"amount":100}`)))
+}
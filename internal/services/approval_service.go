@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"time"
+	"log"
 
 	"github.com/Gerard-007/ajor_app/internal/models"
 	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/events"
+	"github.com/Gerard-007/ajor_app/pkg/messages"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -38,62 +40,200 @@ func ApprovePayout(ctx context.Context, db *mongo.Database, approvalID, approver
 		status = models.ApprovalRejected
 	}
 
-	if err := repository.UpdateApproval(ctx, db, approvalID, status); err != nil {
+	var transaction models.Transaction
+	err = db.Collection("transactions").FindOne(ctx, bson.M{"_id": approval.TransactionID}).Decode(&transaction)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.New("transaction not found")
+		}
 		return err
 	}
 
-	if approve {
-		var transaction models.Transaction
-		err := db.Collection("transactions").FindOne(ctx, bson.M{"_id": approval.TransactionID}).Decode(&transaction)
-		if err != nil {
-			if err == mongo.ErrNoDocuments {
-				return errors.New("transaction not found")
-			}
+	// The recipient must still be within their KYC limits when the payout is made
+	if approve && transaction.Type == models.TransactionPayout {
+		if err := checkPayoutRecipientLimit(ctx, db, &transaction); err != nil {
 			return err
 		}
+	}
 
-		// Update wallet balances
-		if err := repository.UpdateWalletBalance(db, transaction.FromWallet, transaction.Amount, false); err != nil {
-			return err
-		}
-		if err := repository.UpdateWalletBalance(db, transaction.ToWallet, transaction.Amount, true); err != nil {
-			// Rollback
-			repository.UpdateWalletBalance(db, transaction.FromWallet, transaction.Amount, true)
-			return err
+	// Loans and guarantee claims are decided by a majority of the group rather than a single approver
+	payout := approve && transaction.Type != models.TransactionLoan && transaction.Type != models.TransactionGuaranteeClaim
+	var recipientWallet *models.Wallet
+	if payout {
+		recipientWallet, err = repository.GetWalletByID(db, transaction.ToWallet)
+		if err != nil {
+			return errors.New("recipient wallet not found")
 		}
+	}
 
-		// Update transaction status
-		_, err = db.Collection("transactions").UpdateOne(ctx, bson.M{"_id": transaction.ID}, bson.M{
-			"$set": bson.M{
-				"status":     models.StatusSuccess,
-				"updated_at": time.Now(),
-			},
-		})
-		if err != nil {
-			// Rollback
-			repository.UpdateWalletBalance(db, transaction.FromWallet, transaction.Amount, true)
-			repository.UpdateWalletBalance(db, transaction.ToWallet, transaction.Amount, false)
+	// The decision, the payout and telling everyone about them are
+	// committed together, so an approved payout is never left half paid.
+	// Recording the decision fails if another request decided first, and
+	// so does marking the payout paid, so it is never made twice.
+	err = inTransaction(ctx, db, func(ctx context.Context) error {
+		if err := repository.UpdateApproval(ctx, db, approvalID, status); err != nil {
 			return err
 		}
-
-		// Mark member as collected
-		if err := repository.MarkMemberCollected(ctx, db, approval.ContributionID, transaction.ToWallet); err != nil {
+		if err := queueEvent(ctx, db, events.ApprovalDecided, []primitive.ObjectID{approval.ApproverID}, approval.ContributionID, map[string]interface{}{
+			"approval_id":    approval.ID.Hex(),
+			"transaction_id": approval.TransactionID.Hex(),
+			"approver_id":    approval.ApproverID.Hex(),
+			"status":         status,
+		}); err != nil {
 			return err
 		}
+		if !payout {
+			return nil
+		}
+		return payOut(ctx, db, &approval, &transaction, recipientWallet)
+	})
+	if err != nil {
+		return err
+	}
 
-		// Notify user
-		notification := &models.Notification{
-			UserID:         transaction.ToWallet,
-			ContributionID: approval.ContributionID,
-			Message:        fmt.Sprintf("Payout of %.2f approved for contribution", transaction.Amount),
-			Type:           models.NotificationInfo,
+	switch transaction.Type {
+	case models.TransactionLoan:
+		return decideLoan(ctx, db, &transaction)
+	case models.TransactionGuaranteeClaim:
+		return decideGuaranteeClaim(ctx, db, &transaction)
+	}
+	if !payout {
+		return nil
+	}
+
+	if err := recordRotation(ctx, db, recipientWallet.OwnerID); err != nil {
+		log.Printf("Failed to update reliability score for user %s: %v", recipientWallet.OwnerID.Hex(), err)
+	}
+
+	// Recover any outstanding loan from the payout
+	if err := deductLoansFromPayout(ctx, db, approval.ContributionID, recipientWallet, transaction.Amount); err != nil {
+		log.Printf("Failed to deduct loans from payout %s: %v", transaction.ID.Hex(), err)
+	}
+	return nil
+}
+
+// payOut moves an approved payout to the recipient and marks them as
+// having collected, queueing the events and the recipient's notification.
+// It is run within a transaction.
+func payOut(ctx context.Context, db *mongo.Database, approval *models.Approval, transaction *models.Transaction, recipientWallet *models.Wallet) error {
+	if err := repository.SettleTransaction(ctx, db, transaction.ID, models.StatusSuccess); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.New("payout already processed")
 		}
-		return repository.CreateNotification(ctx, db, notification)
+		return err
+	}
+	if err := repository.DebitWallet(ctx, db, transaction.FromWallet, transaction.Amount); err != nil {
+		return err
+	}
+	if err := repository.UpdateWalletBalanceContext(ctx, db, transaction.ToWallet, transaction.Amount, true); err != nil {
+		return err
+	}
+	transaction.Status = models.StatusSuccess
+	if err := queueTransactionEvents(ctx, db, transaction); err != nil {
+		return err
 	}
 
+	if err := repository.MarkMemberCollected(ctx, db, approval.ContributionID, recipientWallet.OwnerID); err != nil {
+		return err
+	}
+	if err := queueRoundClosed(ctx, db, approval.ContributionID); err != nil {
+		return err
+	}
+
+	return queueNotification(ctx, db, &models.Notification{
+		UserID:         recipientWallet.OwnerID,
+		ContributionID: approval.ContributionID,
+		Template:       messages.PayoutApproved,
+		Params:         messages.Params{"Amount": transaction.Amount},
+		Type:           models.NotificationInfo,
+		Category:       models.CategoryPayouts,
+	})
+}
+
+// checkPayoutRecipientLimit checks the payout fits the recipient's KYC
+// limits.
+func checkPayoutRecipientLimit(ctx context.Context, db *mongo.Database, transaction *models.Transaction) error {
+	wallet, err := repository.GetWalletByID(db, transaction.ToWallet)
+	if err != nil {
+		return errors.New("recipient wallet not found")
+	}
+	user, err := repository.GetUserByID(db.Collection("users"), wallet.OwnerID)
+	if err != nil {
+		return errors.New("recipient not found")
+	}
+	if err := checkInflowLimit(ctx, db, user, wallet, transaction.Amount); err != nil {
+		return fmt.Errorf("recipient's %v", err)
+	}
 	return nil
 }
 
 func GetPendingApprovals(ctx context.Context, db *mongo.Database, approverID primitive.ObjectID) ([]*models.Approval, error) {
 	return repository.GetPendingApprovals(ctx, db, approverID)
-}
\ No newline at end of file
+}
+
+// requestGroupApprovals asks the group admin and every member except the
+// excluded users to approve a transaction, and returns who was asked.
+func requestGroupApprovals(ctx context.Context, db *mongo.Database, contribution *models.Contribution, transactionID primitive.ObjectID, exclude ...primitive.ObjectID) ([]primitive.ObjectID, error) {
+	candidates := append([]primitive.ObjectID{contribution.GroupAdmin}, contribution.YetToCollectMembers...)
+	candidates = append(candidates, contribution.AlreadyCollectedMembers...)
+
+	var approvers []primitive.ObjectID
+	for _, candidate := range candidates {
+		if containsUser(exclude, candidate) || containsUser(approvers, candidate) {
+			continue
+		}
+		approvers = append(approvers, candidate)
+	}
+	if len(approvers) == 0 {
+		return nil, errors.New("no group members available to approve")
+	}
+
+	for _, approverID := range approvers {
+		approval := &models.Approval{
+			TransactionID:  transactionID,
+			ApproverID:     approverID,
+			Status:         models.ApprovalPending,
+			ContributionID: contribution.ID,
+		}
+		if err := repository.CreateApproval(ctx, db, approval); err != nil {
+			return nil, err
+		}
+	}
+	publishEvent(events.ApprovalRequested, approvers, contribution.ID, map[string]interface{}{
+		"transaction_id": transactionID.Hex(),
+	})
+	return approvers, nil
+}
+
+// approvalOutcome reports whether a majority of approvers has decided a
+// transaction, and which way.
+func approvalOutcome(ctx context.Context, db *mongo.Database, transactionID primitive.ObjectID) (decided bool, approved bool, err error) {
+	approvals, err := repository.GetApprovalsByTransaction(ctx, db, transactionID)
+	if err != nil {
+		return false, false, err
+	}
+	decided, approved = majorityDecision(approvals)
+	return decided, approved, nil
+}
+
+// majorityDecision decides a transaction once more than half of the
+// approvers approve it, or at least half reject it, so a tie is a rejection.
+func majorityDecision(approvals []*models.Approval) (decided bool, approved bool) {
+	var yes, no int
+	for _, approval := range approvals {
+		switch approval.Status {
+		case models.ApprovalApproved:
+			yes++
+		case models.ApprovalRejected:
+			no++
+		}
+	}
+	total := len(approvals)
+	if yes*2 > total {
+		return true, true
+	}
+	if no*2 >= total {
+		return true, false
+	}
+	return false, false
+}
@@ -0,0 +1,45 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMajorityDecision(t *testing.T) {
+	const (
+		yes     = models.ApprovalApproved
+		no      = models.ApprovalRejected
+		pending = models.ApprovalPending
+	)
+
+	tests := []struct {
+		name         string
+		statuses     []models.ApprovalStatus
+		wantDecided  bool
+		wantApproved bool
+	}{
+		{"sole approver approves", []models.ApprovalStatus{yes}, true, true},
+		{"sole approver rejects", []models.ApprovalStatus{no}, true, false},
+		{"sole approver undecided", []models.ApprovalStatus{pending}, false, false},
+		{"two of three approve", []models.ApprovalStatus{yes, yes, pending}, true, true},
+		{"one of three approves", []models.ApprovalStatus{yes, pending, pending}, false, false},
+		{"two of three reject", []models.ApprovalStatus{no, no, pending}, true, false},
+		{"half of four approve", []models.ApprovalStatus{yes, yes, pending, pending}, false, false},
+		{"half of four reject", []models.ApprovalStatus{no, no, pending, pending}, true, false},
+		{"tie is a rejection", []models.ApprovalStatus{yes, yes, no, no}, true, false},
+		{"three of four approve", []models.ApprovalStatus{yes, yes, yes, no}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			approvals := make([]*models.Approval, len(tt.statuses))
+			for i, status := range tt.statuses {
+				approvals[i] = &models.Approval{Status: status}
+			}
+			decided, approved := majorityDecision(approvals)
+			assert.Equal(t, tt.wantDecided, decided)
+			assert.Equal(t, tt.wantApproved, approved)
+		})
+	}
+}
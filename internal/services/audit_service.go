@@ -0,0 +1,18 @@
+package services
+
+import (
+	"context"
+	"log"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// recordAuditEvent stores an audit event. Failing to record one must not
+// fail the request that caused it, so errors are only logged.
+func recordAuditEvent(ctx context.Context, db *mongo.Database, event *models.AuditEvent) {
+	if err := repository.CreateAuditEvent(ctx, db, event); err != nil {
+		log.Printf("Failed to record %s audit event: %v", event.Type, err)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/Gerard-007/ajor_app/internal/models"
 	"github.com/Gerard-007/ajor_app/internal/repository"
 	"github.com/Gerard-007/ajor_app/pkg/payment"
+	"github.com/Gerard-007/ajor_app/pkg/sms"
 	"github.com/Gerard-007/ajor_app/pkg/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -19,7 +20,7 @@ import (
 )
 
 
-func RegisterUser(db *mongo.Database, user *models.User, pg payment.PaymentGateway) (string, error) {
+func RegisterUser(db *mongo.Database, user *models.User, pg payment.PaymentGateway, provider sms.Provider, deviceName, ip string) (*models.AuthTokens, error) {
 	usersCollection := db.Collection("users")
 
 	// Generate username from email if not provided
@@ -27,35 +28,39 @@ func RegisterUser(db *mongo.Database, user *models.User, pg payment.PaymentGatew
 		generatedUsername, err := utils.GenerateUsernameFromEmail(db, user.Email)
 		if err != nil {
 			log.Printf("Failed to generate username: %v", err)
-			return "", fmt.Errorf("failed to generate username: %v", err)
+			return nil, fmt.Errorf("failed to generate username: %v", err)
 		}
 		user.Username = generatedUsername
 	}
 
 	// Validate input
 	if user.Username == "" {
-		return "", errors.New("username is required")
+		return nil, errors.New("username is required")
 	}
 	if user.Email == "" {
-		return "", errors.New("email is required")
+		return nil, errors.New("email is required")
 	}
 	if user.Password == "" {
-		return "", errors.New("password is required")
+		return nil, errors.New("password is required")
 	}
-	if user.Phone == "" || len(user.Phone) < 11 {
-		return "", errors.New("phone is required and must be at least 11 digits")
+	if err := validatePassword(user.Password); err != nil {
+		return nil, err
 	}
-	// Check if phone contains only digits
-	if _, err := strconv.Atoi(user.Phone); err != nil {
-		return "", errors.New("phone must contain only digits")
+	if user.Phone == "" {
+		return nil, errors.New("phone is required")
 	}
+	phone, err := sms.NormalizePhone(user.Phone)
+	if err != nil {
+		return nil, err
+	}
+	user.Phone = phone
 
 	if user.BVN == "" || len(user.BVN) != 11 {
-		return "", errors.New("BVN is required and must be 11 digits")
+		return nil, errors.New("BVN is required and must be 11 digits")
 	}
 	// Check if BVN contains only digits
 	if _, err := strconv.Atoi(user.BVN); err != nil {
-		return "", errors.New("BVN must contain only digits")
+		return nil, errors.New("BVN must contain only digits")
 	}
 
 	// Check if email, username, or phone exists
@@ -63,52 +68,71 @@ func RegisterUser(db *mongo.Database, user *models.User, pg payment.PaymentGatew
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	err := usersCollection.FindOne(ctx, bson.M{"email": user.Email}).Decode(&existingUser)
+	err = usersCollection.FindOne(ctx, bson.M{"email": user.Email}).Decode(&existingUser)
 	if err == nil {
 		log.Printf("Email already registered: %s", user.Email)
-		return "", errors.New("email already exists")
+		return nil, errors.New("email already exists")
 	}
 	if err != mongo.ErrNoDocuments {
 		log.Printf("Error checking email existence: %v", err)
-		return "", err
+		return nil, err
 	}
 
 	err = usersCollection.FindOne(ctx, bson.M{"username": user.Username}).Decode(&existingUser)
 	if err == nil {
 		log.Printf("Username already taken: %s", user.Username)
-		return "", errors.New("username already exists")
+		return nil, errors.New("username already exists")
 	}
 	if err != mongo.ErrNoDocuments {
 		log.Printf("Error checking username existence: %v", err)
-		return "", err
+		return nil, err
 	}
 
-	err = usersCollection.FindOne(ctx, bson.M{"phone": user.Phone}).Decode(&existingUser)
-	if err == nil {
-		log.Printf("Phone already registered: %s", user.Phone)
-		return "", errors.New("phone already exists")
-	}
-	if err != mongo.ErrNoDocuments {
+	// Phone numbers and BVNs are encrypted, so they are matched on their blind indexes
+	exists, err := repository.UserExistsWithPhone(ctx, db, user.Phone, nil)
+	if err != nil {
 		log.Printf("Error checking phone existence: %v", err)
-		return "", err
+		return nil, err
+	}
+	if exists {
+		log.Printf("Phone already registered for email: %s", user.Email)
+		return nil, errors.New("phone already exists")
+	}
+
+	exists, err = repository.UserExistsWithBVN(ctx, db, user.BVN, nil)
+	if err != nil {
+		log.Printf("Error checking BVN existence: %v", err)
+		return nil, err
+	}
+	if exists {
+		log.Printf("BVN already registered for email: %s", user.Email)
+		return nil, errors.New("BVN already exists")
 	}
 
 	// Hash password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
 	if err != nil {
 		log.Printf("Error hashing password: %v", err)
-		return "", err
+		return nil, err
 	}
 	user.Password = string(hashedPassword)
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
-	user.IsAdmin = false // Enforce false for security
+	user.IsAdmin = false  // Enforce false for security
+	user.Roles = nil      // Roles are only granted by a super admin
+	user.Verified = false // Set only by phone verification
 
-	// Create user
-	userResult, err := usersCollection.InsertOne(ctx, user)
+	// Create user. The phone number and BVN are only encrypted in the stored
+	// copy; the payment gateway still needs them in plaintext below.
+	sealed, err := repository.SealUserPII(user)
+	if err != nil {
+		log.Printf("Error encrypting user details: %v", err)
+		return nil, err
+	}
+	userResult, err := usersCollection.InsertOne(ctx, sealed)
 	if err != nil {
 		log.Printf("Error creating user: %v", err)
-		return "", err
+		return nil, err
 	}
 	user.ID = userResult.InsertedID.(primitive.ObjectID)
 
@@ -130,7 +154,7 @@ func RegisterUser(db *mongo.Database, user *models.User, pg payment.PaymentGatew
 		if delErr != nil {
 			log.Printf("Failed to clean up user after profile creation failure: %v", delErr)
 		}
-		return "", err
+		return nil, err
 	}
 
 	// Create wallet
@@ -151,7 +175,7 @@ func RegisterUser(db *mongo.Database, user *models.User, pg payment.PaymentGatew
 			log.Printf("Failed to clean up user after wallet creation failure: %v", delErr)
 		}
 		db.Collection("profiles").DeleteOne(ctx, bson.M{"user_id": user.ID})
-		return "", err
+		return nil, err
 	}
 	log.Printf("Created wallet with ID: %s for user: %s", wallet.ID.Hex(), user.Email)
 
@@ -163,7 +187,7 @@ func RegisterUser(db *mongo.Database, user *models.User, pg payment.PaymentGatew
 		usersCollection.DeleteOne(ctx, bson.M{"_id": user.ID})
 		db.Collection("profiles").DeleteOne(ctx, bson.M{"user_id": user.ID})
 		db.Collection("wallets").DeleteOne(ctx, bson.M{"_id": wallet.ID})
-		return "", fmt.Errorf("failed to create virtual account: %v", err)
+		return nil, fmt.Errorf("failed to create virtual account: %v", err)
 	}
 
 	// Update wallet with virtual account details
@@ -172,45 +196,72 @@ func RegisterUser(db *mongo.Database, user *models.User, pg payment.PaymentGatew
 		usersCollection.DeleteOne(ctx, bson.M{"_id": user.ID})
 		db.Collection("profiles").DeleteOne(ctx, bson.M{"user_id": user.ID})
 		db.Collection("wallets").DeleteOne(ctx, bson.M{"_id": wallet.ID})
-		return "", fmt.Errorf("failed to update wallet with virtual account: %v", err)
+		return nil, fmt.Errorf("failed to update wallet with virtual account: %v", err)
 	}
 
-	// Generate JWT token for immediate login
-	token, err := utils.GenerateToken(user.Username, user.Email, user.ID, user.IsAdmin)
+	// Start a session for immediate login
+	tokens, err := startSession(ctx, db, user, deviceName, ip)
 	if err != nil {
-		log.Printf("Error generating JWT for user %s: %v", user.Email, err)
+		log.Printf("Error starting session for user %s: %v", user.Email, err)
 		// Clean up user, profile, and wallet
 		usersCollection.DeleteOne(ctx, bson.M{"_id": user.ID})
 		db.Collection("profiles").DeleteOne(ctx, bson.M{"user_id": user.ID})
 		db.Collection("wallets").DeleteOne(ctx, bson.M{"_id": wallet.ID})
-		return "", fmt.Errorf("failed to generate token: %v", err)
+		return nil, fmt.Errorf("failed to start session: %v", err)
+	}
+
+	// Send the phone verification code; the user can ask for another if this fails
+	if err := SendPhoneVerification(ctx, db, provider, user.ID); err != nil {
+		log.Printf("Failed to send verification code to user %s: %v", user.Email, err)
 	}
 
 	log.Printf("User registered and logged in successfully: %s", user.Email)
-	return token, nil
+	return tokens, nil
 }
 
-func LoginUser(db *mongo.Collection, email, password string) (string, error) {
+// LoginUser checks the password. Users with two-factor authentication get
+// an MFA challenge to complete instead of tokens. Unknown emails and wrong
+// passwords fail the same way, and repeated failures lock the email out.
+func LoginUser(db *mongo.Database, email, password, deviceName, ip string) (*models.LoginResult, error) {
+	ctx := context.Background()
+	if err := checkLoginLockout(ctx, db, email); err != nil {
+		return nil, err
+	}
+
 	// Find the user by email
-	user, err := repository.GetUserByEmail(db, email)
+	user, err := repository.GetUserByEmail(db.Collection("users"), email)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return "", errors.New("user not found")
+			compareDummyPassword(password)
+			recordLoginFailure(ctx, db, email, ip, nil)
+			return nil, errors.New("invalid credentials")
 		}
-		return "", err
+		return nil, err
 	}
 
 	// Compare the provided password with the stored hashed password
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
 	if err != nil {
-		return "", errors.New("invalid credentials")
+		recordLoginFailure(ctx, db, email, ip, user)
+		return nil, errors.New("invalid credentials")
+	}
+	clearLoginFailures(ctx, db, email)
+	if user.Closure != nil {
+		return nil, errors.New("account is being closed")
 	}
 
-	// Generate JWT token
-	token, err := utils.GenerateToken(user.Username, user.Email, user.ID, user.IsAdmin)
+	mfaEnabled, err := MFAEnabled(ctx, db, user.ID)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if mfaEnabled {
+		return startMFAChallenge(ctx, db, user, deviceName, ip)
 	}
 
-	return token, nil
-}
+	// Start a new session for this device
+	tokens, err := startSession(ctx, db, user, deviceName, ip)
+	if err != nil {
+		return nil, err
+	}
+	return &models.LoginResult{Tokens: tokens}, nil
+}
\ No newline at end of file
@@ -3,11 +3,11 @@ package services
 import (
 	"context"
 	"errors"
-	"fmt"
 	"time"
 
 	"github.com/Gerard-007/ajor_app/internal/models"
 	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/messages"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -28,7 +28,10 @@ func CreateCollection(ctx context.Context, db *mongo.Database, contributionID, c
 
 	var finalCollectionDate time.Time
 	if collectionDate == nil {
-		finalCollectionDate = computeCollectionDate(contribution.Cycle, contribution.CreatedAt)
+		finalCollectionDate, err = computeCollectionDate(ctx, db, contribution, time.Now())
+		if err != nil {
+			return err
+		}
 	} else {
 		finalCollectionDate = *collectionDate
 	}
@@ -47,10 +50,12 @@ func CreateCollection(ctx context.Context, db *mongo.Database, contributionID, c
 	notification := &models.Notification{
 		UserID:         collectorID,
 		ContributionID: contributionID,
-		Message:        fmt.Sprintf("You are scheduled to collect for group: %s on %s", contribution.Name, finalCollectionDate.Format("2006-01-02")),
+		Template:       messages.CollectionScheduled,
+		Params:         messages.Params{"Group": contribution.Name, "Date": finalCollectionDate},
 		Type:           models.NotificationInfo,
+		Category:       models.CategoryPayouts,
 	}
-	return repository.CreateNotification(ctx, db, notification)
+	return Notify(ctx, db, notification)
 }
 
 func GetCollections(ctx context.Context, db *mongo.Database, contributionID, userID primitive.ObjectID) ([]*models.Collection, error) {
@@ -68,23 +73,15 @@ func GetCollections(ctx context.Context, db *mongo.Database, contributionID, use
 	return repository.GetCollectionsByContribution(ctx, db, contributionID)
 }
 
-func computeCollectionDate(cycle models.ContributionCycle, baseDate time.Time) time.Time {
-	switch cycle {
-	case models.CycleDaily:
-		return baseDate.Truncate(24 * time.Hour).Add(23*time.Hour + 59*time.Minute + 59*time.Second)
-	case models.CycleWeekly:
-		daysUntilSunday := (7 - int(baseDate.Weekday())) % 7
-		if daysUntilSunday == 0 {
-			daysUntilSunday = 7
-		}
-		return baseDate.Add(time.Duration(daysUntilSunday) * 24 * time.Hour).Truncate(24 * time.Hour).Add(23*time.Hour + 59*time.Minute + 59*time.Second)
-	case models.CycleMonthly:
-		nextMonth := baseDate.AddDate(0, 1, 0)
-		lastDay := nextMonth.AddDate(0, 0, -nextMonth.Day())
-		return lastDay.Truncate(24 * time.Hour).Add(23*time.Hour + 59*time.Minute + 59*time.Second)
-	case models.CycleYearly:
-		return time.Date(baseDate.Year(), 12, 31, 23, 59, 59, 0, baseDate.Location())
-	default:
-		return time.Now()
+// computeCollectionDate picks the contribution's current deadline, or the
+// next one if it has already passed.
+func computeCollectionDate(ctx context.Context, db *mongo.Database, contribution *models.Contribution, now time.Time) (time.Time, error) {
+	if contribution.CollectionDeadline.After(now) {
+		return contribution.CollectionDeadline, nil
 	}
-}
\ No newline at end of file
+	recurrence, err := contributionRecurrence(ctx, db, contribution)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return recurrence.Next(now), nil
+}
@@ -8,8 +8,10 @@ import (
 
 	"github.com/Gerard-007/ajor_app/internal/models"
 	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/events"
+	"github.com/Gerard-007/ajor_app/pkg/messages"
 	"github.com/Gerard-007/ajor_app/pkg/payment"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/Gerard-007/ajor_app/pkg/schedule"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -18,40 +20,32 @@ func CreateContribution(ctx context.Context, db *mongo.Database, pg payment.Paym
 	if contribution.Name == "" || contribution.Cycle == "" || contribution.Type == "" {
 		return errors.New("name, cycle, and type are required")
 	}
-	if contribution.Amount <= 0 {
-		return errors.New("amount must be positive")
-	}
-	if contribution.PenaltyAmount < 0 {
-		return errors.New("penalty amount cannot be negative")
+	if err := validateContributionSettings(contribution); err != nil {
+		return err
 	}
 	//if contribution.CycleCount <= 0 {
 	//	return errors.New("cycle count must be positive")
 	//}
-	if !isValidCycle(contribution.Cycle) || !isValidType(contribution.Type) {
-		return errors.New("invalid cycle or type")
+	if !isValidType(contribution.Type) {
+		return errors.New("invalid contribution type")
+	}
+	if contribution.CycleStartDate.IsZero() {
+		contribution.CycleStartDate = time.Now()
+	}
+	if contribution.Timezone == "" {
+		contribution.Timezone = schedule.DefaultTimezone
+	}
+	recurrence, err := contributionRecurrence(ctx, db, contribution)
+	if err != nil {
+		return err
+	}
+	if err := recurrence.Validate(); err != nil {
+		return err
 	}
 
 	// Set collection day and deadline
-	switch contribution.Cycle {
-	case models.CycleDaily:
-		contribution.CollectionDay = "end of day"
-		contribution.CollectionDeadline = time.Now().Truncate(24 * time.Hour).Add(23*time.Hour + 59*time.Minute + 59*time.Second)
-	case models.CycleWeekly:
-		contribution.CollectionDay = "end of week"
-		daysUntilSunday := (7 - int(time.Now().Weekday())) % 7
-		if daysUntilSunday == 0 {
-			daysUntilSunday = 7
-		}
-		contribution.CollectionDeadline = time.Now().Add(time.Duration(daysUntilSunday) * 24 * time.Hour).Truncate(24 * time.Hour).Add(23*time.Hour + 59*time.Minute + 59*time.Second)
-	case models.CycleMonthly:
-		contribution.CollectionDay = "last day of month"
-		nextMonth := time.Now().AddDate(0, 1, 0)
-		lastDay := nextMonth.AddDate(0, 0, -nextMonth.Day())
-		contribution.CollectionDeadline = lastDay.Truncate(24 * time.Hour).Add(23*time.Hour + 59*time.Minute + 59*time.Second)
-	case models.CycleYearly:
-		contribution.CollectionDay = "last day of year"
-		contribution.CollectionDeadline = time.Date(time.Now().Year(), 12, 31, 23, 59, 59, 0, time.Now().Location())
-	}
+	contribution.CollectionDay = recurrence.Label()
+	contribution.CollectionDeadline = recurrence.Next(time.Now())
 
 	// Create wallet
 	wallet := &models.Wallet{
@@ -65,8 +59,7 @@ func CreateContribution(ctx context.Context, db *mongo.Database, pg payment.Paym
 	}
 
 	// Create virtual account
-	var user models.User
-	err := db.Collection("users").FindOne(ctx, bson.M{"_id": groupAdminID}).Decode(&user)
+	user, err := repository.GetUserByID(db.Collection("users"), groupAdminID)
 	if err != nil {
 		repository.DeleteWallet(db, wallet.ID)
 		return errors.New("group admin not found")
@@ -113,18 +106,142 @@ func GetUserContributions(ctx context.Context, db *mongo.Database, userID primit
 	return repository.GetContributionsByUser(ctx, db, userID)
 }
 
-func UpdateContribution(ctx context.Context, db *mongo.Database, id, userID primitive.ObjectID, contribution *models.Contribution) error {
-	existing, err := repository.GetContributionByID(ctx, db, id)
+// UpdateContribution applies the supplied settings to the contribution and
+// keeps the rest. The collection deadline is recomputed when the schedule
+// changes.
+func UpdateContribution(ctx context.Context, db *mongo.Database, id, userID primitive.ObjectID, update *models.ContributionUpdate) error {
+	contribution, err := repository.GetContributionByID(ctx, db, id)
 	if err != nil {
 		return err
 	}
-	if existing.GroupAdmin != userID {
+	if contribution.GroupAdmin != userID {
 		return errors.New("only group admin can update contribution")
 	}
 
+	scheduleChanged := applyContributionUpdate(contribution, update)
+	if contribution.Name == "" {
+		return errors.New("name cannot be empty")
+	}
+	if err := validateContributionSettings(contribution); err != nil {
+		return err
+	}
+	if !isValidType(contribution.Type) {
+		return errors.New("invalid contribution type")
+	}
+
+	if scheduleChanged {
+		recurrence, err := contributionRecurrence(ctx, db, contribution)
+		if err != nil {
+			return err
+		}
+		if err := recurrence.Validate(); err != nil {
+			return err
+		}
+		contribution.CollectionDay = recurrence.Label()
+		contribution.CollectionDeadline = recurrence.Next(time.Now())
+	}
+
 	return repository.UpdateContribution(ctx, db, id, contribution)
 }
 
+// validateContributionSettings checks the amounts and limits a
+// contribution is created or updated with. A loan limit of 0 leaves loans
+// turned off.
+func validateContributionSettings(contribution *models.Contribution) error {
+	if contribution.Amount <= 0 {
+		return errors.New("amount must be positive")
+	}
+	if contribution.PenaltyAmount < 0 {
+		return errors.New("penalty amount cannot be negative")
+	}
+	if contribution.GuarantorsRequired < 0 || contribution.GuarantorClaimAfter < 0 {
+		return errors.New("guarantor settings cannot be negative")
+	}
+	if contribution.MinReliabilityScore < 0 || contribution.MinReliabilityScore > 100 {
+		return errors.New("minimum reliability score must be between 0 and 100")
+	}
+	if contribution.LoanInterestRate < 0 {
+		return errors.New("loan interest rate cannot be negative")
+	}
+	if contribution.LoanLimitPercent < 0 || contribution.LoanLimitPercent > 100 {
+		return errors.New("loan limit percent must be between 0 and 100")
+	}
+	return nil
+}
+
+// applyContributionUpdate copies the supplied fields of update onto
+// contribution and reports whether any of them affect the schedule.
+func applyContributionUpdate(contribution *models.Contribution, update *models.ContributionUpdate) bool {
+	if update.Name != nil {
+		contribution.Name = *update.Name
+	}
+	if update.Description != nil {
+		contribution.Description = *update.Description
+	}
+	if update.Amount != nil {
+		contribution.Amount = *update.Amount
+	}
+	if update.CycleCount != nil {
+		contribution.CycleCount = *update.CycleCount
+	}
+	if update.Type != nil {
+		contribution.Type = *update.Type
+	}
+	if update.PenaltyAmount != nil {
+		contribution.PenaltyAmount = *update.PenaltyAmount
+	}
+	if update.LoanInterestRate != nil {
+		contribution.LoanInterestRate = *update.LoanInterestRate
+	}
+	if update.LoanLimitPercent != nil {
+		contribution.LoanLimitPercent = *update.LoanLimitPercent
+	}
+	if update.MinReliabilityScore != nil {
+		contribution.MinReliabilityScore = *update.MinReliabilityScore
+	}
+	if update.GuarantorsRequired != nil {
+		contribution.GuarantorsRequired = *update.GuarantorsRequired
+	}
+	if update.GuarantorClaimAfter != nil {
+		contribution.GuarantorClaimAfter = *update.GuarantorClaimAfter
+	}
+
+	scheduleChanged := false
+	if update.Cycle != nil {
+		contribution.Cycle = *update.Cycle
+		scheduleChanged = true
+	}
+	if update.CycleInterval != nil {
+		contribution.CycleInterval = *update.CycleInterval
+		scheduleChanged = true
+	}
+	if update.CycleUnit != nil {
+		contribution.CycleUnit = *update.CycleUnit
+		scheduleChanged = true
+	}
+	if update.CycleMonthDay != nil {
+		contribution.CycleMonthDay = *update.CycleMonthDay
+		scheduleChanged = true
+	}
+	if update.CycleWeekday != nil {
+		contribution.CycleWeekday = *update.CycleWeekday
+		scheduleChanged = true
+	}
+	if update.CycleStartDate != nil && !update.CycleStartDate.IsZero() {
+		contribution.CycleStartDate = *update.CycleStartDate
+		scheduleChanged = true
+	}
+	if update.Timezone != nil && *update.Timezone != "" {
+		contribution.Timezone = *update.Timezone
+		scheduleChanged = true
+	}
+	if update.RollHolidays != nil {
+		contribution.RollHolidays = *update.RollHolidays
+		scheduleChanged = true
+	}
+	return scheduleChanged
+}
+
 func FindContributionByInviteCode(ctx context.Context, db *mongo.Database, inviteCode string) (*models.Contribution, error) {
 	if inviteCode == "" {
 		return nil, errors.New("invite code is required")
@@ -138,32 +255,65 @@ func FindContributionByInviteCode(ctx context.Context, db *mongo.Database, invit
 	return contribution, nil
 }
 
-func JoinContribution(ctx context.Context, db *mongo.Database, contributionID, userID primitive.ObjectID, inviteCode string) error {
+// JoinContribution adds a member with the right invite code. When the
+// contribution requires guarantors the member is only admitted once enough
+// of them accept, and pending is true until then.
+func JoinContribution(ctx context.Context, db *mongo.Database, contributionID, userID primitive.ObjectID, inviteCode string, guarantorIDs []primitive.ObjectID) (bool, error) {
 	contribution, err := repository.GetContributionByID(ctx, db, contributionID)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	if contribution.InviteCode != inviteCode {
-		return errors.New("invalid invite code")
+		return false, errors.New("invalid invite code")
 	}
 
 	if containsUser(contribution.YetToCollectMembers, userID) || containsUser(contribution.AlreadyCollectedMembers, userID) {
-		return errors.New("user already in contribution")
+		return false, errors.New("user already in contribution")
 	}
 
-	err = repository.JoinContribution(ctx, db, contributionID, userID)
-	if err != nil {
+	if contribution.MinReliabilityScore > 0 {
+		score, err := GetReliabilityScore(ctx, db, userID)
+		if err != nil {
+			return false, err
+		}
+		if score.Score < contribution.MinReliabilityScore {
+			return false, fmt.Errorf("reliability score %.1f is below the %.1f required to join", score.Score, contribution.MinReliabilityScore)
+		}
+	}
+
+	if contribution.GuarantorsRequired > 0 {
+		if err := requestGuarantees(ctx, db, contribution, userID, guarantorIDs); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	return false, admitMember(ctx, db, contribution, userID)
+}
+
+// admitMember adds the user to the contribution, starts any guarantees
+// given for them and lets the group admin know.
+func admitMember(ctx context.Context, db *mongo.Database, contribution *models.Contribution, userID primitive.ObjectID) error {
+	if err := repository.JoinContribution(ctx, db, contribution.ID, userID); err != nil {
 		return err
 	}
+	if contribution.GuarantorsRequired > 0 {
+		if err := repository.ActivateGuarantees(ctx, db, contribution.ID, userID, time.Now()); err != nil {
+			return err
+		}
+	}
+	publishEvent(events.MemberJoined, []primitive.ObjectID{userID}, contribution.ID, map[string]string{"user_id": userID.Hex()})
 
 	notification := &models.Notification{
 		UserID:         contribution.GroupAdmin,
-		ContributionID: contributionID,
-		Message:        "A new member has joined your contribution group: " + contribution.Name,
+		ContributionID: contribution.ID,
+		Template:       messages.MemberJoined,
+		Params:         messages.Params{"Group": contribution.Name},
 		Type:           models.NotificationInfo,
+		Category:       models.CategoryMembership,
 	}
-	return repository.CreateNotification(ctx, db, notification)
+	return Notify(ctx, db, notification)
 }
 
 func RemoveMember(ctx context.Context, db *mongo.Database, contributionID, userID, groupAdminID primitive.ObjectID) error {
@@ -179,18 +329,31 @@ func RemoveMember(ctx context.Context, db *mongo.Database, contributionID, userI
 	if err != nil {
 		return err
 	}
+	publishEvent(events.MemberRemoved, []primitive.ObjectID{userID}, contributionID, map[string]string{"user_id": userID.Hex()})
 
 	notification := &models.Notification{
 		UserID:         userID,
 		ContributionID: contributionID,
-		Message:        "You have been removed from the contribution group: " + contribution.Name,
+		Template:       messages.MemberRemoved,
+		Params:         messages.Params{"Group": contribution.Name},
 		Type:           models.NotificationWarning,
+		Category:       models.CategoryMembership,
 	}
-	return repository.CreateNotification(ctx, db, notification)
+	return Notify(ctx, db, notification)
 }
 
-func isValidCycle(cycle models.ContributionCycle) bool {
-	return cycle == models.CycleDaily || cycle == models.CycleWeekly || cycle == models.CycleMonthly || cycle == models.CycleYearly
+// contributionRecurrence builds the deadline rule for a contribution, loading
+// the holiday calendar only when the contribution rolls past holidays.
+func contributionRecurrence(ctx context.Context, db *mongo.Database, contribution *models.Contribution) (schedule.Recurrence, error) {
+	var calendar schedule.Calendar
+	if contribution.RollHolidays {
+		holidays, err := repository.GetHolidays(ctx, db)
+		if err != nil {
+			return schedule.Recurrence{}, fmt.Errorf("failed to load holidays: %w", err)
+		}
+		calendar = schedule.NewCalendar(holidays)
+	}
+	return schedule.FromContribution(contribution, calendar), nil
 }
 
 func isValidType(contributionType models.ContributionType) bool {
@@ -206,9 +369,9 @@ func containsUser(members []primitive.ObjectID, userID primitive.ObjectID) bool
 	return false
 }
 
-func GetAllContributions(db *mongo.Database, isSystemAdmin bool) ([]*models.Contribution, error) {
-	if !isSystemAdmin {
-		return nil, errors.New("only system admins can view all contributions")
+func GetAllContributions(db *mongo.Database, actorID primitive.ObjectID) ([]*models.Contribution, error) {
+	if err := RequirePermission(context.Background(), db, actorID, models.PermContributionsRead); err != nil {
+		return nil, err
 	}
 	return repository.GetAllContributions(db)
 }
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyContributionUpdate(t *testing.T) {
+	existing := func() *models.Contribution {
+		return &models.Contribution{
+			Name:                "Market women",
+			Cycle:               models.CycleWeekly,
+			Timezone:            "Africa/Lagos",
+			RollHolidays:        true,
+			Amount:              5000,
+			Type:                models.TypeGroupContribution,
+			LoanInterestRate:    2,
+			LoanLimitPercent:    50,
+			MinReliabilityScore: 60,
+			GuarantorsRequired:  2,
+			GuarantorClaimAfter: 3,
+		}
+	}
+	name := "Market traders"
+	amount := 7500.0
+	cycle := models.CycleDaily
+	rollHolidays := false
+	guarantors := 0
+
+	tests := []struct {
+		name            string
+		update          models.ContributionUpdate
+		want            func(c *models.Contribution)
+		scheduleChanged bool
+	}{
+		{
+			name:   "empty update changes nothing",
+			update: models.ContributionUpdate{},
+			want:   func(c *models.Contribution) {},
+		},
+		{
+			name:   "name and amount keep the other settings",
+			update: models.ContributionUpdate{Name: &name, Amount: &amount},
+			want: func(c *models.Contribution) {
+				c.Name = name
+				c.Amount = amount
+			},
+		},
+		{
+			name:   "zero values are applied when supplied",
+			update: models.ContributionUpdate{GuarantorsRequired: &guarantors},
+			want:   func(c *models.Contribution) { c.GuarantorsRequired = 0 },
+		},
+		{
+			name:            "cycle changes the schedule",
+			update:          models.ContributionUpdate{Cycle: &cycle},
+			want:            func(c *models.Contribution) { c.Cycle = cycle },
+			scheduleChanged: true,
+		},
+		{
+			name:            "turning off holiday rolling changes the schedule",
+			update:          models.ContributionUpdate{RollHolidays: &rollHolidays},
+			want:            func(c *models.Contribution) { c.RollHolidays = false },
+			scheduleChanged: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contribution := existing()
+			want := existing()
+			tt.want(want)
+			assert.Equal(t, tt.scheduleChanged, applyContributionUpdate(contribution, &tt.update))
+			assert.Equal(t, want, contribution)
+		})
+	}
+}
+
+func TestValidateContributionSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(c *models.Contribution)
+		wantErr string
+	}{
+		{"valid", func(c *models.Contribution) {}, ""},
+		{"loans turned off", func(c *models.Contribution) { c.LoanLimitPercent = 0 }, ""},
+		{"whole pool lendable", func(c *models.Contribution) { c.LoanLimitPercent = 100 }, ""},
+		{"interest free loans", func(c *models.Contribution) { c.LoanInterestRate = 0 }, ""},
+		{"zero amount", func(c *models.Contribution) { c.Amount = 0 }, "amount must be positive"},
+		{"negative penalty", func(c *models.Contribution) { c.PenaltyAmount = -1 }, "penalty amount cannot be negative"},
+		{"negative guarantors", func(c *models.Contribution) { c.GuarantorsRequired = -1 }, "guarantor settings cannot be negative"},
+		{"reliability score above 100", func(c *models.Contribution) { c.MinReliabilityScore = 101 }, "minimum reliability score must be between 0 and 100"},
+		{"negative interest rate", func(c *models.Contribution) { c.LoanInterestRate = -0.5 }, "loan interest rate cannot be negative"},
+		{"negative loan limit", func(c *models.Contribution) { c.LoanLimitPercent = -10 }, "loan limit percent must be between 0 and 100"},
+		{"loan limit above 100", func(c *models.Contribution) { c.LoanLimitPercent = 150 }, "loan limit percent must be between 0 and 100"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contribution := &models.Contribution{
+				Amount:              5000,
+				PenaltyAmount:       500,
+				LoanInterestRate:    2,
+				LoanLimitPercent:    50,
+				MinReliabilityScore: 60,
+				GuarantorsRequired:  2,
+			}
+			tt.change(contribution)
+			err := validateContributionSettings(contribution)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
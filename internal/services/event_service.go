@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/events"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// eventHistorySize is how many recent events the default broker keeps for
+// clients resuming a stream.
+const eventHistorySize = 1000
+
+var (
+	eventBrokerMu sync.RWMutex
+	eventBroker   events.Broker = events.NewMemoryBroker(eventHistorySize)
+)
+
+// SetEventBroker replaces the in-process broker events are published to.
+func SetEventBroker(broker events.Broker) {
+	eventBrokerMu.Lock()
+	eventBroker = broker
+	eventBrokerMu.Unlock()
+}
+
+func currentEventBroker() events.Broker {
+	eventBrokerMu.RLock()
+	defer eventBrokerMu.RUnlock()
+	return eventBroker
+}
+
+// publishEvent sends an event to the given users and, unless contributionID
+// is zero, to every member of the contribution.
+func publishEvent(eventType events.Type, userIDs []primitive.ObjectID, contributionID primitive.ObjectID, data interface{}) {
+	currentEventBroker().Publish(newEvent(eventType, userIDs, contributionID, data))
+}
+
+// publishKeyedEvent is publishEvent for an event that may be published
+// again, such as one relayed from the outbox; key identifies it so the
+// repeat is dropped.
+func publishKeyedEvent(key string, eventType events.Type, userIDs []primitive.ObjectID, contributionID primitive.ObjectID, data interface{}) {
+	event := newEvent(eventType, userIDs, contributionID, data)
+	event.Key = key
+	currentEventBroker().Publish(event)
+}
+
+func newEvent(eventType events.Type, userIDs []primitive.ObjectID, contributionID primitive.ObjectID, data interface{}) events.Event {
+	event := events.Event{Type: eventType, Data: data}
+	for _, userID := range userIDs {
+		event.UserIDs = append(event.UserIDs, userID.Hex())
+	}
+	if !contributionID.IsZero() {
+		event.ContributionID = contributionID.Hex()
+	}
+	return event
+}
+
+// queueTransactionEvents queues telling the owners of the wallets a
+// successful transaction moved money between that they were debited and
+// credited. Contribution wallets belong to the whole group, and an
+// organisation with a webhook is also sent its wallet's events there.
+func queueTransactionEvents(ctx context.Context, db *mongo.Database, transaction *models.Transaction) error {
+	sides := []struct {
+		walletID  primitive.ObjectID
+		eventType events.Type
+	}{
+		{transaction.FromWallet, events.WalletDebited},
+		{transaction.ToWallet, events.WalletCredited},
+	}
+	for _, side := range sides {
+		if side.walletID.IsZero() {
+			continue
+		}
+		wallet, err := repository.GetWalletByID(db, side.walletID)
+		if err != nil {
+			return err
+		}
+		data := map[string]interface{}{
+			"wallet_id":        wallet.ID.Hex(),
+			"transaction_id":   transaction.ID.Hex(),
+			"transaction_type": transaction.Type,
+			"amount":           transaction.Amount,
+		}
+		if transaction.Reference != "" {
+			data["reference"] = transaction.Reference
+		}
+		switch wallet.Type {
+		case models.WalletTypeContribution:
+			err = queueEvent(ctx, db, side.eventType, nil, wallet.OwnerID, data)
+		case models.WalletTypeOrganisation:
+			organisation, lookupErr := repository.GetOrganisationByID(ctx, db, wallet.OwnerID)
+			if lookupErr != nil {
+				return lookupErr
+			}
+			if organisation.WebhookURL != "" {
+				if err := queueWebhook(ctx, db, organisation.ID, side.eventType, data); err != nil {
+					return err
+				}
+			}
+			err = queueEvent(ctx, db, side.eventType, []primitive.ObjectID{organisation.OwnerID}, primitive.NilObjectID, data)
+		default:
+			err = queueEvent(ctx, db, side.eventType, []primitive.ObjectID{wallet.OwnerID}, primitive.NilObjectID, data)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queueRoundClosed queues telling the group once every member has
+// collected their payout.
+func queueRoundClosed(ctx context.Context, db *mongo.Database, contributionID primitive.ObjectID) error {
+	contribution, err := repository.GetContributionByID(ctx, db, contributionID)
+	if err != nil {
+		return err
+	}
+	if len(contribution.YetToCollectMembers) > 0 {
+		return nil
+	}
+	return queueEvent(ctx, db, events.RoundClosed, nil, contributionID, map[string]interface{}{
+		"collected_members": len(contribution.AlreadyCollectedMembers),
+	})
+}
+
+// SubscribeEvents subscribes the user to their own events and those of
+// every contribution they are a member of, following them as they join and
+// leave contributions while subscribed. It returns events.ErrEventsMissed
+// with the subscription if events since lastEventID can no longer be
+// replayed.
+func SubscribeEvents(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, lastEventID string) (*events.Subscription, error) {
+	contributions, err := repository.GetContributionsByUser(ctx, db, userID)
+	if err != nil {
+		return nil, err
+	}
+	audience := &eventAudience{userID: userID.Hex(), contributions: map[string]bool{}}
+	for _, contribution := range contributions {
+		audience.contributions[contribution.ID.Hex()] = true
+	}
+	return currentEventBroker().Subscribe(audience.match, lastEventID)
+}
+
+// eventAudience matches the events one user may see.
+type eventAudience struct {
+	mu            sync.Mutex
+	userID        string
+	contributions map[string]bool
+}
+
+func (a *eventAudience) match(event events.Event) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if event.ForUser(a.userID) && event.ContributionID != "" {
+		switch event.Type {
+		case events.MemberJoined:
+			a.contributions[event.ContributionID] = true
+		case events.MemberRemoved:
+			delete(a.contributions, event.ContributionID)
+		}
+	}
+	if event.ForUser(a.userID) {
+		return true
+	}
+	return event.ContributionID != "" && a.contributions[event.ContributionID]
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ExportUserData writes a ZIP of everything held about the user to w:
+// each kind of record as JSON, and the tabular ones also as CSV. It is the
+// user's own data, so personal details are not masked.
+func ExportUserData(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, w io.Writer) error {
+	user, err := repository.GetUserByID(db.Collection("users"), userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	user.Password = ""
+
+	profile, _ := repository.GetProfileByUserID(db.Collection("profiles"), userID)
+	wallet, _ := repository.GetWalletByUserID(db, userID)
+	reliability, err := GetReliabilityScore(ctx, db, userID)
+	if err != nil {
+		return err
+	}
+	contributions, err := repository.GetContributionsByUser(ctx, db, userID)
+	if err != nil {
+		return err
+	}
+	var transactions []models.Transaction
+	if wallet != nil {
+		transactions, err = repository.GetTransactions(ctx, db, bson.M{
+			"$or": []bson.M{{"from_wallet": wallet.ID}, {"to_wallet": wallet.ID}},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	notifications, err := repository.GetUserNotifications(ctx, db, userID)
+	if err != nil {
+		return err
+	}
+	notificationPreferences, err := GetNotificationPreferences(ctx, db, userID)
+	if err != nil {
+		return err
+	}
+	loans, err := repository.GetLoans(ctx, db, bson.M{"borrower_id": userID})
+	if err != nil {
+		return err
+	}
+	guarantees, err := repository.GetGuarantees(ctx, db, bson.M{
+		"$or": []bson.M{{"guarantor_id": userID}, {"member_id": userID}},
+	})
+	if err != nil {
+		return err
+	}
+	sessions, err := repository.GetActiveSessions(ctx, db, userID)
+	if err != nil {
+		return err
+	}
+	kycRequests, err := repository.GetKYCRequests(ctx, db, bson.M{"user_id": userID})
+	if err != nil {
+		return err
+	}
+
+	archive := zip.NewWriter(w)
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"account.json", user},
+		{"profile.json", profile},
+		{"wallet.json", wallet},
+		{"reliability.json", reliability},
+		{"contributions.json", contributions},
+		{"transactions.json", transactions},
+		{"notifications.json", notifications},
+		{"notification_preferences.json", notificationPreferences},
+		{"loans.json", loans},
+		{"guarantees.json", guarantees},
+		{"sessions.json", sessions},
+		{"kyc_requests.json", kycRequests},
+	}
+	for _, file := range files {
+		if err := writeJSONFile(archive, file.name, file.data); err != nil {
+			return err
+		}
+	}
+
+	contributionRows := [][]string{{"id", "name", "amount", "cycle", "role", "collected", "joined_at"}}
+	for _, contribution := range contributions {
+		role := "member"
+		if contribution.GroupAdmin == userID {
+			role = "group_admin"
+		}
+		contributionRows = append(contributionRows, []string{
+			contribution.ID.Hex(), contribution.Name, formatAmount(contribution.Amount), string(contribution.Cycle),
+			role, strconv.FormatBool(containsUser(contribution.AlreadyCollectedMembers, userID)), formatTime(contribution.CreatedAt),
+		})
+	}
+	transactionRows := [][]string{{"id", "date", "type", "direction", "amount", "status", "payment_method", "contribution_id", "penalty"}}
+	for _, transaction := range transactions {
+		direction := "out"
+		if wallet != nil && transaction.ToWallet == wallet.ID {
+			direction = "in"
+		}
+		transactionRows = append(transactionRows, []string{
+			transaction.ID.Hex(), formatTime(transaction.Date), string(transaction.Type), direction,
+			formatAmount(transaction.Amount), string(transaction.Status), string(transaction.PaymentMethod),
+			hexOrEmpty(transaction.ContributionID), formatAmount(transaction.Penalty),
+		})
+	}
+	notificationRows := [][]string{{"id", "created_at", "type", "message", "read"}}
+	for _, notification := range notifications {
+		notificationRows = append(notificationRows, []string{
+			notification.ID.Hex(), formatTime(notification.CreatedAt), string(notification.Type),
+			notification.Message, strconv.FormatBool(notification.Read),
+		})
+	}
+	for name, rows := range map[string][][]string{
+		"contributions.csv": contributionRows,
+		"transactions.csv":  transactionRows,
+		"notifications.csv": notificationRows,
+	} {
+		if err := writeCSVFile(archive, name, rows); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func writeJSONFile(archive *zip.Writer, name string, data interface{}) error {
+	f, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(data); err != nil {
+		return fmt.Errorf("failed to write %s: %v", name, err)
+	}
+	return nil
+}
+
+func writeCSVFile(archive *zip.Writer, name string, rows [][]string) error {
+	f, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	writer := csv.NewWriter(f)
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write %s: %v", name, err)
+	}
+	return nil
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func hexOrEmpty(id primitive.ObjectID) string {
+	if id.IsZero() {
+		return ""
+	}
+	return id.Hex()
+}
@@ -0,0 +1,466 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/messages"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// defaultGuarantorClaimAfter is used when a contribution requires guarantors
+// but does not say how many missed cycles count as a default.
+const defaultGuarantorClaimAfter = 2
+
+// requestGuarantees asks each nominated guarantor to accept liability for a
+// member joining the contribution. Guarantors who are already pending or
+// have accepted for this member are not asked again.
+func requestGuarantees(ctx context.Context, db *mongo.Database, contribution *models.Contribution, memberID primitive.ObjectID, guarantorIDs []primitive.ObjectID) error {
+	existing, err := repository.GetGuarantees(ctx, db, bson.M{
+		"contribution_id": contribution.ID,
+		"member_id":       memberID,
+		"status":          bson.M{"$in": []models.GuaranteeStatus{models.GuaranteePending, models.GuaranteeAccepted}},
+	})
+	if err != nil {
+		return err
+	}
+	var known []primitive.ObjectID
+	for _, guarantee := range existing {
+		known = append(known, guarantee.GuarantorID)
+	}
+
+	var nominated []primitive.ObjectID
+	for _, guarantorID := range guarantorIDs {
+		if guarantorID == memberID {
+			return errors.New("a member cannot be their own guarantor")
+		}
+		if containsUser(known, guarantorID) || containsUser(nominated, guarantorID) {
+			continue
+		}
+		if _, err := repository.GetUserByID(db.Collection("users"), guarantorID); err != nil {
+			return errors.New("guarantor not found")
+		}
+		nominated = append(nominated, guarantorID)
+	}
+	if len(known)+len(nominated) < contribution.GuarantorsRequired {
+		return fmt.Errorf("this contribution requires %d guarantors", contribution.GuarantorsRequired)
+	}
+
+	for _, guarantorID := range nominated {
+		guarantee := &models.Guarantee{
+			ContributionID: contribution.ID,
+			MemberID:       memberID,
+			GuarantorID:    guarantorID,
+			Status:         models.GuaranteePending,
+		}
+		if err := repository.CreateGuarantee(ctx, db, guarantee); err != nil {
+			return err
+		}
+		notification := &models.Notification{
+			UserID:         guarantorID,
+			ContributionID: contribution.ID,
+			Template:       messages.GuaranteeRequested,
+			Params:         messages.Params{"Group": contribution.Name, "Amount": contribution.Amount},
+			Type:           models.NotificationInfo,
+			Category:       models.CategoryMembership,
+		}
+		if err := Notify(ctx, db, notification); err != nil {
+			log.Printf("Failed to notify guarantor %s: %v", guarantorID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// RespondToGuarantee records a guarantor's answer. The member is admitted to
+// the contribution once enough guarantors have accepted.
+func RespondToGuarantee(ctx context.Context, db *mongo.Database, guaranteeID, guarantorID primitive.ObjectID, accept bool) error {
+	guarantee, err := repository.GetGuaranteeByID(ctx, db, guaranteeID)
+	if err != nil {
+		return err
+	}
+	if guarantee.GuarantorID != guarantorID {
+		return errors.New("unauthorized to respond to this guarantee")
+	}
+	if guarantee.Status != models.GuaranteePending {
+		return errors.New("guarantee already processed")
+	}
+	contribution, err := repository.GetContributionByID(ctx, db, guarantee.ContributionID)
+	if err != nil {
+		return err
+	}
+
+	if !accept {
+		guarantee.Status = models.GuaranteeDeclined
+		if err := repository.UpdateGuarantee(ctx, db, guarantee); err != nil {
+			return err
+		}
+		notification := &models.Notification{
+			UserID:         guarantee.MemberID,
+			ContributionID: guarantee.ContributionID,
+			Template:       messages.GuaranteeDeclined,
+			Params:         messages.Params{"Group": contribution.Name},
+			Type:           models.NotificationWarning,
+			Category:       models.CategoryMembership,
+		}
+		return Notify(ctx, db, notification)
+	}
+
+	guarantee.Status = models.GuaranteeAccepted
+	if err := repository.UpdateGuarantee(ctx, db, guarantee); err != nil {
+		return err
+	}
+
+	accepted, err := repository.GetGuarantees(ctx, db, bson.M{
+		"contribution_id": guarantee.ContributionID,
+		"member_id":       guarantee.MemberID,
+		"status":          models.GuaranteeAccepted,
+	})
+	if err != nil {
+		return err
+	}
+	if len(accepted) < contribution.GuarantorsRequired {
+		return nil
+	}
+	if containsUser(contribution.YetToCollectMembers, guarantee.MemberID) || containsUser(contribution.AlreadyCollectedMembers, guarantee.MemberID) {
+		// Already admitted; this guarantee only adds cover
+		now := time.Now()
+		guarantee.ActiveFrom = &now
+		return repository.UpdateGuarantee(ctx, db, guarantee)
+	}
+	return admitMember(ctx, db, contribution, guarantee.MemberID)
+}
+
+// FileGuaranteeClaim lets the group admin claim a defaulting member's arrears
+// from one of their guarantors. The claim is debited only after the group
+// approves it.
+func FileGuaranteeClaim(ctx context.Context, db *mongo.Database, guaranteeID, groupAdminID primitive.ObjectID) (*models.Transaction, error) {
+	guarantee, err := repository.GetGuaranteeByID(ctx, db, guaranteeID)
+	if err != nil {
+		return nil, err
+	}
+	contribution, err := repository.GetContributionByID(ctx, db, guarantee.ContributionID)
+	if err != nil {
+		return nil, err
+	}
+	if contribution.GroupAdmin != groupAdminID {
+		return nil, errors.New("only group admin can file a guarantee claim")
+	}
+	if guarantee.Status != models.GuaranteeAccepted || guarantee.ActiveFrom == nil {
+		return nil, errors.New("guarantee is not active")
+	}
+	if guarantee.ClaimTransactionID != nil {
+		return nil, errors.New("a claim is already pending for this guarantee")
+	}
+
+	arrears, err := memberArrears(ctx, db, contribution, guarantee.MemberID, *guarantee.ActiveFrom)
+	if err != nil {
+		return nil, err
+	}
+	threshold := contribution.GuarantorClaimAfter
+	if threshold <= 0 {
+		threshold = defaultGuarantorClaimAfter
+	}
+	if arrears.CyclesMissed < threshold {
+		return nil, fmt.Errorf("member has not defaulted: %d of %d missed cycles before a claim", arrears.CyclesMissed, threshold)
+	}
+
+	// Arrears are shared equally between the member's active guarantors
+	active, err := repository.GetGuarantees(ctx, db, bson.M{
+		"contribution_id": guarantee.ContributionID,
+		"member_id":       guarantee.MemberID,
+		"status":          models.GuaranteeAccepted,
+		"active_from":     bson.M{"$ne": nil},
+	})
+	if err != nil {
+		return nil, err
+	}
+	amount := roundMoney(arrears.AmountOwed / float64(len(active)))
+
+	guarantorWallet, err := repository.GetWalletByUserID(db, guarantee.GuarantorID)
+	if err != nil {
+		return nil, errors.New("guarantor wallet not found")
+	}
+
+	// Create transaction (pending until the group approves)
+	transaction := &models.Transaction{
+		FromWallet:     guarantorWallet.ID,
+		ToWallet:       contribution.WalletID,
+		Amount:         amount,
+		Type:           models.TransactionGuaranteeClaim,
+		Date:           time.Now(),
+		PaymentMethod:  models.PaymentWallet,
+		Status:         models.StatusPending,
+		ContributionID: contribution.ID,
+	}
+	if err := repository.CreateTransaction(ctx, db, transaction); err != nil {
+		return nil, err
+	}
+
+	guarantee.ClaimTransactionID = &transaction.ID
+	if err := repository.UpdateGuarantee(ctx, db, guarantee); err != nil {
+		db.Collection("transactions").DeleteOne(ctx, bson.M{"_id": transaction.ID})
+		return nil, err
+	}
+
+	if _, err := requestGroupApprovals(ctx, db, contribution, transaction.ID, guarantee.MemberID, guarantee.GuarantorID); err != nil {
+		// Rollback: Release the guarantee and delete the transaction
+		guarantee.ClaimTransactionID = nil
+		repository.UpdateGuarantee(ctx, db, guarantee)
+		db.Collection("approvals").DeleteMany(ctx, bson.M{"transaction_id": transaction.ID})
+		db.Collection("transactions").DeleteOne(ctx, bson.M{"_id": transaction.ID})
+		return nil, err
+	}
+
+	notification := &models.Notification{
+		UserID:         guarantee.GuarantorID,
+		ContributionID: contribution.ID,
+		Template:       messages.GuaranteeClaimFiled,
+		Params:         messages.Params{"Amount": amount, "Group": contribution.Name, "Cycles": arrears.CyclesMissed},
+		Type:           models.NotificationWarning,
+		Category:       models.CategoryPaymentReminders,
+	}
+	if err := Notify(ctx, db, notification); err != nil {
+		log.Printf("Failed to notify guarantor %s: %v", guarantee.GuarantorID.Hex(), err)
+	}
+
+	return transaction, nil
+}
+
+// decideGuaranteeClaim debits the guarantor or drops the claim once a
+// majority of approvers has voted.
+func decideGuaranteeClaim(ctx context.Context, db *mongo.Database, transaction *models.Transaction) error {
+	guarantee, err := repository.GetGuaranteeByClaimTransaction(ctx, db, transaction.ID)
+	if err != nil {
+		return err
+	}
+	if guarantee.Status != models.GuaranteeAccepted {
+		return nil
+	}
+
+	decided, approved, err := approvalOutcome(ctx, db, transaction.ID)
+	if err != nil || !decided {
+		return err
+	}
+	contribution, err := repository.GetContributionByID(ctx, db, guarantee.ContributionID)
+	if err != nil {
+		return err
+	}
+	if _, err := repository.GetWalletByID(db, transaction.FromWallet); err != nil {
+		return errors.New("guarantor wallet not found")
+	}
+
+	err = inTransaction(ctx, db, func(ctx context.Context) error {
+		// The claim transaction leaves pending only once, so concurrent
+		// final votes cannot both charge the guarantor
+		status := models.StatusFailed
+		if approved {
+			status = models.StatusSuccess
+		}
+		err := repository.SettleTransaction(ctx, db, transaction.ID, status)
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := repository.CloseApprovals(ctx, db, transaction.ID); err != nil {
+			return err
+		}
+
+		if approved {
+			if err := repository.DebitWallet(ctx, db, transaction.FromWallet, transaction.Amount); err != nil {
+				if err.Error() != "insufficient balance" {
+					return err
+				}
+				if err := repository.UpdateTransactionStatus(ctx, db, transaction.ID, models.StatusFailed); err != nil {
+					return err
+				}
+				err := queueNotification(ctx, db, &models.Notification{
+					UserID:         contribution.GroupAdmin,
+					ContributionID: contribution.ID,
+					Template:       messages.GuaranteeClaimUnfunded,
+					Params:         messages.Params{"Amount": transaction.Amount, "Group": contribution.Name},
+					Type:           models.NotificationWarning,
+					Category:       models.CategoryPaymentReminders,
+				})
+				if err != nil {
+					return err
+				}
+				approved = false
+			}
+		}
+
+		if !approved {
+			guarantee.ClaimTransactionID = nil
+			return repository.UpdateGuarantee(ctx, db, guarantee)
+		}
+
+		if err := repository.UpdateWalletBalanceContext(ctx, db, transaction.ToWallet, transaction.Amount, true); err != nil {
+			return err
+		}
+		transaction.Status = models.StatusSuccess
+		if err := queueTransactionEvents(ctx, db, transaction); err != nil {
+			return err
+		}
+
+		guarantee.Status = models.GuaranteeClaimed
+		guarantee.ClaimedAmount = transaction.Amount
+		if err := repository.UpdateGuarantee(ctx, db, guarantee); err != nil {
+			return err
+		}
+		notifications := []*models.Notification{
+			{
+				UserID:         guarantee.GuarantorID,
+				ContributionID: contribution.ID,
+				Template:       messages.GuaranteeGuarantorCharged,
+				Params:         messages.Params{"Amount": transaction.Amount, "Group": contribution.Name},
+				Type:           models.NotificationWarning,
+				Category:       models.CategoryPayouts,
+			},
+			{
+				UserID:         guarantee.MemberID,
+				ContributionID: contribution.ID,
+				Template:       messages.GuaranteeMemberCovered,
+				Params:         messages.Params{"Amount": transaction.Amount, "Group": contribution.Name},
+				Type:           models.NotificationWarning,
+				Category:       models.CategoryPayouts,
+			},
+		}
+		for _, notification := range notifications {
+			if err := queueNotification(ctx, db, notification); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if guarantee.Status == models.GuaranteeClaimed {
+		if err := recordDefault(ctx, db, guarantee.MemberID); err != nil {
+			log.Printf("Failed to update reliability score for user %s: %v", guarantee.MemberID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// GetUserGuarantees lists guarantees the user has given or relies on.
+func GetUserGuarantees(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) ([]*models.Guarantee, error) {
+	return repository.GetGuarantees(ctx, db, bson.M{
+		"$or": []bson.M{
+			{"guarantor_id": userID},
+			{"member_id": userID},
+		},
+	})
+}
+
+func GetContributionGuarantees(ctx context.Context, db *mongo.Database, contributionID, userID primitive.ObjectID) ([]*models.Guarantee, error) {
+	contribution, err := repository.GetContributionByID(ctx, db, contributionID)
+	if err != nil {
+		return nil, err
+	}
+	if contribution.GroupAdmin != userID {
+		return nil, errors.New("only group admin can view guarantees")
+	}
+	return repository.GetGuarantees(ctx, db, bson.M{"contribution_id": contributionID})
+}
+
+// GetMemberArrears reports how far a member is behind since they joined as
+// far as guarantees record it, or since the contribution started otherwise.
+func GetMemberArrears(ctx context.Context, db *mongo.Database, contributionID, memberID, userID primitive.ObjectID) (*models.Arrears, error) {
+	contribution, err := repository.GetContributionByID(ctx, db, contributionID)
+	if err != nil {
+		return nil, err
+	}
+	if userID != memberID && contribution.GroupAdmin != userID {
+		return nil, errors.New("unauthorized access to member arrears")
+	}
+	if !containsUser(contribution.YetToCollectMembers, memberID) && !containsUser(contribution.AlreadyCollectedMembers, memberID) {
+		return nil, errors.New("user not in contribution")
+	}
+
+	since, err := arrearsSince(ctx, db, contribution, memberID)
+	if err != nil {
+		return nil, err
+	}
+	return memberArrears(ctx, db, contribution, memberID, since)
+}
+
+// arrearsSince is when a member's arrears are counted from: when their
+// guarantee became active, or when the contribution started.
+func arrearsSince(ctx context.Context, db *mongo.Database, contribution *models.Contribution, memberID primitive.ObjectID) (time.Time, error) {
+	since := contribution.CreatedAt
+	guarantees, err := repository.GetGuarantees(ctx, db, bson.M{
+		"contribution_id": contribution.ID,
+		"member_id":       memberID,
+		"active_from":     bson.M{"$ne": nil},
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, guarantee := range guarantees {
+		if guarantee.ActiveFrom != nil && guarantee.ActiveFrom.After(since) {
+			since = *guarantee.ActiveFrom
+		}
+	}
+	return since, nil
+}
+
+// memberArrears compares the deadlines that have passed since a date with
+// the contributions the member actually paid in that time.
+func memberArrears(ctx context.Context, db *mongo.Database, contribution *models.Contribution, memberID primitive.ObjectID, since time.Time) (*models.Arrears, error) {
+	recurrence, err := contributionRecurrence(ctx, db, contribution)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	due := 0
+	for deadline := recurrence.Next(since); deadline.Before(now); deadline = recurrence.Next(deadline) {
+		due++
+	}
+
+	wallet, err := repository.GetWalletByUserID(db, memberID)
+	if err != nil {
+		return nil, errors.New("user wallet not found")
+	}
+	transactions, err := repository.GetTransactions(ctx, db, bson.M{
+		"from_wallet":     wallet.ID,
+		"contribution_id": contribution.ID,
+		"type":            models.TransactionContribution,
+		"status":          models.StatusSuccess,
+		"date":            bson.M{"$gte": since},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var paidAmount float64
+	for _, transaction := range transactions {
+		paidAmount += transaction.Amount
+	}
+	paid := 0
+	if contribution.Amount > 0 {
+		paid = int(paidAmount/contribution.Amount + 1e-9)
+	}
+
+	missed := due - paid
+	if missed < 0 {
+		missed = 0
+	}
+	return &models.Arrears{
+		ContributionID: contribution.ID,
+		MemberID:       memberID,
+		Since:          since,
+		CyclesDue:      due,
+		CyclesPaid:     paid,
+		CyclesMissed:   missed,
+		AmountOwed:     roundMoney(float64(missed) * contribution.Amount),
+	}, nil
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/schedule"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func AddHoliday(ctx context.Context, db *mongo.Database, actorID primitive.ObjectID, holiday *models.Holiday) error {
+	if err := RequirePermission(ctx, db, actorID, models.PermHolidaysWrite); err != nil {
+		return err
+	}
+	holiday.Name = strings.TrimSpace(holiday.Name)
+	if holiday.Name == "" {
+		return errors.New("holiday name is required")
+	}
+	if _, err := schedule.ParseDate(holiday.Date); err != nil {
+		return errors.New("holiday date must be in YYYY-MM-DD format")
+	}
+	return repository.UpsertHoliday(ctx, db, holiday)
+}
+
+func GetHolidays(ctx context.Context, db *mongo.Database) ([]*models.Holiday, error) {
+	return repository.GetHolidays(ctx, db)
+}
+
+func DeleteHoliday(ctx context.Context, db *mongo.Database, actorID, id primitive.ObjectID) error {
+	if err := RequirePermission(ctx, db, actorID, models.PermHolidaysWrite); err != nil {
+		return err
+	}
+	return repository.DeleteHoliday(ctx, db, id)
+}
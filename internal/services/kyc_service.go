@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/messages"
+	"github.com/Gerard-007/ajor_app/pkg/payment"
+	"github.com/Gerard-007/ajor_app/pkg/sms"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// kycLimitWindow is the rolling window daily inflow and outflow limits
+// are measured over.
+const kycLimitWindow = 24 * time.Hour
+
+func GetKYCStatus(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) (*models.KYCStatus, error) {
+	user, err := repository.GetUserByID(db.Collection("users"), userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	tier := user.EffectiveKYCTier()
+	status := &models.KYCStatus{Tier: tier, Limits: models.KYCTierLimits[tier]}
+
+	if wallet, err := repository.GetWalletByUserID(db, userID); err == nil {
+		since := time.Now().Add(-kycLimitWindow)
+		if status.InflowToday, err = repository.SumWalletFlow(ctx, db, wallet.ID, true, since); err != nil {
+			return nil, err
+		}
+		if status.OutflowToday, err = repository.SumWalletFlow(ctx, db, wallet.ID, false, since); err != nil {
+			return nil, err
+		}
+	}
+	if pending, err := repository.GetPendingKYCRequest(ctx, db, userID); err == nil {
+		status.PendingRequest = pending
+	}
+	return status, nil
+}
+
+// RequestKYCUpgrade queues a request to move the user up to tier for a
+// compliance review. Documents must already be saved. Tier 2 and above
+// need the user's BVN verified, which is done here if it has not been.
+func RequestKYCUpgrade(ctx context.Context, db *mongo.Database, pg payment.PaymentGateway, userID primitive.ObjectID, tier models.KYCTier, documents []models.KYCDocument) (*models.KYCRequest, error) {
+	required, ok := models.KYCRequiredDocuments[tier]
+	if !ok {
+		return nil, errors.New("invalid KYC tier")
+	}
+	user, err := repository.GetUserByID(db.Collection("users"), userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	current := user.EffectiveKYCTier()
+	if current == models.KYCTierNone {
+		return nil, errors.New("phone number not verified")
+	}
+	if tier <= current {
+		return nil, fmt.Errorf("already at KYC tier %d", current)
+	}
+	if _, err := repository.GetPendingKYCRequest(ctx, db, userID); err == nil {
+		return nil, errors.New("kyc request already pending")
+	} else if !strings.Contains(err.Error(), "not found") {
+		return nil, err
+	}
+
+	for _, documentType := range required {
+		found := false
+		for _, document := range documents {
+			if document.Type == documentType {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("document %s is required for KYC tier %d", documentType, tier)
+		}
+	}
+	if tier >= models.KYCTier2 && user.BVNVerifiedAt == nil {
+		if _, err := verifyBVN(ctx, db, pg, user); err != nil {
+			return nil, err
+		}
+	}
+
+	request := &models.KYCRequest{
+		UserID:    userID,
+		Tier:      tier,
+		Documents: documents,
+		Status:    models.KYCRequestPending,
+	}
+	if err := repository.CreateKYCRequest(ctx, db, request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// GetKYCRequests lists the review queue, pending requests by default.
+func GetKYCRequests(ctx context.Context, db *mongo.Database, actorID primitive.ObjectID, status models.KYCRequestStatus) ([]*models.KYCRequest, error) {
+	if err := RequirePermission(ctx, db, actorID, models.PermKYCReview); err != nil {
+		return nil, err
+	}
+	if status == "" {
+		status = models.KYCRequestPending
+	}
+	return repository.GetKYCRequests(ctx, db, bson.M{"status": status})
+}
+
+// GetKYCDocument returns a document attached to a request so a reviewer
+// can download it.
+func GetKYCDocument(ctx context.Context, db *mongo.Database, actorID, requestID primitive.ObjectID, documentType models.KYCDocumentType) (*models.KYCDocument, error) {
+	if err := RequirePermission(ctx, db, actorID, models.PermKYCReview); err != nil {
+		return nil, err
+	}
+	request, err := repository.GetKYCRequestByID(ctx, db, requestID)
+	if err != nil {
+		return nil, err
+	}
+	for _, document := range request.Documents {
+		if document.Type == documentType {
+			return &document, nil
+		}
+	}
+	return nil, errors.New("document not found")
+}
+
+// ReviewKYCRequest approves or rejects an upgrade. Approval moves the user
+// to the requested tier; reviewers cannot decide their own requests.
+func ReviewKYCRequest(ctx context.Context, db *mongo.Database, actorID, requestID primitive.ObjectID, approve bool, note string) (*models.KYCRequest, error) {
+	if err := RequirePermission(ctx, db, actorID, models.PermKYCReview); err != nil {
+		return nil, err
+	}
+	request, err := repository.GetKYCRequestByID(ctx, db, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if request.UserID == actorID {
+		return nil, errors.New("cannot review your own kyc request")
+	}
+
+	status := models.KYCRequestRejected
+	if approve {
+		status = models.KYCRequestApproved
+		if request.Tier >= models.KYCTier2 {
+			user, err := repository.GetUserByID(db.Collection("users"), request.UserID)
+			if err != nil {
+				return nil, errors.New("user not found")
+			}
+			if user.BVNVerifiedAt == nil {
+				return nil, errors.New("BVN not verified")
+			}
+		}
+	}
+	if err := repository.ReviewKYCRequest(ctx, db, requestID, actorID, status, note); err != nil {
+		return nil, err
+	}
+	if approve {
+		if err := repository.SetUserKYCTier(db, request.UserID, request.Tier); err != nil {
+			return nil, err
+		}
+	}
+	recordAuditEvent(ctx, db, &models.AuditEvent{
+		Type:    models.AuditKYCReviewed,
+		UserID:  &request.UserID,
+		ActorID: &actorID,
+		Details: map[string]interface{}{"request_id": requestID, "tier": request.Tier, "status": status},
+	})
+
+	notification := &models.Notification{
+		UserID:   request.UserID,
+		Template: messages.KYCUpgraded,
+		Params:   messages.Params{"Tier": request.Tier},
+		Type:     models.NotificationInfo,
+		Category: models.CategorySecurity,
+	}
+	if !approve {
+		notification.Template = messages.KYCRejected
+		notification.Params["Reason"] = note
+		notification.Type = models.NotificationWarning
+	}
+	if err := Notify(ctx, db, notification); err != nil {
+		return nil, err
+	}
+	return repository.GetKYCRequestByID(ctx, db, requestID)
+}
+
+// verifyBVN checks the user's BVN with the payment provider and records it
+// as verified when it is registered to the user's phone number. It returns
+// what the provider holds for the BVN.
+func verifyBVN(ctx context.Context, db *mongo.Database, pg payment.PaymentGateway, user *models.User) (*payment.BVNDetails, error) {
+	if user.BVN == "" {
+		return nil, errors.New("BVN not verified: no BVN on the account")
+	}
+	details, err := pg.ResolveBVN(ctx, user.BVN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify BVN: %w", err)
+	}
+	if !bvnMatchesUser(details, user) {
+		return nil, errors.New("BVN not verified: it is registered to a different phone number")
+	}
+	now := time.Now()
+	if err := repository.SetUserBVNVerified(db, user.ID, now); err != nil {
+		return nil, err
+	}
+	user.BVNVerifiedAt = &now
+	return details, nil
+}
+
+// bvnMatchesUser reports whether a BVN is registered to the user's phone
+// number, whichever form either is written in.
+func bvnMatchesUser(details *payment.BVNDetails, user *models.User) bool {
+	registered, err := sms.NormalizePhone(details.PhoneNumber)
+	if err != nil {
+		return false
+	}
+	phone, err := sms.NormalizePhone(user.Phone)
+	if err != nil {
+		return false
+	}
+	return registered == phone
+}
+
+// checkInflowLimit stops money reaching the user's wallet beyond their
+// tier's balance cap or daily inflow limit.
+func checkInflowLimit(ctx context.Context, db *mongo.Database, user *models.User, wallet *models.Wallet, amount float64) error {
+	tier, limits, err := kycLimits(user)
+	if err != nil {
+		return err
+	}
+	if limits.MaxBalance > 0 && wallet.Balance+amount > limits.MaxBalance {
+		return fmt.Errorf("KYC limit exceeded: tier %d wallets cannot hold more than %.2f", tier, limits.MaxBalance)
+	}
+	if limits.DailyInflow > 0 {
+		inflow, err := repository.SumWalletFlow(ctx, db, wallet.ID, true, time.Now().Add(-kycLimitWindow))
+		if err != nil {
+			return err
+		}
+		if inflow+amount > limits.DailyInflow {
+			return fmt.Errorf("KYC limit exceeded: tier %d can receive %.2f a day, %.2f left", tier, limits.DailyInflow, remaining(limits.DailyInflow, inflow))
+		}
+	}
+	return nil
+}
+
+// checkOutflowLimit stops the user sending more than their tier's daily
+// outflow limit from their wallet. It applies to every debit the user
+// makes: contributions and loan repayments. Recoveries the group makes,
+// deductions from payouts and guarantor claims, only return money owed
+// and are not limited.
+func checkOutflowLimit(ctx context.Context, db *mongo.Database, user *models.User, wallet *models.Wallet, transactionType models.TransactionType, amount float64) error {
+	outflow, err := repository.SumWalletFlow(ctx, db, wallet.ID, false, time.Now().Add(-kycLimitWindow))
+	if err != nil {
+		return err
+	}
+	return checkDailyOutflow(user, transactionType, outflow, amount)
+}
+
+// checkDailyOutflow checks a debit against the user's daily outflow limit,
+// given what the wallet has already sent within it. The withdrawal that
+// pays out the balance on account closure is not limited: it can be more
+// than a day's limit, and there is no other way to take the money out.
+func checkDailyOutflow(user *models.User, transactionType models.TransactionType, outflow, amount float64) error {
+	if transactionType == models.TransactionWithdrawal {
+		return nil
+	}
+	tier, limits, err := kycLimits(user)
+	if err != nil {
+		return err
+	}
+	if limits.DailyOutflow > 0 && outflow+amount > limits.DailyOutflow {
+		return fmt.Errorf("KYC limit exceeded: tier %d can send %.2f a day, %.2f left", tier, limits.DailyOutflow, remaining(limits.DailyOutflow, outflow))
+	}
+	return nil
+}
+
+func checkContributionLimit(user *models.User, amount float64) error {
+	tier, limits, err := kycLimits(user)
+	if err != nil {
+		return err
+	}
+	if limits.MaxContribution > 0 && amount > limits.MaxContribution {
+		return fmt.Errorf("KYC limit exceeded: tier %d contributions cannot exceed %.2f", tier, limits.MaxContribution)
+	}
+	return nil
+}
+
+func kycLimits(user *models.User) (models.KYCTier, models.KYCLimits, error) {
+	tier := user.EffectiveKYCTier()
+	if tier == models.KYCTierNone {
+		return tier, models.KYCLimits{}, errors.New("phone number not verified")
+	}
+	return tier, models.KYCTierLimits[tier], nil
+}
+
+func remaining(limit, used float64) float64 {
+	if used >= limit {
+		return 0
+	}
+	return limit - used
+}
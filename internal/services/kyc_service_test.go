@@ -0,0 +1,88 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/pkg/payment"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBVNMatchesUser(t *testing.T) {
+	user := &models.User{Phone: "+2348031234567"}
+
+	tests := []struct {
+		name       string
+		registered string
+		want       bool
+	}{
+		{"same number", "+2348031234567", true},
+		{"same number in local form", "08031234567", true},
+		{"same number without the plus", "2348031234567", true},
+		{"different number", "08039999999", false},
+		{"no number on the BVN", "", false},
+		{"invalid number on the BVN", "12345", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, bvnMatchesUser(&payment.BVNDetails{PhoneNumber: tt.registered}, user))
+		})
+	}
+}
+
+func TestCheckContributionLimit(t *testing.T) {
+	verifiedAt := time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		user    *models.User
+		amount  float64
+		wantErr string
+	}{
+		{"tier 1 within the limit", &models.User{Verified: true}, 50000, ""},
+		{"tier 1 over the limit", &models.User{Verified: true}, 50001, "KYC limit exceeded: tier 1 contributions cannot exceed 50000.00"},
+		{"tier 2 over the tier 1 limit", &models.User{Verified: true, KYCTier: models.KYCTier2, BVNVerifiedAt: &verifiedAt}, 150000, ""},
+		{"tier 2 without a verified BVN", &models.User{Verified: true, KYCTier: models.KYCTier2}, 150000, "KYC limit exceeded: tier 1 contributions cannot exceed 50000.00"},
+		{"phone not verified", &models.User{}, 100, "phone number not verified"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkContributionLimit(tt.user, tt.amount)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckDailyOutflow(t *testing.T) {
+	tier1 := &models.User{Verified: true}
+	tests := []struct {
+		name            string
+		user            *models.User
+		transactionType models.TransactionType
+		outflow         float64
+		amount          float64
+		wantErr         string
+	}{
+		{"contribution within the limit", tier1, models.TransactionContribution, 20000, 30000, ""},
+		{"contribution over the limit", tier1, models.TransactionContribution, 20000, 30001, "KYC limit exceeded: tier 1 can send 50000.00 a day, 30000.00 left"},
+		{"loan repayment after the limit is used up", tier1, models.TransactionLoanRepayment, 60000, 1, "KYC limit exceeded: tier 1 can send 50000.00 a day, 0.00 left"},
+		{"closure payout of a balance above the limit", tier1, models.TransactionWithdrawal, 0, 300000, ""},
+		{"closure payout after the limit is used up", tier1, models.TransactionWithdrawal, 50000, 250000, ""},
+		{"phone not verified", &models.User{}, models.TransactionContribution, 0, 100, "phone number not verified"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDailyOutflow(tt.user, tt.transactionType, tt.outflow, tt.amount)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,589 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/messages"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const maxLoanInstallments = 12
+
+// ApplyForLoan records a member's request to borrow from the contribution
+// wallet and asks the rest of the group to approve it.
+func ApplyForLoan(ctx context.Context, db *mongo.Database, contributionID, borrowerID primitive.ObjectID, amount float64, installments int, reason string) (*models.Loan, error) {
+	contribution, err := repository.GetContributionByID(ctx, db, contributionID)
+	if err != nil {
+		return nil, err
+	}
+	if !containsUser(contribution.YetToCollectMembers, borrowerID) && !containsUser(contribution.AlreadyCollectedMembers, borrowerID) {
+		return nil, errors.New("user not in contribution")
+	}
+	if contribution.LoanLimitPercent <= 0 {
+		return nil, errors.New("loans are not enabled for this contribution")
+	}
+	if amount <= 0 {
+		return nil, errors.New("loan amount must be positive")
+	}
+	if installments < 1 || installments > maxLoanInstallments {
+		return nil, fmt.Errorf("loan installments must be between 1 and %d", maxLoanInstallments)
+	}
+
+	existing, err := repository.GetLoans(ctx, db, bson.M{
+		"contribution_id": contributionID,
+		"status":          bson.M{"$in": []models.LoanStatus{models.LoanPending, models.LoanActive}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var committed float64
+	for _, loan := range existing {
+		if loan.BorrowerID == borrowerID {
+			return nil, errors.New("borrower already has an open loan in this contribution")
+		}
+		if loan.Status == models.LoanPending {
+			committed += loan.Principal
+		}
+	}
+
+	groupWallet, err := repository.GetWalletByID(db, contribution.WalletID)
+	if err != nil {
+		return nil, errors.New("group wallet not found")
+	}
+	lendable := groupWallet.Balance*contribution.LoanLimitPercent/100 - committed
+	if amount > lendable {
+		return nil, fmt.Errorf("loan amount exceeds the %.2f available to lend", math.Max(lendable, 0))
+	}
+
+	borrowerWallet, err := repository.GetWalletByUserID(db, borrowerID)
+	if err != nil {
+		return nil, errors.New("user wallet not found")
+	}
+
+	// Create transaction (pending until the group approves)
+	transaction := &models.Transaction{
+		FromWallet:     groupWallet.ID,
+		ToWallet:       borrowerWallet.ID,
+		Amount:         amount,
+		Type:           models.TransactionLoan,
+		Date:           time.Now(),
+		PaymentMethod:  models.PaymentWallet,
+		Status:         models.StatusPending,
+		ContributionID: contributionID,
+	}
+	if err := repository.CreateTransaction(ctx, db, transaction); err != nil {
+		return nil, err
+	}
+
+	loan := &models.Loan{
+		ContributionID:   contributionID,
+		BorrowerID:       borrowerID,
+		TransactionID:    transaction.ID,
+		Principal:        amount,
+		InterestRate:     contribution.LoanInterestRate,
+		InstallmentCount: installments,
+		Reason:           reason,
+		Schedule:         []models.LoanInstallment{},
+		Status:           models.LoanPending,
+	}
+	if err := repository.CreateLoan(ctx, db, loan); err != nil {
+		db.Collection("transactions").DeleteOne(ctx, bson.M{"_id": transaction.ID})
+		return nil, err
+	}
+
+	approvers, err := requestGroupApprovals(ctx, db, contribution, transaction.ID, borrowerID)
+	if err != nil {
+		// Rollback: Delete loan and transaction
+		db.Collection("loans").DeleteOne(ctx, bson.M{"_id": loan.ID})
+		db.Collection("approvals").DeleteMany(ctx, bson.M{"transaction_id": transaction.ID})
+		db.Collection("transactions").DeleteOne(ctx, bson.M{"_id": transaction.ID})
+		return nil, err
+	}
+
+	for _, approverID := range approvers {
+		notification := &models.Notification{
+			UserID:         approverID,
+			ContributionID: contributionID,
+			Template:       messages.LoanRequested,
+			Params:         messages.Params{"Amount": amount, "Group": contribution.Name},
+			Type:           models.NotificationInfo,
+			Category:       models.CategoryMembership,
+		}
+		if err := Notify(ctx, db, notification); err != nil {
+			log.Printf("Failed to notify approver %s: %v", approverID.Hex(), err)
+		}
+	}
+
+	return loan, nil
+}
+
+// decideLoan disburses or rejects a pending loan once a majority of its
+// approvers has voted.
+func decideLoan(ctx context.Context, db *mongo.Database, transaction *models.Transaction) error {
+	loan, err := repository.GetLoanByTransactionID(ctx, db, transaction.ID)
+	if err != nil {
+		return err
+	}
+	if loan.Status != models.LoanPending {
+		return nil
+	}
+
+	decided, approved, err := approvalOutcome(ctx, db, transaction.ID)
+	if err != nil || !decided {
+		return err
+	}
+
+	contribution, err := repository.GetContributionByID(ctx, db, loan.ContributionID)
+	if err != nil {
+		return err
+	}
+
+	// The loan transaction leaves pending only once, so when the last
+	// votes are cast together only one of them decides the loan
+	return inTransaction(ctx, db, func(ctx context.Context) error {
+		status := models.StatusFailed
+		if approved {
+			status = models.StatusSuccess
+		}
+		err := repository.SettleTransaction(ctx, db, transaction.ID, status)
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		transaction.Status = status
+		if err := repository.CloseApprovals(ctx, db, transaction.ID); err != nil {
+			return err
+		}
+
+		if !approved {
+			loan.Status = models.LoanRejected
+			if err := repository.UpdateLoan(ctx, db, loan); err != nil {
+				return err
+			}
+			return queueNotification(ctx, db, &models.Notification{
+				UserID:         loan.BorrowerID,
+				ContributionID: loan.ContributionID,
+				Template:       messages.LoanDeclined,
+				Params:         messages.Params{"Amount": loan.Principal, "Group": contribution.Name},
+				Type:           models.NotificationWarning,
+				Category:       models.CategoryMembership,
+			})
+		}
+
+		if err := repository.DebitWallet(ctx, db, transaction.FromWallet, loan.Principal); err != nil {
+			if err.Error() == "insufficient balance" {
+				return errors.New("insufficient balance in group wallet")
+			}
+			return err
+		}
+		if err := repository.UpdateWalletBalanceContext(ctx, db, transaction.ToWallet, loan.Principal, true); err != nil {
+			return err
+		}
+		if err := queueTransactionEvents(ctx, db, transaction); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		loan.Status = models.LoanActive
+		loan.DisbursedAt = &now
+		loan.Schedule = buildLoanSchedule(loan.Principal, loan.InterestRate, loan.InstallmentCount, now)
+		refreshLoan(loan, now)
+		if err := repository.UpdateLoan(ctx, db, loan); err != nil {
+			return err
+		}
+		return queueNotification(ctx, db, &models.Notification{
+			UserID:         loan.BorrowerID,
+			ContributionID: loan.ContributionID,
+			Template:       messages.LoanDisbursed,
+			Params:         messages.Params{"Amount": loan.Principal, "Group": contribution.Name, "DueDate": loan.Schedule[0].DueDate},
+			Type:           models.NotificationInfo,
+			Category:       models.CategoryPayouts,
+		})
+	})
+}
+
+// RepayLoan moves money from the borrower's wallet back to the contribution
+// wallet and applies it to the oldest unpaid installments.
+func RepayLoan(ctx context.Context, db *mongo.Database, loanID, userID primitive.ObjectID, amount float64) (*models.Loan, error) {
+	loan, err := repository.GetLoanByID(ctx, db, loanID)
+	if err != nil {
+		return nil, err
+	}
+	if loan.BorrowerID != userID {
+		return nil, errors.New("only the borrower can repay this loan")
+	}
+	if loan.Status != models.LoanActive {
+		return nil, errors.New("loan is not active")
+	}
+	if amount <= 0 {
+		return nil, errors.New("repayment amount must be positive")
+	}
+
+	refreshLoan(loan, time.Now())
+	if roundMoney(amount) > loan.Outstanding {
+		return nil, fmt.Errorf("repayment exceeds outstanding balance of %.2f", loan.Outstanding)
+	}
+
+	wallet, err := repository.GetWalletByUserID(db, userID)
+	if err != nil {
+		return nil, errors.New("user wallet not found")
+	}
+	if wallet.Balance < amount {
+		return nil, errors.New("insufficient balance")
+	}
+	borrower, err := repository.GetUserByID(db.Collection("users"), userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if err := checkOutflowLimit(ctx, db, borrower, wallet, models.TransactionLoanRepayment, amount); err != nil {
+		return nil, err
+	}
+
+	if err := repayLoan(ctx, db, loan, wallet, amount); err != nil {
+		return nil, err
+	}
+	return loan, nil
+}
+
+// deductLoansFromPayout recovers outstanding loans from a member's payout
+// right after it lands in their wallet.
+func deductLoansFromPayout(ctx context.Context, db *mongo.Database, contributionID primitive.ObjectID, wallet *models.Wallet, payout float64) error {
+	loans, err := repository.GetLoans(ctx, db, bson.M{
+		"contribution_id": contributionID,
+		"borrower_id":     wallet.OwnerID,
+		"status":          models.LoanActive,
+	})
+	if err != nil {
+		return err
+	}
+
+	remaining := payout
+	for _, loan := range loans {
+		if remaining <= 0 {
+			break
+		}
+		refreshLoan(loan, time.Now())
+		deduction := math.Min(loan.Outstanding, remaining)
+		if deduction <= 0 {
+			continue
+		}
+		err := inTransaction(ctx, db, func(ctx context.Context) error {
+			if err := repayLoan(ctx, db, loan, wallet, deduction); err != nil {
+				return err
+			}
+			return queueNotification(ctx, db, &models.Notification{
+				UserID:         loan.BorrowerID,
+				ContributionID: contributionID,
+				Template:       messages.LoanPayoutDeduction,
+				Params:         messages.Params{"Amount": deduction, "Outstanding": loan.Outstanding},
+				Type:           models.NotificationInfo,
+				Category:       models.CategoryPayouts,
+			})
+		})
+		if err != nil {
+			return err
+		}
+		remaining -= deduction
+	}
+	return nil
+}
+
+// repayLoan moves amount from the wallet to the contribution wallet and
+// applies it to the loan, all in one transaction. The loan is read again
+// within it, so concurrent repayments each apply to the latest balance;
+// loan is updated to match.
+func repayLoan(ctx context.Context, db *mongo.Database, loan *models.Loan, wallet *models.Wallet, amount float64) error {
+	contribution, err := repository.GetContributionByID(ctx, db, loan.ContributionID)
+	if err != nil {
+		return err
+	}
+
+	return inTransaction(ctx, db, func(ctx context.Context) error {
+		current, err := repository.GetLoanByID(ctx, db, loan.ID)
+		if err != nil {
+			return err
+		}
+		if current.Status != models.LoanActive {
+			return errors.New("loan is not active")
+		}
+		if err := applyLoanRepayment(current, amount, time.Now()); err != nil {
+			return err
+		}
+
+		if err := repository.DebitWallet(ctx, db, wallet.ID, amount); err != nil {
+			return err
+		}
+		if err := repository.UpdateWalletBalanceContext(ctx, db, contribution.WalletID, amount, true); err != nil {
+			return err
+		}
+		transaction := &models.Transaction{
+			FromWallet:     wallet.ID,
+			ToWallet:       contribution.WalletID,
+			Amount:         amount,
+			Type:           models.TransactionLoanRepayment,
+			Date:           time.Now(),
+			PaymentMethod:  models.PaymentWallet,
+			Status:         models.StatusSuccess,
+			ContributionID: loan.ContributionID,
+		}
+		if err := repository.CreateTransaction(ctx, db, transaction); err != nil {
+			return err
+		}
+		if err := queueTransactionEvents(ctx, db, transaction); err != nil {
+			return err
+		}
+		if err := repository.UpdateLoan(ctx, db, current); err != nil {
+			return err
+		}
+		*loan = *current
+		return nil
+	})
+}
+
+func GetLoan(ctx context.Context, db *mongo.Database, loanID, userID primitive.ObjectID) (*models.Loan, error) {
+	loan, err := repository.GetLoanByID(ctx, db, loanID)
+	if err != nil {
+		return nil, err
+	}
+	if loan.BorrowerID != userID {
+		contribution, err := repository.GetContributionByID(ctx, db, loan.ContributionID)
+		if err != nil {
+			return nil, err
+		}
+		if contribution.GroupAdmin != userID {
+			return nil, errors.New("unauthorized access to loan")
+		}
+	}
+	return loan, nil
+}
+
+// GetContributionLoans lists every loan for the group admin, and only their
+// own loans for other members.
+func GetContributionLoans(ctx context.Context, db *mongo.Database, contributionID, userID primitive.ObjectID) ([]*models.Loan, error) {
+	contribution, err := repository.GetContributionByID(ctx, db, contributionID)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{"contribution_id": contributionID}
+	if contribution.GroupAdmin != userID {
+		if !containsUser(contribution.YetToCollectMembers, userID) && !containsUser(contribution.AlreadyCollectedMembers, userID) {
+			return nil, errors.New("unauthorized access to contribution")
+		}
+		filter["borrower_id"] = userID
+	}
+	return repository.GetLoans(ctx, db, filter)
+}
+
+// AccrueLoans brings every active loan's interest and overdue state up to
+// date and reminds borrowers and group admins about overdue installments.
+// It returns how many loans it updated.
+func AccrueLoans(ctx context.Context, db *mongo.Database, now time.Time) (int, error) {
+	loans, err := repository.GetLoans(ctx, db, bson.M{"status": models.LoanActive})
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, loan := range loans {
+		contribution, err := repository.GetContributionByID(ctx, db, loan.ContributionID)
+		if err != nil {
+			log.Printf("Failed to get contribution %s: %v", loan.ContributionID.Hex(), err)
+			continue
+		}
+
+		// The loan is read again within the transaction, so a repayment
+		// made since it was listed is not overwritten
+		accrued, newlyOverdue := false, false
+		err = inTransaction(ctx, db, func(ctx context.Context) error {
+			current, err := repository.GetLoanByID(ctx, db, loan.ID)
+			if err != nil {
+				return err
+			}
+			if current.Status != models.LoanActive {
+				return nil
+			}
+			wasOverdue := current.Overdue
+			refreshLoan(current, now)
+			if err := repository.UpdateLoan(ctx, db, current); err != nil {
+				return err
+			}
+			accrued, newlyOverdue = true, current.Overdue && !wasOverdue
+			if !current.Overdue {
+				return nil
+			}
+
+			// Keyed per day, so a retried run does not send them again
+			day := now.UTC().Format("2006-01-02")
+			overdue := overdueAmount(current, now)
+			notifications := []*models.Notification{
+				{
+					UserID:         current.BorrowerID,
+					ContributionID: current.ContributionID,
+					Template:       messages.LoanOverdue,
+					Params:         messages.Params{"Amount": overdue, "Group": contribution.Name},
+					Type:           models.NotificationWarning,
+					Category:       models.CategoryPaymentReminders,
+					Key:            fmt.Sprintf("loan_overdue:%s:%s", current.ID.Hex(), day),
+				},
+				{
+					UserID:         contribution.GroupAdmin,
+					ContributionID: current.ContributionID,
+					Template:       messages.LoanOverdueAdmin,
+					Params:         messages.Params{"Amount": overdue, "Group": contribution.Name},
+					Type:           models.NotificationWarning,
+					Category:       models.CategoryPaymentReminders,
+					Key:            fmt.Sprintf("loan_overdue_admin:%s:%s", current.ID.Hex(), day),
+				},
+			}
+			for _, notification := range notifications {
+				if err := queueNotification(ctx, db, notification); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("Failed to update loan %s: %v", loan.ID.Hex(), err)
+			continue
+		}
+		if !accrued {
+			continue
+		}
+		updated++
+		if newlyOverdue {
+			if err := recordDefault(ctx, db, loan.BorrowerID); err != nil {
+				log.Printf("Failed to update reliability score for user %s: %v", loan.BorrowerID.Hex(), err)
+			}
+		}
+	}
+	return updated, nil
+}
+
+// buildLoanSchedule splits principal and flat interest into equal monthly
+// installments, putting any rounding remainder on the last one.
+func buildLoanSchedule(principal, monthlyRate float64, installments int, disbursedAt time.Time) []models.LoanInstallment {
+	totalInterest := roundMoney(principal * monthlyRate / 100 * float64(installments))
+	principalShare := roundMoney(principal / float64(installments))
+	interestShare := roundMoney(totalInterest / float64(installments))
+
+	schedule := make([]models.LoanInstallment, installments)
+	for i := range schedule {
+		schedule[i] = models.LoanInstallment{
+			Number:    i + 1,
+			DueDate:   disbursedAt.AddDate(0, i+1, 0),
+			Principal: principalShare,
+			Interest:  interestShare,
+			Status:    models.InstallmentPending,
+		}
+	}
+	last := &schedule[installments-1]
+	last.Principal = roundMoney(principal - principalShare*float64(installments-1))
+	last.Interest = roundMoney(totalInterest - interestShare*float64(installments-1))
+	return schedule
+}
+
+// refreshLoan recomputes overdue state, late interest and totals. Late
+// interest is charged daily at the loan's monthly rate on whatever part of an
+// installment is still unpaid past its due date. Each full day is charged
+// once, on what was unpaid when it is charged, so paying part of an
+// installment lowers the interest still to come but not what has already
+// accrued.
+func refreshLoan(loan *models.Loan, now time.Time) {
+	var accrued, outstanding float64
+	loan.Overdue = false
+	for i := range loan.Schedule {
+		installment := &loan.Schedule[i]
+		if installment.Status == models.InstallmentPaid {
+			accrued += installment.Interest + installment.LateInterest
+			continue
+		}
+		if now.After(installment.DueDate) {
+			accrueLateInterest(installment, loan.InterestRate, now)
+			installment.Status = models.InstallmentOverdue
+			loan.Overdue = true
+			accrued += installment.Interest + installment.LateInterest
+		}
+		outstanding += installment.Principal + installment.Interest + installment.LateInterest - installment.Paid
+	}
+	loan.AccruedInterest = roundMoney(accrued)
+	loan.Outstanding = roundMoney(math.Max(outstanding, 0))
+	if loan.Status == models.LoanActive && loan.Outstanding < 0.01 {
+		loan.Status = models.LoanRepaid
+	}
+}
+
+// accrueLateInterest charges an overdue installment late interest for the
+// full days since it was last charged. Installments charged before the
+// charge time was kept are recharged from their due date.
+func accrueLateInterest(installment *models.LoanInstallment, monthlyRate float64, now time.Time) {
+	since := installment.DueDate
+	if installment.LateInterestAccruedAt != nil {
+		since = *installment.LateInterestAccruedAt
+	} else {
+		installment.LateInterest = 0
+	}
+	days := math.Floor(now.Sub(since).Hours() / 24)
+	if days < 1 {
+		if installment.LateInterestAccruedAt == nil {
+			installment.LateInterestAccruedAt = &since
+		}
+		return
+	}
+	unpaid := math.Max(installment.Principal+installment.Interest-installment.Paid, 0)
+	installment.LateInterest = roundMoney(installment.LateInterest + unpaid*monthlyRate/100/30*days)
+	accruedAt := since.Add(time.Duration(days) * 24 * time.Hour)
+	installment.LateInterestAccruedAt = &accruedAt
+}
+
+// applyLoanRepayment pays amount off the loan's installments, oldest first.
+// It fails without changing the loan if amount is more than is
+// outstanding.
+func applyLoanRepayment(loan *models.Loan, amount float64, now time.Time) error {
+	refreshLoan(loan, now)
+	if roundMoney(amount) > loan.Outstanding {
+		return fmt.Errorf("repayment exceeds outstanding balance of %.2f", loan.Outstanding)
+	}
+	loan.AmountRepaid = roundMoney(loan.AmountRepaid + amount)
+	remaining := amount
+	for i := range loan.Schedule {
+		installment := &loan.Schedule[i]
+		if installment.Status == models.InstallmentPaid || remaining <= 0 {
+			continue
+		}
+		due := installment.Principal + installment.Interest + installment.LateInterest - installment.Paid
+		payment := math.Min(due, remaining)
+		installment.Paid = roundMoney(installment.Paid + payment)
+		remaining -= payment
+		if due-payment < 0.01 {
+			installment.Status = models.InstallmentPaid
+			installment.PaidAt = &now
+		}
+	}
+	refreshLoan(loan, now)
+	return nil
+}
+
+func overdueAmount(loan *models.Loan, now time.Time) float64 {
+	var total float64
+	for _, installment := range loan.Schedule {
+		if installment.Status == models.InstallmentOverdue {
+			total += installment.Principal + installment.Interest + installment.LateInterest - installment.Paid
+		}
+	}
+	return roundMoney(total)
+}
+
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildLoanSchedule(t *testing.T) {
+	disbursed := time.Date(2025, time.January, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		principal     float64
+		rate          float64
+		installments  int
+		wantPrincipal []float64
+		wantInterest  []float64
+	}{
+		{
+			name:          "splits evenly",
+			principal:     30000,
+			rate:          2,
+			installments:  3,
+			wantPrincipal: []float64{10000, 10000, 10000},
+			wantInterest:  []float64{600, 600, 600},
+		},
+		{
+			name:          "puts the rounding remainder on the last installment",
+			principal:     10000,
+			rate:          1.5,
+			installments:  3,
+			wantPrincipal: []float64{3333.33, 3333.33, 3333.34},
+			wantInterest:  []float64{150, 150, 150},
+		},
+		{
+			name:          "interest free",
+			principal:     5000,
+			rate:          0,
+			installments:  2,
+			wantPrincipal: []float64{2500, 2500},
+			wantInterest:  []float64{0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := buildLoanSchedule(tt.principal, tt.rate, tt.installments, disbursed)
+			assert.Len(t, schedule, tt.installments)
+			var principal float64
+			for i, installment := range schedule {
+				assert.Equal(t, i+1, installment.Number)
+				assert.Equal(t, disbursed.AddDate(0, i+1, 0), installment.DueDate)
+				assert.Equal(t, tt.wantPrincipal[i], installment.Principal)
+				assert.Equal(t, tt.wantInterest[i], installment.Interest)
+				assert.Equal(t, models.InstallmentPending, installment.Status)
+				principal += installment.Principal
+			}
+			assert.Equal(t, tt.principal, roundMoney(principal))
+		})
+	}
+}
+
+func newTestLoan(disbursed time.Time) *models.Loan {
+	// 30,000 over 3 months at 3% a month: 10,900 due each month
+	return &models.Loan{
+		Principal:        30000,
+		InterestRate:     3,
+		InstallmentCount: 3,
+		Schedule:         buildLoanSchedule(30000, 3, 3, disbursed),
+		Status:           models.LoanActive,
+	}
+}
+
+func TestRefreshLoanLateInterest(t *testing.T) {
+	disbursed := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	due := disbursed.AddDate(0, 1, 0)
+
+	tests := []struct {
+		name             string
+		now              time.Time
+		wantOverdue      bool
+		wantLateInterest float64
+		wantOutstanding  float64
+	}{
+		{"before the first due date", due.Add(-time.Hour), false, 0, 32700},
+		{"on the due date", due, false, 0, 32700},
+		{"less than a day late", due.Add(23 * time.Hour), true, 0, 32700},
+		{"ten days late", due.AddDate(0, 0, 10), true, 109, 32809},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loan := newTestLoan(disbursed)
+			refreshLoan(loan, tt.now)
+			assert.Equal(t, tt.wantOverdue, loan.Overdue)
+			assert.Equal(t, tt.wantLateInterest, loan.Schedule[0].LateInterest)
+			assert.Equal(t, tt.wantOutstanding, loan.Outstanding)
+		})
+	}
+}
+
+func TestRefreshLoanIsRepeatable(t *testing.T) {
+	disbursed := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	now := disbursed.AddDate(0, 1, 10)
+
+	loan := newTestLoan(disbursed)
+	refreshLoan(loan, now)
+	refreshLoan(loan, now)
+	refreshLoan(loan, now.Add(time.Hour))
+	assert.Equal(t, 109.0, loan.Schedule[0].LateInterest)
+}
+
+func TestPartialRepaymentKeepsAccruedLateInterest(t *testing.T) {
+	disbursed := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	due := disbursed.AddDate(0, 1, 0)
+	loan := newTestLoan(disbursed)
+
+	// Ten days late: 10,900 * 3% / 30 * 10 = 109
+	assert.NoError(t, applyLoanRepayment(loan, 5000, due.AddDate(0, 0, 10)))
+	assert.Equal(t, 109.0, loan.Schedule[0].LateInterest)
+	assert.Equal(t, 5000.0, loan.Schedule[0].Paid)
+
+	// Ten more days on the 5,900 still unpaid: 59 more
+	refreshLoan(loan, due.AddDate(0, 0, 20))
+	assert.Equal(t, 168.0, loan.Schedule[0].LateInterest)
+}
+
+func TestApplyLoanRepayment(t *testing.T) {
+	disbursed := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	now := disbursed.AddDate(0, 0, 5)
+
+	t.Run("pays installments oldest first", func(t *testing.T) {
+		loan := newTestLoan(disbursed)
+		assert.NoError(t, applyLoanRepayment(loan, 15000, now))
+		assert.Equal(t, models.InstallmentPaid, loan.Schedule[0].Status)
+		assert.Equal(t, 4100.0, loan.Schedule[1].Paid)
+		assert.Equal(t, 15000.0, loan.AmountRepaid)
+		assert.Equal(t, 17700.0, loan.Outstanding)
+		assert.Equal(t, models.LoanActive, loan.Status)
+	})
+
+	t.Run("paying everything repays the loan", func(t *testing.T) {
+		loan := newTestLoan(disbursed)
+		assert.NoError(t, applyLoanRepayment(loan, 32700, now))
+		assert.Equal(t, 0.0, loan.Outstanding)
+		assert.Equal(t, models.LoanRepaid, loan.Status)
+	})
+
+	t.Run("rejects overpayment", func(t *testing.T) {
+		loan := newTestLoan(disbursed)
+		err := applyLoanRepayment(loan, 40000, now)
+		assert.EqualError(t, err, "repayment exceeds outstanding balance of 32700.00")
+		assert.Equal(t, 0.0, loan.AmountRepaid)
+		assert.Equal(t, 0.0, loan.Schedule[0].Paid)
+	})
+}
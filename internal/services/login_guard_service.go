@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// loginFreeAttempts is how many wrong passwords an email gets before
+	// lockouts start.
+	loginFreeAttempts  = 5
+	loginBaseLockout   = time.Minute
+	loginMaxLockout    = time.Hour
+	loginFailureWindow = 24 * time.Hour // failures older than this are forgotten
+)
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// checkLoginLockout fails while the email is locked out.
+func checkLoginLockout(ctx context.Context, db *mongo.Database, email string) error {
+	attempts, err := repository.GetLoginAttempts(ctx, db, loginKey(email))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil
+		}
+		return err
+	}
+	if attempts.LockedUntil != nil && time.Now().Before(*attempts.LockedUntil) {
+		return fmt.Errorf("login locked, try again in %s", time.Until(*attempts.LockedUntil).Round(time.Second))
+	}
+	return nil
+}
+
+// recordLoginFailure counts a wrong password. After loginFreeAttempts each
+// further failure locks the email for twice as long as the last, up to
+// loginMaxLockout, and records an audit event.
+func recordLoginFailure(ctx context.Context, db *mongo.Database, email, ip string, user *models.User) {
+	key := loginKey(email)
+	if existing, err := repository.GetLoginAttempts(ctx, db, key); err == nil && time.Since(existing.LastFailedAt) > loginFailureWindow {
+		if err := repository.ClearLoginAttempts(ctx, db, key); err != nil {
+			log.Printf("Failed to clear login attempts: %v", err)
+		}
+	}
+
+	attempts, err := repository.RecordLoginFailure(ctx, db, key, ip)
+	if err != nil {
+		log.Printf("Failed to record login failure: %v", err)
+		return
+	}
+	if attempts.FailedAttempts < loginFreeAttempts {
+		return
+	}
+
+	lockout := time.Duration(float64(loginBaseLockout) * math.Pow(2, float64(attempts.FailedAttempts-loginFreeAttempts)))
+	if lockout > loginMaxLockout || lockout <= 0 {
+		lockout = loginMaxLockout
+	}
+	until := time.Now().Add(lockout)
+	if err := repository.LockLogin(ctx, db, key, until); err != nil {
+		log.Printf("Failed to lock login: %v", err)
+		return
+	}
+
+	event := &models.AuditEvent{
+		Type:    models.AuditLoginLockout,
+		Subject: key,
+		IP:      ip,
+		Details: map[string]interface{}{
+			"failed_attempts": attempts.FailedAttempts,
+			"locked_until":    until,
+		},
+	}
+	if user != nil {
+		event.UserID = &user.ID
+	}
+	recordAuditEvent(ctx, db, event)
+}
+
+func clearLoginFailures(ctx context.Context, db *mongo.Database, email string) {
+	if err := repository.ClearLoginAttempts(ctx, db, loginKey(email)); err != nil {
+		log.Printf("Failed to clear login attempts: %v", err)
+	}
+}
+
+// compareDummyPassword spends as long as a real password check so that
+// unknown emails cannot be told apart by response time.
+func compareDummyPassword(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+	})
+	bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+func loginKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,317 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/messages"
+	"github.com/Gerard-007/ajor_app/pkg/totp"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	mfaIssuer           = "AjoR"
+	mfaChallengeTTL     = 5 * time.Minute
+	mfaMaxAttempts      = 5
+	recoveryCodeCount   = 10
+	recoveryCodeLength  = 10
+	recoveryCodeDivider = 5
+)
+
+// MFARequired reports whether policy forces the user to use two-factor
+// authentication. MFA_REQUIRED_FOR_ADMINS=true covers anyone with a
+// platform role, and MFA_GROUP_ADMIN_THRESHOLD covers group admins of any
+// contribution whose pot (amount times members) exceeds it.
+func MFARequired(ctx context.Context, db *mongo.Database, user *models.User) (bool, error) {
+	if len(user.EffectiveRoles()) > 0 && os.Getenv("MFA_REQUIRED_FOR_ADMINS") == "true" {
+		return true, nil
+	}
+	threshold, _ := strconv.ParseFloat(os.Getenv("MFA_GROUP_ADMIN_THRESHOLD"), 64)
+	if threshold <= 0 {
+		return false, nil
+	}
+	contributions, err := repository.GetContributionsByGroupAdmin(ctx, db, user.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, contribution := range contributions {
+		members := len(contribution.YetToCollectMembers) + len(contribution.AlreadyCollectedMembers)
+		if contribution.Amount*float64(members) > threshold {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// MFAEnabled reports whether the user has finished TOTP enrollment.
+func MFAEnabled(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) (bool, error) {
+	mfa, err := repository.GetMFA(ctx, db, userID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not set up") {
+			return false, nil
+		}
+		return false, err
+	}
+	return mfa.Enabled, nil
+}
+
+func GetMFAStatus(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) (*models.MFAStatus, error) {
+	user, err := repository.GetUserByID(db.Collection("users"), userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	required, err := MFARequired(ctx, db, user)
+	if err != nil {
+		return nil, err
+	}
+	status := &models.MFAStatus{Required: required}
+	mfa, err := repository.GetMFA(ctx, db, userID)
+	if err == nil && mfa.Enabled {
+		status.Enabled = true
+		status.RecoveryCodesRemaining = len(mfa.RecoveryCodeHashes)
+	}
+	return status, nil
+}
+
+// SetupMFA creates a TOTP secret for the user to add to their
+// authenticator app. 2FA is not on until EnableMFA confirms a code.
+func SetupMFA(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) (*models.MFAEnrollment, error) {
+	user, err := repository.GetUserByID(db.Collection("users"), userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if enabled, err := MFAEnabled(ctx, db, userID); err != nil {
+		return nil, err
+	} else if enabled {
+		return nil, errors.New("two-factor authentication already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.SaveMFASetup(ctx, db, userID, secret); err != nil {
+		return nil, err
+	}
+	return &models.MFAEnrollment{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(mfaIssuer, user.Email, secret),
+	}, nil
+}
+
+// EnableMFA turns 2FA on once the user enters a code from their app, and
+// returns recovery codes that are shown only this once. Every other
+// session is signed out since none of them passed a second factor.
+func EnableMFA(ctx context.Context, db *mongo.Database, userID, sessionID primitive.ObjectID, code string) ([]string, error) {
+	mfa, err := repository.GetMFA(ctx, db, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa.Enabled {
+		return nil, errors.New("two-factor authentication already enabled")
+	}
+	step, ok := totp.Validate(mfa.Secret, code, time.Now())
+	if !ok {
+		return nil, errors.New("invalid code")
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.EnableMFA(ctx, db, userID, step, hashes); err != nil {
+		return nil, err
+	}
+	if _, err := repository.RevokeUserSessions(ctx, db, userID, "two-factor enabled", &sessionID); err != nil {
+		log.Printf("Failed to revoke sessions for user %s: %v", userID.Hex(), err)
+	}
+
+	notification := &models.Notification{
+		UserID:   userID,
+		Template: messages.MFAEnabled,
+		Type:     models.NotificationInfo,
+		Category: models.CategorySecurity,
+	}
+	if err := Notify(ctx, db, notification); err != nil {
+		log.Printf("Failed to notify user %s: %v", userID.Hex(), err)
+	}
+	return codes, nil
+}
+
+// DisableMFA turns 2FA off after checking the password and a second
+// factor. Users the policy covers cannot turn it off.
+func DisableMFA(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, password, code string) error {
+	user, err := repository.GetUserByID(db.Collection("users"), userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	required, err := MFARequired(ctx, db, user)
+	if err != nil {
+		return err
+	}
+	if required {
+		return errors.New("two-factor authentication is required for your account")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return errors.New("current password is incorrect")
+	}
+	mfa, err := repository.GetMFA(ctx, db, userID)
+	if err != nil {
+		return err
+	}
+	if !mfa.Enabled {
+		return errors.New("two-factor authentication not enabled")
+	}
+	if err := verifySecondFactor(ctx, db, mfa, code); err != nil {
+		return err
+	}
+	if err := repository.DeleteMFA(ctx, db, userID); err != nil {
+		return err
+	}
+
+	notification := &models.Notification{
+		UserID:   userID,
+		Template: messages.MFADisabled,
+		Type:     models.NotificationWarning,
+		Category: models.CategorySecurity,
+	}
+	return Notify(ctx, db, notification)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after
+// checking a code from their authenticator app.
+func RegenerateRecoveryCodes(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, code string) ([]string, error) {
+	mfa, err := repository.GetMFA(ctx, db, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !mfa.Enabled {
+		return nil, errors.New("two-factor authentication not enabled")
+	}
+	if err := verifyTOTP(ctx, db, mfa, code); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.SetRecoveryCodes(ctx, db, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// startMFAChallenge records a password login that still needs its second
+// factor and returns the token the client sends back with the code.
+func startMFAChallenge(ctx context.Context, db *mongo.Database, user *models.User, deviceName, ip string) (*models.LoginResult, error) {
+	token, err := newRefreshSecret()
+	if err != nil {
+		return nil, err
+	}
+	challenge := &models.MFAChallenge{
+		UserID:     user.ID,
+		TokenHash:  hashToken(token),
+		DeviceName: deviceName,
+		IP:         ip,
+		ExpiresAt:  time.Now().Add(mfaChallengeTTL),
+	}
+	if err := repository.CreateMFAChallenge(ctx, db, challenge); err != nil {
+		return nil, err
+	}
+	return &models.LoginResult{
+		MFARequired: true,
+		MFAToken:    token,
+		ExpiresIn:   int64(mfaChallengeTTL.Seconds()),
+	}, nil
+}
+
+// CompleteMFALogin finishes a login with a TOTP or recovery code and
+// starts the session.
+func CompleteMFALogin(ctx context.Context, db *mongo.Database, mfaToken, code string) (*models.AuthTokens, error) {
+	challenge, err := repository.GetMFAChallengeByTokenHash(ctx, db, hashToken(strings.TrimSpace(mfaToken)))
+	if err != nil {
+		return nil, errors.New("invalid or expired mfa token")
+	}
+	if challenge.UsedAt != nil || time.Now().After(challenge.ExpiresAt) || challenge.Attempts >= mfaMaxAttempts {
+		return nil, errors.New("invalid or expired mfa token")
+	}
+
+	mfa, err := repository.GetMFA(ctx, db, challenge.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySecondFactor(ctx, db, mfa, code); err != nil {
+		if err := repository.IncrementMFAChallengeAttempts(ctx, db, challenge.ID); err != nil {
+			return nil, err
+		}
+		return nil, err
+	}
+	if err := repository.UseMFAChallenge(ctx, db, challenge.ID); err != nil {
+		return nil, errors.New("invalid or expired mfa token")
+	}
+
+	user, err := repository.GetUserByID(db.Collection("users"), challenge.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	return startSession(ctx, db, user, challenge.DeviceName, challenge.IP)
+}
+
+// verifySecondFactor accepts either a TOTP code or an unused recovery code.
+func verifySecondFactor(ctx context.Context, db *mongo.Database, mfa *models.MFA, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		return verifyTOTP(ctx, db, mfa, code)
+	}
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	if err := repository.UseRecoveryCode(ctx, db, mfa.UserID, hashToken(normalized)); err != nil {
+		if strings.Contains(err.Error(), "invalid recovery code") {
+			return errors.New("invalid code")
+		}
+		return err
+	}
+	return nil
+}
+
+func verifyTOTP(ctx context.Context, db *mongo.Database, mfa *models.MFA, code string) error {
+	step, ok := totp.Validate(mfa.Secret, code, time.Now())
+	if !ok {
+		return errors.New("invalid code")
+	}
+	if err := repository.UseMFAStep(ctx, db, mfa.UserID, step); err != nil {
+		if strings.Contains(err.Error(), "already used") {
+			return errors.New("invalid code")
+		}
+		return err
+	}
+	return nil
+}
+
+// generateRecoveryCodes returns codes formatted as "xxxxx-xxxxx" and the
+// hashes to store for them.
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, recoveryCodeLength)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(encoding.EncodeToString(b))[:recoveryCodeLength]
+		codes = append(codes, raw[:recoveryCodeDivider]+"-"+raw[recoveryCodeDivider:])
+		hashes = append(hashes, hashToken(raw))
+	}
+	return codes, hashes, nil
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, hashes, err := generateRecoveryCodes()
+	assert.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+	assert.Len(t, hashes, recoveryCodeCount)
+
+	seen := map[string]bool{}
+	for i, code := range codes {
+		t.Run(code, func(t *testing.T) {
+			assert.Len(t, code, recoveryCodeLength+1)
+			assert.Equal(t, "-", code[recoveryCodeDivider:recoveryCodeDivider+1])
+			assert.Equal(t, strings.ToLower(code), code)
+			// The stored hash is of the code as verifySecondFactor normalises it
+			assert.Equal(t, hashToken(strings.ReplaceAll(code, "-", "")), hashes[i])
+			assert.False(t, seen[code], "codes must be unique")
+			seen[code] = true
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/notify"
+	"github.com/Gerard-007/ajor_app/pkg/schedule"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// defaultDigestTime is when digests go out for users who have not chosen a
+// time.
+const defaultDigestTime = "08:00"
+
+const clockLayout = "15:04"
+
+// defaultNotificationPreferences sends every category on every channel
+// straight away.
+func defaultNotificationPreferences(userID primitive.ObjectID) *models.NotificationPreferences {
+	preferences := &models.NotificationPreferences{
+		UserID:     userID,
+		Timezone:   schedule.DefaultTimezone,
+		DigestTime: defaultDigestTime,
+		Categories: map[models.NotificationCategory]models.CategoryPreference{},
+	}
+	fillDefaultCategories(preferences)
+	return preferences
+}
+
+// fillDefaultCategories gives every category the user has not set all
+// channels and no digest.
+func fillDefaultCategories(preferences *models.NotificationPreferences) {
+	if preferences.Categories == nil {
+		preferences.Categories = map[models.NotificationCategory]models.CategoryPreference{}
+	}
+	for _, category := range models.NotificationCategories {
+		if _, ok := preferences.Categories[category]; !ok {
+			preferences.Categories[category] = models.CategoryPreference{
+				Channels: []string{string(notify.ChannelSMS), string(notify.ChannelEmail), string(notify.ChannelPush)},
+			}
+		}
+	}
+}
+
+// GetNotificationPreferences returns the user's preferences, or the
+// defaults if they have never set any.
+func GetNotificationPreferences(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) (*models.NotificationPreferences, error) {
+	preferences, err := repository.GetNotificationPreferences(ctx, db, userID)
+	if err != nil {
+		if err.Error() == "notification preferences not found" {
+			return defaultNotificationPreferences(userID), nil
+		}
+		return nil, err
+	}
+	fillDefaultCategories(preferences)
+	return preferences, nil
+}
+
+// UpdateNotificationPreferences replaces the user's preferences. Categories
+// left out get the defaults; security alerts must keep at least one channel
+// and cannot be put in the digest.
+func UpdateNotificationPreferences(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, preferences *models.NotificationPreferences) (*models.NotificationPreferences, error) {
+	if preferences.Timezone == "" {
+		preferences.Timezone = schedule.DefaultTimezone
+	}
+	if _, err := schedule.LoadLocation(preferences.Timezone); err != nil {
+		return nil, errors.New("invalid timezone")
+	}
+	if preferences.QuietHours != nil {
+		start, err := time.Parse(clockLayout, preferences.QuietHours.Start)
+		if err != nil {
+			return nil, errors.New("invalid quiet hours: start must be HH:MM")
+		}
+		end, err := time.Parse(clockLayout, preferences.QuietHours.End)
+		if err != nil {
+			return nil, errors.New("invalid quiet hours: end must be HH:MM")
+		}
+		if start.Equal(end) {
+			return nil, errors.New("invalid quiet hours: start and end must differ")
+		}
+	}
+	if preferences.DigestTime == "" {
+		preferences.DigestTime = defaultDigestTime
+	}
+	if _, err := time.Parse(clockLayout, preferences.DigestTime); err != nil {
+		return nil, errors.New("invalid digest time: must be HH:MM")
+	}
+
+	for category, preference := range preferences.Categories {
+		if !isNotificationCategory(category) {
+			return nil, fmt.Errorf("invalid notification category: %s", category)
+		}
+		for _, channel := range preference.Channels {
+			switch notify.Channel(channel) {
+			case notify.ChannelSMS, notify.ChannelEmail, notify.ChannelPush:
+			default:
+				return nil, fmt.Errorf("invalid notification channel: %s", channel)
+			}
+		}
+		if category == models.CategorySecurity && (len(preference.Channels) == 0 || preference.Digest) {
+			return nil, errors.New("security alerts cannot be muted")
+		}
+	}
+	fillDefaultCategories(preferences)
+
+	preferences.UserID = userID
+	if err := repository.SaveNotificationPreferences(ctx, db, preferences); err != nil {
+		return nil, err
+	}
+	return preferences, nil
+}
+
+func isNotificationCategory(category models.NotificationCategory) bool {
+	for _, known := range models.NotificationCategories {
+		if category == known {
+			return true
+		}
+	}
+	return false
+}
+
+// notificationPreferencesFor is GetNotificationPreferences for the delivery
+// pipeline, which falls back to the defaults rather than fail.
+func notificationPreferencesFor(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) *models.NotificationPreferences {
+	preferences, err := GetNotificationPreferences(ctx, db, userID)
+	if err != nil {
+		log.Printf("Failed to get notification preferences of user %s, using defaults: %v", userID.Hex(), err)
+		return defaultNotificationPreferences(userID)
+	}
+	return preferences
+}
+
+// planDelivery decides whether a notification goes out on a channel and,
+// if so, when and whether with the digest. Notifications without a
+// category go out on every channel but still wait out quiet hours.
+func planDelivery(preferences *models.NotificationPreferences, category models.NotificationCategory, channel notify.Channel, now time.Time) (at time.Time, digest bool, ok bool) {
+	preference, known := preferences.Categories[category]
+	if known && !containsChannel(preference.Channels, channel) {
+		return time.Time{}, false, false
+	}
+	if category == models.CategorySecurity {
+		return now, false, true
+	}
+
+	loc, err := schedule.LoadLocation(preferences.Timezone)
+	if err != nil {
+		loc = schedule.DefaultLocation()
+	}
+	at = now
+	if preference.Digest {
+		at = nextClockTime(now.In(loc), preferences.DigestTime)
+		digest = true
+	}
+	return afterQuietHours(at.In(loc), preferences.QuietHours), digest, true
+}
+
+func containsChannel(channels []string, channel notify.Channel) bool {
+	for _, c := range channels {
+		if notify.Channel(c) == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// afterQuietHours moves t to the end of quiet hours if it falls within
+// them.
+func afterQuietHours(t time.Time, quietHours *models.QuietHours) time.Time {
+	if quietHours == nil {
+		return t
+	}
+	start, errStart := time.Parse(clockLayout, quietHours.Start)
+	end, errEnd := time.Parse(clockLayout, quietHours.End)
+	if errStart != nil || errEnd != nil {
+		return t
+	}
+	minute := t.Hour()*60 + t.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+
+	quiet := minute >= startMinute && minute < endMinute
+	if startMinute > endMinute {
+		quiet = minute >= startMinute || minute < endMinute
+	}
+	if !quiet {
+		return t
+	}
+	return nextClockTime(t, quietHours.End)
+}
+
+// nextClockTime is the first time after t, in t's location, that the clock
+// shows clock (HH:MM).
+func nextClockTime(t time.Time, clock string) time.Time {
+	parsed, err := time.Parse(clockLayout, clock)
+	if err != nil {
+		return t
+	}
+	next := time.Date(t.Year(), t.Month(), t.Day(), parsed.Hour(), parsed.Minute(), 0, 0, t.Location())
+	if !next.After(t) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
@@ -2,13 +2,414 @@ package services
 
 import (
 	"context"
+	"errors"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/Gerard-007/ajor_app/internal/models"
 	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/events"
+	"github.com/Gerard-007/ajor_app/pkg/messages"
+	"github.com/Gerard-007/ajor_app/pkg/notify"
+	"github.com/Gerard-007/ajor_app/pkg/sms"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func GetUserNotifications(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) ([]*models.Notification, error) {
-	return repository.GetUserNotifications(ctx, db, userID)
+const (
+	// maxDeliveryAttempts is how often a channel is tried before the
+	// delivery is marked failed.
+	maxDeliveryAttempts = 6
+	// deliveryRetryBase is the wait after the first failed attempt; it
+	// doubles with each further failure, up to deliveryRetryMax.
+	deliveryRetryBase = 30 * time.Second
+	deliveryRetryMax  = time.Hour
+	// deliveryLease holds a claimed delivery for one worker. It must be
+	// longer than deliverySendTimeout.
+	deliveryLease       = 2 * time.Minute
+	deliverySendTimeout = 30 * time.Second
+)
+
+var (
+	notificationSendersMu sync.RWMutex
+	notificationSenders   map[notify.Channel]notify.Sender
+)
+
+// SetNotificationSenders sets the channels notifications are delivered on
+// besides the inbox.
+func SetNotificationSenders(senders map[notify.Channel]notify.Sender) {
+	notificationSendersMu.Lock()
+	notificationSenders = senders
+	notificationSendersMu.Unlock()
+}
+
+func notificationSender(channel notify.Channel) (notify.Sender, bool) {
+	notificationSendersMu.RLock()
+	defer notificationSendersMu.RUnlock()
+	sender, ok := notificationSenders[channel]
+	return sender, ok
+}
+
+func notificationChannels() []notify.Channel {
+	notificationSendersMu.RLock()
+	defer notificationSendersMu.RUnlock()
+	channels := make([]notify.Channel, 0, len(notificationSenders))
+	for channel := range notificationSenders {
+		channels = append(channels, channel)
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i] < channels[j] })
+	return channels
+}
+
+// Notify puts the notification in the user's inbox and queues it for
+// delivery on the channels the user's preferences allow for its category,
+// held back for quiet hours or the digest as they ask. Delivery that is due
+// starts straight away in the background; the rest, and failed channels,
+// are picked up by the delivery job. Only
+// failing to store the notification and its deliveries is an error, so
+// callers do not fail after their own change has been made because a
+// channel is down.
+//
+// A notification with a Template has its Message rendered from the
+// catalogue in the user's locale.
+func Notify(ctx context.Context, db *mongo.Database, notification *models.Notification) error {
+	renderNotification(db, notification)
+	return createNotification(ctx, db, notification)
+}
+
+func renderNotification(db *mongo.Database, notification *models.Notification) {
+	if notification.Template != "" {
+		notification.Message = messages.Render(userLocale(db, notification.UserID), notification.Template, notification.Params)
+	}
+}
+
+// createNotification stores a rendered notification together with its
+// deliveries, so a notification is never stored without them. One given an
+// ID or Key that is already stored was stored by an earlier attempt, so it
+// is only announced again, which the broker drops as a repeat.
+func createNotification(ctx context.Context, db *mongo.Database, notification *models.Notification) error {
+	var deliveries []*models.NotificationDelivery
+	err := repository.WithTransaction(ctx, db, func(ctx context.Context) error {
+		if err := repository.CreateNotification(ctx, db, notification); err != nil {
+			return err
+		}
+		deliveries = planDeliveries(ctx, db, notification)
+		if len(deliveries) == 0 {
+			return nil
+		}
+		return repository.CreateNotificationDeliveries(ctx, db, deliveries)
+	})
+	if mongo.IsDuplicateKeyError(err) && (!notification.ID.IsZero() || notification.Key != "") {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+	eventKey := notification.Key
+	if eventKey == "" {
+		eventKey = notification.ID.Hex()
+	}
+	publishKeyedEvent(eventKey, events.NotificationCreated, []primitive.ObjectID{notification.UserID}, primitive.NilObjectID, notification)
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	notificationID := notification.ID
+	go func() {
+		if _, err := DeliverNotifications(context.Background(), db, &notificationID); err != nil {
+			log.Printf("Failed to deliver notification %s: %v", notificationID.Hex(), err)
+		}
+	}()
+	return nil
+}
+
+// planDeliveries returns the deliveries of a notification on the channels
+// the user's preferences allow for its category.
+func planDeliveries(ctx context.Context, db *mongo.Database, notification *models.Notification) []*models.NotificationDelivery {
+	channels := notificationChannels()
+	if len(channels) == 0 {
+		return nil
+	}
+	preferences := notificationPreferencesFor(ctx, db, notification.UserID)
+	deliveries := make([]*models.NotificationDelivery, 0, len(channels))
+	for _, channel := range channels {
+		at, digest, ok := planDelivery(preferences, notification.Category, channel, notification.CreatedAt)
+		if !ok {
+			continue
+		}
+		deliveries = append(deliveries, &models.NotificationDelivery{
+			NotificationID: notification.ID,
+			UserID:         notification.UserID,
+			Channel:        string(channel),
+			Status:         models.DeliveryPending,
+			Digest:         digest,
+			NextAttemptAt:  at,
+		})
+	}
+	return deliveries
+}
+
+// DeliverNotifications sends every delivery that is due, only those of one
+// notification if notificationID is set, and returns how many it handled.
+func DeliverNotifications(ctx context.Context, db *mongo.Database, notificationID *primitive.ObjectID) (int, error) {
+	handled := 0
+	for {
+		delivery, err := repository.ClaimDueDelivery(ctx, db, notificationID, time.Now(), deliveryLease)
+		if err != nil {
+			return handled, err
+		}
+		if delivery == nil {
+			return handled, nil
+		}
+		deliver(ctx, db, delivery)
+		handled++
+	}
+}
+
+// deliver makes one attempt at a claimed delivery and records the result.
+// A digest delivery takes every other digest delivery of the user on the
+// channel that is due with it, and they are sent as one message.
+func deliver(ctx context.Context, db *mongo.Database, delivery *models.NotificationDelivery) {
+	batch := []*models.NotificationDelivery{delivery}
+	if delivery.Digest {
+		for {
+			next, err := repository.ClaimDueDigestDelivery(ctx, db, delivery.UserID, delivery.Channel, time.Now(), deliveryLease)
+			if err != nil {
+				log.Printf("Failed to collect %s digest of user %s: %v", delivery.Channel, delivery.UserID.Hex(), err)
+				break
+			}
+			if next == nil {
+				break
+			}
+			batch = append(batch, next)
+		}
+	}
+
+	status, lastError := models.DeliverySent, ""
+	nextAttemptAt := time.Now()
+
+	if err := sendDeliveries(ctx, db, batch); err != nil {
+		lastError = err.Error()
+		switch {
+		case err == errNoRecipient:
+			status = models.DeliverySkipped
+		case delivery.Attempts >= maxDeliveryAttempts:
+			status = models.DeliveryFailed
+		default:
+			status = models.DeliveryPending
+			nextAttemptAt = nextAttemptAt.Add(deliveryBackoff(delivery.Attempts))
+		}
+	}
+	for _, delivery := range batch {
+		if err := repository.UpdateDeliveryStatus(ctx, db, delivery.ID, status, lastError, nextAttemptAt); err != nil {
+			log.Printf("Failed to record %s delivery %s: %v", delivery.Channel, delivery.ID.Hex(), err)
+		}
+	}
+}
+
+// deliveryBackoff is how long to wait after the given number of attempts.
+func deliveryBackoff(attempts int) time.Duration {
+	backoff := deliveryRetryBase
+	for i := 1; i < attempts && backoff < deliveryRetryMax; i++ {
+		backoff *= 2
+	}
+	if backoff > deliveryRetryMax {
+		backoff = deliveryRetryMax
+	}
+	return backoff
+}
+
+var errNoRecipient = errors.New("user has no address on this channel")
+
+// sendDeliveries sends a batch of deliveries to one user on one channel:
+// a single notification as it is, several as a digest.
+func sendDeliveries(ctx context.Context, db *mongo.Database, batch []*models.NotificationDelivery) error {
+	delivery := batch[0]
+	channel := notify.Channel(delivery.Channel)
+	sender, ok := notificationSender(channel)
+	if !ok {
+		return errors.New("channel not configured")
+	}
+	notifications := make([]*models.Notification, 0, len(batch))
+	for _, delivery := range batch {
+		notification, err := repository.GetNotificationByID(ctx, db, delivery.NotificationID)
+		if err != nil {
+			return err
+		}
+		notifications = append(notifications, notification)
+	}
+	user, err := repository.GetUserByID(db.Collection("users"), delivery.UserID)
+	if err != nil {
+		return err
+	}
+	if user.ClosedAt != nil {
+		return errNoRecipient
+	}
+
+	msg := notificationMessage(notifications, userLocale(db, user.ID))
+	switch channel {
+	case notify.ChannelSMS:
+		phone, err := sms.NormalizePhone(user.Phone)
+		if err != nil {
+			return errNoRecipient
+		}
+		msg.To = phone
+	case notify.ChannelEmail:
+		if user.Email == "" {
+			return errNoRecipient
+		}
+		msg.To = user.Email
+	case notify.ChannelPush:
+		msg.To = user.ID.Hex()
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, deliverySendTimeout)
+	defer cancel()
+	return sender.Send(sendCtx, msg)
+}
+
+func notificationMessage(notifications []*models.Notification, locale messages.Locale) notify.Message {
+	if len(notifications) > 1 {
+		lines := make([]string, len(notifications))
+		for i, notification := range notifications {
+			lines[i] = "- " + notification.Message
+		}
+		return notify.Message{
+			Subject: messages.Render(locale, messages.SubjectDigest, messages.Params{"Count": len(notifications)}),
+			Body:    strings.Join(lines, "\n"),
+			Data: map[string]string{
+				"digest": "true",
+				"count":  strconv.Itoa(len(notifications)),
+			},
+		}
+	}
+
+	notification := notifications[0]
+	msg := notify.Message{
+		Subject: notificationSubject(notification, locale),
+		Body:    notification.Message,
+		Data: map[string]string{
+			"notification_id": notification.ID.Hex(),
+			"type":            string(notification.Type),
+		},
+	}
+	if notification.Category != "" {
+		msg.Data["category"] = string(notification.Category)
+	}
+	if !notification.ContributionID.IsZero() {
+		msg.Data["contribution_id"] = notification.ContributionID.Hex()
+	}
+	return msg
+}
+
+// notificationSubject is the email subject and push title of a
+// notification.
+func notificationSubject(notification *models.Notification, locale messages.Locale) string {
+	switch notification.Type {
+	case models.NotificationWarning:
+		return messages.Render(locale, messages.SubjectWarning, nil)
+	case models.NotificationError:
+		return messages.Render(locale, messages.SubjectError, nil)
+	default:
+		return messages.Render(locale, messages.SubjectInfo, nil)
+	}
+}
+
+const (
+	defaultNotificationPageSize = 20
+	maxNotificationPageSize     = 100
+)
+
+// NotificationQuery filters and pages a user's inbox. Cursor is the
+// NextCursor of the previous page.
+type NotificationQuery struct {
+	Type           models.NotificationType
+	ContributionID *primitive.ObjectID
+	Read           *bool
+	Cursor         string
+	Limit          int
+}
+
+// GetUserNotifications returns a page of the user's notifications, newest
+// first.
+func GetUserNotifications(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, query NotificationQuery) (*models.NotificationPage, error) {
+	filter := notificationFilter(userID, query)
+	if query.Cursor != "" {
+		after, err := primitive.ObjectIDFromHex(query.Cursor)
+		if err != nil {
+			return nil, errors.New("invalid cursor")
+		}
+		filter["_id"] = bson.M{"$lt": after}
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultNotificationPageSize
+	}
+	if limit > maxNotificationPageSize {
+		limit = maxNotificationPageSize
+	}
+
+	// Fetch one more than the page to know whether another page follows.
+	notifications, err := repository.GetNotifications(ctx, db, filter, int64(limit+1))
+	if err != nil {
+		return nil, err
+	}
+	page := &models.NotificationPage{Notifications: notifications}
+	if len(notifications) > limit {
+		page.Notifications = notifications[:limit]
+		page.NextCursor = notifications[limit-1].ID.Hex()
+	}
+	return page, nil
+}
+
+func notificationFilter(userID primitive.ObjectID, query NotificationQuery) bson.M {
+	filter := bson.M{"user_id": userID}
+	if query.Type != "" {
+		filter["type"] = query.Type
+	}
+	if query.ContributionID != nil {
+		filter["contribution_id"] = *query.ContributionID
+	}
+	if query.Read != nil {
+		filter["read"] = *query.Read
+	}
+	return filter
+}
+
+func GetUnreadNotificationCount(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) (int64, error) {
+	return repository.CountNotifications(ctx, db, bson.M{"user_id": userID, "read": false})
+}
+
+func MarkNotificationRead(ctx context.Context, db *mongo.Database, notificationID, userID primitive.ObjectID) error {
+	return repository.MarkNotificationRead(ctx, db, notificationID, userID)
+}
+
+// MarkAllNotificationsRead marks every unread notification of the user
+// read, or only those matching the query's type and contribution, and
+// returns how many it marked.
+func MarkAllNotificationsRead(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, query NotificationQuery) (int64, error) {
+	query.Read = nil
+	return repository.MarkNotificationsRead(ctx, db, notificationFilter(userID, query))
+}
+
+func DeleteNotification(ctx context.Context, db *mongo.Database, notificationID, userID primitive.ObjectID) error {
+	return repository.DeleteNotification(ctx, db, notificationID, userID)
+}
+
+// GetNotificationDeliveries shows how a notification was delivered on each
+// channel, to the user it was for.
+func GetNotificationDeliveries(ctx context.Context, db *mongo.Database, notificationID, userID primitive.ObjectID) ([]*models.NotificationDelivery, error) {
+	notification, err := repository.GetNotificationByID(ctx, db, notificationID)
+	if err != nil {
+		return nil, err
+	}
+	if notification.UserID != userID {
+		return nil, errors.New("notification not found")
+	}
+	return repository.GetNotificationDeliveries(ctx, db, notificationID)
 }
\ No newline at end of file
@@ -0,0 +1,329 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/messages"
+	"github.com/Gerard-007/ajor_app/pkg/payment"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CreateOrganisation creates an organisation owned by the user, with a
+// wallet and virtual account it funds payroll contributions through.
+func CreateOrganisation(ctx context.Context, db *mongo.Database, pg payment.PaymentGateway, ownerID primitive.ObjectID, name string) (*models.Organisation, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	owner, err := repository.GetUserByID(db.Collection("users"), ownerID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	staffCode, err := newStaffCode()
+	if err != nil {
+		return nil, err
+	}
+
+	wallet := &models.Wallet{
+		ID:      primitive.NewObjectID(),
+		OwnerID: ownerID,
+		Type:    models.WalletTypeOrganisation,
+		Balance: 0.0,
+	}
+	if err := repository.CreateWallet(db, wallet); err != nil {
+		return nil, fmt.Errorf("failed to create wallet: %w", err)
+	}
+	narration := fmt.Sprintf("Organisation %s", name)
+	va, err := pg.CreateVirtualAccount(ctx, ownerID, owner.Email, owner.Phone, narration, true, owner.BVN, 0.0)
+	if err != nil {
+		repository.DeleteWallet(db, wallet.ID)
+		return nil, fmt.Errorf("failed to create virtual account: %v", err)
+	}
+	if err := repository.UpdateWalletVirtualAccount(db, wallet.ID, va.AccountNumber, va.AccountID, va.BankName); err != nil {
+		repository.DeleteWallet(db, wallet.ID)
+		return nil, fmt.Errorf("failed to update wallet with virtual account: %w", err)
+	}
+
+	organisation := &models.Organisation{
+		Name:      name,
+		OwnerID:   ownerID,
+		StaffCode: staffCode,
+		WalletID:  wallet.ID,
+	}
+	if err := repository.CreateOrganisation(ctx, db, organisation); err != nil {
+		repository.DeleteWallet(db, wallet.ID)
+		return nil, err
+	}
+	return organisation, nil
+}
+
+// newStaffCode returns the code staff join an organisation with.
+func newStaffCode() (string, error) {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return strings.ToUpper(hex.EncodeToString(b)), nil
+}
+
+// getOwnedOrganisation returns the organisation if the user owns it.
+func getOwnedOrganisation(ctx context.Context, db *mongo.Database, organisationID, userID primitive.ObjectID) (*models.Organisation, error) {
+	organisation, err := repository.GetOrganisationByID(ctx, db, organisationID)
+	if err != nil {
+		return nil, err
+	}
+	if organisation.OwnerID != userID {
+		return nil, errors.New("only the organisation owner can do this")
+	}
+	return organisation, nil
+}
+
+func GetOrganisation(ctx context.Context, db *mongo.Database, organisationID, userID primitive.ObjectID) (*models.Organisation, error) {
+	return getOwnedOrganisation(ctx, db, organisationID, userID)
+}
+
+func GetUserOrganisations(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) ([]*models.Organisation, error) {
+	return repository.GetOrganisationsByOwner(ctx, db, userID)
+}
+
+// JoinOrganisationStaff adds the user to the staff of the organisation
+// with the code, allowing it to enrol them into its contributions and pay
+// for them.
+func JoinOrganisationStaff(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, staffCode string) (*models.Organisation, error) {
+	organisation, err := repository.GetOrganisationByStaffCode(ctx, db, strings.ToUpper(strings.TrimSpace(staffCode)))
+	if err != nil {
+		return nil, errors.New("invalid staff code")
+	}
+	if containsUser(organisation.Staff, userID) {
+		return nil, errors.New("user already in organisation staff")
+	}
+	if err := repository.AddOrganisationStaff(ctx, db, organisation.ID, userID); err != nil {
+		return nil, err
+	}
+
+	notification := &models.Notification{
+		UserID:   organisation.OwnerID,
+		Template: messages.OrganisationStaffJoined,
+		Params:   messages.Params{"Organisation": organisation.Name},
+		Type:     models.NotificationInfo,
+		Category: models.CategoryMembership,
+	}
+	if err := Notify(ctx, db, notification); err != nil {
+		log.Printf("Failed to notify owner of organisation %s: %v", organisation.ID.Hex(), err)
+	}
+	return organisation, nil
+}
+
+// RemoveOrganisationStaff removes a user from the staff, either because
+// they left or because the owner removed them. Contributions they were
+// enrolled in are not affected.
+func RemoveOrganisationStaff(ctx context.Context, db *mongo.Database, organisationID, userID, actorID primitive.ObjectID) error {
+	organisation, err := repository.GetOrganisationByID(ctx, db, organisationID)
+	if err != nil {
+		return err
+	}
+	if actorID != userID && organisation.OwnerID != actorID {
+		return errors.New("only the organisation owner can remove staff")
+	}
+	if !containsUser(organisation.Staff, userID) {
+		return errors.New("user not in organisation staff")
+	}
+	return repository.RemoveOrganisationStaff(ctx, db, organisationID, userID)
+}
+
+// LinkOrganisationContribution lets an organisation enrol and pay for
+// members of a contribution. The owner must be the contribution's group
+// admin.
+func LinkOrganisationContribution(ctx context.Context, db *mongo.Database, organisationID, contributionID, userID primitive.ObjectID) error {
+	organisation, err := getOwnedOrganisation(ctx, db, organisationID, userID)
+	if err != nil {
+		return err
+	}
+	contribution, err := repository.GetContributionByID(ctx, db, contributionID)
+	if err != nil {
+		return err
+	}
+	if contribution.GroupAdmin != userID {
+		return errors.New("only group admin can link a contribution to an organisation")
+	}
+	if contribution.OrganisationID != nil && *contribution.OrganisationID != organisation.ID {
+		return errors.New("contribution already linked to another organisation")
+	}
+	return repository.SetContributionOrganisation(ctx, db, contributionID, organisation.ID)
+}
+
+// GetOrganisationStaff lists the organisation's staff.
+func GetOrganisationStaff(ctx context.Context, db *mongo.Database, organisationID primitive.ObjectID) ([]models.StaffMember, error) {
+	organisation, err := repository.GetOrganisationByID(ctx, db, organisationID)
+	if err != nil {
+		return nil, err
+	}
+	staff := []models.StaffMember{}
+	for _, userID := range organisation.Staff {
+		user, err := repository.GetUserByID(db.Collection("users"), userID)
+		if err != nil {
+			continue
+		}
+		staff = append(staff, models.StaffMember{
+			UserID:   user.ID,
+			Username: user.Username,
+			Verified: user.Verified,
+			KYCTier:  user.EffectiveKYCTier(),
+		})
+	}
+	return staff, nil
+}
+
+func GetOrganisationContributions(ctx context.Context, db *mongo.Database, organisationID primitive.ObjectID) ([]*models.Contribution, error) {
+	return repository.GetContributionsByOrganisation(ctx, db, organisationID)
+}
+
+func GetOrganisationWallet(ctx context.Context, db *mongo.Database, organisationID primitive.ObjectID) (*models.Wallet, error) {
+	organisation, err := repository.GetOrganisationByID(ctx, db, organisationID)
+	if err != nil {
+		return nil, err
+	}
+	return repository.GetWalletByID(db, organisation.WalletID)
+}
+
+// getOrganisationContribution returns the contribution and the staff
+// member, checking the organisation may act for them in it.
+func getOrganisationContribution(ctx context.Context, db *mongo.Database, organisationID, contributionID, userID primitive.ObjectID) (*models.Organisation, *models.Contribution, *models.User, error) {
+	organisation, err := repository.GetOrganisationByID(ctx, db, organisationID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if !containsUser(organisation.Staff, userID) {
+		return nil, nil, nil, errors.New("user not in organisation staff")
+	}
+	contribution, err := repository.GetContributionByID(ctx, db, contributionID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if contribution.OrganisationID == nil || *contribution.OrganisationID != organisationID {
+		return nil, nil, nil, errors.New("contribution not linked to organisation")
+	}
+	user, err := repository.GetUserByID(db.Collection("users"), userID)
+	if err != nil {
+		return nil, nil, nil, errors.New("user not found")
+	}
+	if !user.Verified {
+		return nil, nil, nil, errors.New("phone number not verified")
+	}
+	return organisation, contribution, user, nil
+}
+
+// EnrolStaff adds a staff member to one of the organisation's
+// contributions, as if they had joined with the invite code. pending is
+// true while guarantors still have to accept.
+func EnrolStaff(ctx context.Context, db *mongo.Database, organisationID, contributionID, userID primitive.ObjectID, guarantorIDs []primitive.ObjectID) (bool, error) {
+	_, contribution, _, err := getOrganisationContribution(ctx, db, organisationID, contributionID, userID)
+	if err != nil {
+		return false, err
+	}
+	return JoinContribution(ctx, db, contributionID, userID, contribution.InviteCode, guarantorIDs)
+}
+
+// PayStaffContribution pays a staff member's contribution from the
+// organisation's wallet. The money is paid into the member's wallet and
+// contributed from there, so the contribution counts as theirs. The
+// reference identifies the payment on the organisation's side, e.g. a
+// payroll line, and a reference can only be paid once.
+func PayStaffContribution(ctx context.Context, db *mongo.Database, organisationID, contributionID, userID primitive.ObjectID, reference string) (*models.Transaction, error) {
+	reference = strings.TrimSpace(reference)
+	if reference == "" {
+		return nil, errors.New("reference is required")
+	}
+	organisation, contribution, user, err := getOrganisationContribution(ctx, db, organisationID, contributionID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !containsUser(contribution.YetToCollectMembers, userID) && !containsUser(contribution.AlreadyCollectedMembers, userID) {
+		return nil, errors.New("user not in contribution")
+	}
+	amount := contribution.Amount
+
+	organisationWallet, err := repository.GetWalletByID(db, organisation.WalletID)
+	if err != nil {
+		return nil, errors.New("organisation wallet not found")
+	}
+	userWallet, err := repository.GetWalletByUserID(db, userID)
+	if err != nil {
+		return nil, errors.New("user wallet not found")
+	}
+	if organisationWallet.Balance < amount {
+		return nil, errors.New("insufficient organisation balance")
+	}
+	if err := checkInflowLimit(ctx, db, user, userWallet, amount); err != nil {
+		return nil, err
+	}
+
+	// The transfer, the contribution it pays for and the notices of both
+	// are committed together. The transfer is recorded first so that a
+	// reference already in use is rejected before anything else is done.
+	transfer := &models.Transaction{
+		FromWallet:     organisationWallet.ID,
+		ToWallet:       userWallet.ID,
+		Amount:         amount,
+		Type:           models.TransactionWallet,
+		Date:           time.Now(),
+		PaymentMethod:  models.PaymentPayroll,
+		Status:         models.StatusSuccess,
+		ContributionID: contributionID,
+		Reference:      reference,
+	}
+	var contributed *models.Transaction
+	err = inTransaction(ctx, db, func(ctx context.Context) error {
+		if err := repository.CreateTransaction(ctx, db, transfer); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				return errors.New("payment reference already used")
+			}
+			return err
+		}
+		// Debited only if the balance still covers it, in case another
+		// payment has spent it since it was checked above
+		if err := repository.DebitWallet(ctx, db, organisationWallet.ID, amount); err != nil {
+			if err.Error() == "insufficient balance" {
+				return errors.New("insufficient organisation balance")
+			}
+			return err
+		}
+		if err := repository.UpdateWalletBalanceContext(ctx, db, userWallet.ID, amount, true); err != nil {
+			return err
+		}
+		if err := queueTransactionEvents(ctx, db, transfer); err != nil {
+			return err
+		}
+		var err error
+		contributed, err = recordContribution(ctx, db, contributionID, userID, amount, models.PaymentPayroll)
+		if err != nil {
+			return err
+		}
+		return queueNotification(ctx, db, &models.Notification{
+			UserID:         userID,
+			ContributionID: contributionID,
+			Template:       messages.OrganisationContributionPaid,
+			Params:         messages.Params{"Organisation": organisation.Name, "Amount": amount, "Group": contribution.Name},
+			Type:           models.NotificationInfo,
+			Category:       models.CategoryPayouts,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordPayment(ctx, db, userID, contributed.Late, contributed.Penalty > 0); err != nil {
+		log.Printf("Failed to update reliability score for user %s: %v", userID.Hex(), err)
+	}
+	return transfer, nil
+}
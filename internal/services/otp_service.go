@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/messages"
+	"github.com/Gerard-007/ajor_app/pkg/sms"
+	"github.com/Gerard-007/ajor_app/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	otpLength      = 6
+	otpTTL         = 10 * time.Minute
+	otpMaxAttempts = 5
+	otpCooldown    = time.Minute
+)
+
+// sendOTP generates a fresh code for the purpose, replacing any earlier one,
+// and texts it to the destination in the message messageKey names, in the
+// user's language. Codes can only be resent after the cooldown.
+func sendOTP(ctx context.Context, db *mongo.Database, provider sms.Provider, userID primitive.ObjectID, purpose models.OTPPurpose, destination, messageKey string) error {
+	existing, err := repository.GetOTP(ctx, db, userID, purpose)
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		return err
+	}
+	if existing != nil && existing.ConsumedAt == nil {
+		if wait := otpCooldown - time.Since(existing.SentAt); wait > 0 {
+			return fmt.Errorf("please wait %d seconds before requesting another code", int(wait.Seconds())+1)
+		}
+	}
+
+	code, err := generateOTPCode()
+	if err != nil {
+		return err
+	}
+	otp := &models.OTP{
+		UserID:      userID,
+		Purpose:     purpose,
+		Destination: utils.MaskPhone(destination),
+		CodeHash:    hashOTP(userID, purpose, code),
+		ExpiresAt:   time.Now().Add(otpTTL),
+		SentAt:      time.Now(),
+	}
+	if err := repository.SaveOTP(ctx, db, otp); err != nil {
+		return err
+	}
+	message := messages.Render(userLocale(db, userID), messageKey, messages.Params{"Code": code})
+	return provider.Send(ctx, destination, message)
+}
+
+// verifyOTP checks a code and consumes it on success. Each wrong guess
+// counts towards the attempt limit.
+func verifyOTP(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, purpose models.OTPPurpose, code string) error {
+	otp, err := repository.GetOTP(ctx, db, userID, purpose)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return errors.New("no code has been requested")
+		}
+		return err
+	}
+	if otp.ConsumedAt != nil {
+		return errors.New("code already used")
+	}
+	if time.Now().After(otp.ExpiresAt) {
+		return errors.New("code expired")
+	}
+	if otp.Attempts >= otpMaxAttempts {
+		return errors.New("too many attempts, request a new code")
+	}
+
+	expected := []byte(otp.CodeHash)
+	if !hmac.Equal(expected, []byte(hashOTP(userID, purpose, strings.TrimSpace(code)))) {
+		if err := repository.IncrementOTPAttempts(ctx, db, otp.ID); err != nil {
+			return err
+		}
+		return errors.New("invalid code")
+	}
+	return repository.ConsumeOTP(ctx, db, otp.ID)
+}
+
+// SendPhoneVerification texts the user a code confirming their phone number.
+func SendPhoneVerification(ctx context.Context, db *mongo.Database, provider sms.Provider, userID primitive.ObjectID) error {
+	user, err := repository.GetUserByID(db.Collection("users"), userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if user.Verified {
+		return errors.New("phone number already verified")
+	}
+	return sendOTP(ctx, db, provider, user.ID, models.OTPPhoneVerification, user.Phone, messages.OTPPhoneVerification)
+}
+
+// VerifyPhone marks the user verified once they enter the code sent to
+// their phone.
+func VerifyPhone(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, code string) error {
+	user, err := repository.GetUserByID(db.Collection("users"), userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if user.Verified {
+		return errors.New("phone number already verified")
+	}
+	if err := verifyOTP(ctx, db, userID, models.OTPPhoneVerification, code); err != nil {
+		return err
+	}
+	return repository.SetUserVerified(db, userID, true)
+}
+
+func generateOTPCode() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < otpLength; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", otpLength, n.Int64()), nil
+}
+
+// hashOTP binds a code to its user and purpose so a stored hash cannot be
+// replayed elsewhere.
+func hashOTP(userID primitive.ObjectID, purpose models.OTPPurpose, code string) string {
+	secret := os.Getenv("OTP_SECRET")
+	if secret == "" {
+		secret = os.Getenv("JWT_SECRET")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(userID.Hex() + ":" + string(purpose) + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
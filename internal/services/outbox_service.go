@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/events"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// maxOutboxAttempts is how often an entry is tried before it is marked
+	// failed. Retries wait as long as notification deliveries do.
+	maxOutboxAttempts = 10
+	// outboxLease holds a claimed entry for one relay. It must be longer
+	// than outboxPublishTimeout.
+	outboxLease          = 2 * time.Minute
+	outboxPublishTimeout = 30 * time.Second
+)
+
+// inTransaction runs fn in a database transaction and, once it has
+// committed, starts relaying the side effects fn queued in the outbox.
+// Called within a transaction, fn joins it and the outer one relays them.
+func inTransaction(ctx context.Context, db *mongo.Database, fn func(ctx context.Context) error) error {
+	nested := mongo.SessionFromContext(ctx) != nil
+	if err := repository.WithTransaction(ctx, db, fn); err != nil {
+		return err
+	}
+	if !nested {
+		relayOutboxSoon(db)
+	}
+	return nil
+}
+
+// queueOutbox adds entries to the outbox, within the transaction of ctx if
+// there is one. Entries queued outside a transaction are relayed straight
+// away.
+func queueOutbox(ctx context.Context, db *mongo.Database, entries ...*models.OutboxEntry) error {
+	if err := repository.CreateOutboxEntries(ctx, db, entries); err != nil {
+		return err
+	}
+	if mongo.SessionFromContext(ctx) == nil {
+		relayOutboxSoon(db)
+	}
+	return nil
+}
+
+// queueNotification queues a notification to be sent as by Notify. It is
+// rendered now and given its ID, so relaying it twice stores it once.
+func queueNotification(ctx context.Context, db *mongo.Database, notification *models.Notification) error {
+	renderNotification(db, notification)
+	notification.ID = primitive.NewObjectID()
+	return queueOutbox(ctx, db, &models.OutboxEntry{Kind: models.OutboxKindNotification, Notification: notification})
+}
+
+// queueEvent queues an event to be published as by publishEvent.
+func queueEvent(ctx context.Context, db *mongo.Database, eventType events.Type, userIDs []primitive.ObjectID, contributionID primitive.ObjectID, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return queueOutbox(ctx, db, &models.OutboxEntry{
+		Kind: models.OutboxKindEvent,
+		Event: &models.OutboxEvent{
+			Type:           string(eventType),
+			UserIDs:        userIDs,
+			ContributionID: contributionID,
+			Data:           string(raw),
+		},
+	})
+}
+
+// queueWebhook queues an event to be posted to the organisation's webhook.
+func queueWebhook(ctx context.Context, db *mongo.Database, organisationID primitive.ObjectID, eventType events.Type, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return queueOutbox(ctx, db, &models.OutboxEntry{
+		Kind: models.OutboxKindWebhook,
+		Webhook: &models.OutboxWebhook{
+			OrganisationID: organisationID,
+			Type:           string(eventType),
+			Data:           string(raw),
+		},
+	})
+}
+
+func relayOutboxSoon(db *mongo.Database) {
+	go func() {
+		if _, err := RelayOutbox(context.Background(), db); err != nil {
+			log.Printf("Failed to relay outbox: %v", err)
+		}
+	}()
+}
+
+// RelayOutbox publishes every outbox entry that is due, oldest first, and
+// returns how many it handled. Entries are published at least once: one
+// whose outcome could not be recorded is published again after its lease.
+func RelayOutbox(ctx context.Context, db *mongo.Database) (int, error) {
+	handled := 0
+	for {
+		entry, err := repository.ClaimDueOutboxEntry(ctx, db, time.Now(), outboxLease)
+		if err != nil {
+			return handled, err
+		}
+		if entry == nil {
+			return handled, nil
+		}
+		relayOutboxEntry(ctx, db, entry)
+		handled++
+	}
+}
+
+// relayOutboxEntry makes one attempt at a claimed entry and records the
+// result.
+func relayOutboxEntry(ctx context.Context, db *mongo.Database, entry *models.OutboxEntry) {
+	publishCtx, cancel := context.WithTimeout(ctx, outboxPublishTimeout)
+	err := publishOutboxEntry(publishCtx, db, entry)
+	cancel()
+
+	status, lastError := models.OutboxPublished, ""
+	nextAttemptAt := time.Now()
+	if err != nil {
+		lastError = err.Error()
+		if entry.Attempts >= maxOutboxAttempts {
+			status = models.OutboxFailed
+			log.Printf("Giving up on %s outbox entry %s: %v", entry.Kind, entry.ID.Hex(), err)
+		} else {
+			status = models.OutboxPending
+			nextAttemptAt = nextAttemptAt.Add(deliveryBackoff(entry.Attempts))
+		}
+	}
+	if err := repository.UpdateOutboxStatus(ctx, db, entry.ID, status, lastError, nextAttemptAt); err != nil {
+		log.Printf("Failed to record %s outbox entry %s: %v", entry.Kind, entry.ID.Hex(), err)
+	}
+}
+
+func publishOutboxEntry(ctx context.Context, db *mongo.Database, entry *models.OutboxEntry) error {
+	switch entry.Kind {
+	case models.OutboxKindNotification:
+		return createNotification(ctx, db, entry.Notification)
+	case models.OutboxKindEvent:
+		event := entry.Event
+		var data interface{}
+		if event.Data != "" {
+			data = json.RawMessage(event.Data)
+		}
+		publishKeyedEvent(entry.ID.Hex(), events.Type(event.Type), event.UserIDs, event.ContributionID, data)
+		return nil
+	case models.OutboxKindWebhook:
+		return sendWebhook(ctx, db, entry)
+	}
+	return fmt.Errorf("unknown outbox entry kind %q", entry.Kind)
+}
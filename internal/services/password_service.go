@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/messages"
+	"github.com/Gerard-007/ajor_app/pkg/sms"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	minPasswordLength     = 8
+	passwordResetTTL      = 30 * time.Minute
+	passwordResetCooldown = time.Minute
+)
+
+// ForgotPassword texts a single-use reset token to the phone number of the
+// account with this email. Unknown emails are ignored so callers cannot
+// probe which accounts exist.
+func ForgotPassword(ctx context.Context, db *mongo.Database, provider sms.Provider, email string) error {
+	user, err := repository.GetUserByEmail(db.Collection("users"), email)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		return err
+	}
+
+	if latest, err := repository.GetLatestPasswordReset(ctx, db, user.ID); err == nil && time.Since(latest.CreatedAt) < passwordResetCooldown {
+		return nil
+	}
+
+	token, err := newRefreshSecret()
+	if err != nil {
+		return err
+	}
+	reset := &models.PasswordReset{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	}
+	if err := repository.CreatePasswordReset(ctx, db, reset); err != nil {
+		return err
+	}
+
+	message := messages.Render(userLocale(db, user.ID), messages.PasswordResetToken, messages.Params{"Token": token})
+	return provider.Send(ctx, user.Phone, message)
+}
+
+// ResetPassword sets a new password using a reset token and signs the user
+// out everywhere.
+func ResetPassword(ctx context.Context, db *mongo.Database, token, newPassword string) error {
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
+	reset, err := repository.GetPasswordResetByTokenHash(ctx, db, hashToken(strings.TrimSpace(token)))
+	if err != nil {
+		return errors.New("invalid or expired reset token")
+	}
+	if reset.UsedAt != nil || time.Now().After(reset.ExpiresAt) {
+		return errors.New("invalid or expired reset token")
+	}
+	if err := repository.UsePasswordReset(ctx, db, reset.ID); err != nil {
+		return errors.New("invalid or expired reset token")
+	}
+	return setPassword(ctx, db, reset.UserID, newPassword, "password reset")
+}
+
+// ChangePassword replaces the password of a signed-in user. Every session,
+// including the current one, is revoked and the caller gets a new session.
+func ChangePassword(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, currentPassword, newPassword, deviceName, ip string) (*models.AuthTokens, error) {
+	user, err := repository.GetUserByID(db.Collection("users"), userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword)); err != nil {
+		return nil, errors.New("current password is incorrect")
+	}
+	if currentPassword == newPassword {
+		return nil, errors.New("new password must be different from the current password")
+	}
+	if err := validatePassword(newPassword); err != nil {
+		return nil, err
+	}
+	if err := setPassword(ctx, db, userID, newPassword, "password changed"); err != nil {
+		return nil, err
+	}
+	return startSession(ctx, db, user, deviceName, ip)
+}
+
+func setPassword(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, password, reason string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	if err := repository.UpdateUserPassword(db, userID, string(hashedPassword)); err != nil {
+		return err
+	}
+	if _, err := RevokeAllSessions(ctx, db, userID, reason); err != nil {
+		return err
+	}
+	if err := repository.ExpireUserPasswordResets(ctx, db, userID); err != nil {
+		log.Printf("Failed to expire password resets for user %s: %v", userID.Hex(), err)
+	}
+
+	notification := &models.Notification{
+		UserID:   userID,
+		Template: messages.PasswordChanged,
+		Type:     models.NotificationWarning,
+		Category: models.CategorySecurity,
+	}
+	return Notify(ctx, db, notification)
+}
+
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", minPasswordLength)
+	}
+	return nil
+}
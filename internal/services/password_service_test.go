@@ -0,0 +1,30 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{"empty", "", true},
+		{"one short of the minimum", "1234567", true},
+		{"exactly the minimum", "12345678", false},
+		{"long passphrase", "correct horse battery staple", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePassword(tt.password)
+			if tt.wantErr {
+				assert.EqualError(t, err, "password must be at least 8 characters")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/messages"
+	"github.com/Gerard-007/ajor_app/pkg/sms"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// pinFreeAttempts is how many wrong PINs are allowed before lockouts start.
+	pinFreeAttempts = 3
+	pinBaseLockout  = 30 * time.Second
+	pinMaxLockout   = 24 * time.Hour
+)
+
+// SetTransactionPIN sets the user's first PIN.
+func SetTransactionPIN(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, pin string) error {
+	if _, err := repository.GetTransactionPIN(ctx, db, userID); err == nil {
+		return errors.New("transaction PIN already set")
+	} else if !strings.Contains(err.Error(), "not set") {
+		return err
+	}
+	return savePIN(ctx, db, userID, pin)
+}
+
+// ChangeTransactionPIN replaces the PIN after checking the current one.
+// Wrong current PINs count towards the lockout.
+func ChangeTransactionPIN(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, currentPIN, newPIN string) error {
+	if err := VerifyTransactionPIN(ctx, db, userID, currentPIN); err != nil {
+		return err
+	}
+	if err := savePIN(ctx, db, userID, newPIN); err != nil {
+		return err
+	}
+	return notifyPINChanged(ctx, db, userID)
+}
+
+// RequestPINReset texts the user a code to confirm a PIN reset.
+func RequestPINReset(ctx context.Context, db *mongo.Database, provider sms.Provider, userID primitive.ObjectID) error {
+	user, err := repository.GetUserByID(db.Collection("users"), userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if !user.Verified {
+		return errors.New("phone number not verified")
+	}
+	return sendOTP(ctx, db, provider, user.ID, models.OTPPINReset, user.Phone, messages.OTPPINReset)
+}
+
+// ResetTransactionPIN sets a new PIN once the user has re-verified their
+// phone number, and lifts any lockout.
+func ResetTransactionPIN(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, code, newPIN string) error {
+	if err := validatePIN(newPIN); err != nil {
+		return err
+	}
+	if err := verifyOTP(ctx, db, userID, models.OTPPINReset, code); err != nil {
+		return err
+	}
+	if err := savePIN(ctx, db, userID, newPIN); err != nil {
+		return err
+	}
+	return notifyPINChanged(ctx, db, userID)
+}
+
+// VerifyTransactionPIN checks the PIN entered for a debit. After
+// pinFreeAttempts wrong PINs each further failure locks the PIN for twice
+// as long as the last, up to pinMaxLockout. Every attempt is counted, and
+// the lock taken, before the PIN is compared, so parallel guesses cannot
+// get past the limit; a correct PIN then clears them.
+func VerifyTransactionPIN(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, pin string) error {
+	stored, err := repository.CountPINAttempt(ctx, db, userID, time.Now())
+	if err != nil {
+		return err
+	}
+	if stored.LockedUntil != nil && time.Now().Before(*stored.LockedUntil) {
+		wait := time.Until(*stored.LockedUntil).Round(time.Second)
+		return fmt.Errorf("transaction PIN locked, try again in %s", wait)
+	}
+
+	attempts := stored.FailedAttempts
+	var lockedUntil *time.Time
+	if lockout := pinLockout(attempts); lockout > 0 {
+		until := time.Now().Add(lockout)
+		lockedUntil = &until
+		if err := repository.LockPIN(ctx, db, userID, until); err != nil {
+			return err
+		}
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(stored.PINHash), []byte(pin)); err != nil {
+		if lockedUntil != nil {
+			recordAuditEvent(ctx, db, &models.AuditEvent{
+				Type:    models.AuditPINLockout,
+				UserID:  &userID,
+				Details: map[string]interface{}{"failed_attempts": attempts, "locked_until": *lockedUntil},
+			})
+			return fmt.Errorf("incorrect transaction PIN, locked for %s", time.Until(*lockedUntil).Round(time.Second))
+		}
+		return fmt.Errorf("incorrect transaction PIN, %d attempts left before lockout", pinFreeAttempts-attempts)
+	}
+
+	return repository.ClearPINFailures(ctx, db, userID)
+}
+
+// pinLockout returns how long the PIN is locked after the given number of
+// failed attempts in a row.
+func pinLockout(attempts int) time.Duration {
+	if attempts < pinFreeAttempts {
+		return 0
+	}
+	lockout := time.Duration(float64(pinBaseLockout) * math.Pow(2, float64(attempts-pinFreeAttempts)))
+	if lockout > pinMaxLockout || lockout <= 0 {
+		lockout = pinMaxLockout
+	}
+	return lockout
+}
+
+func savePIN(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, pin string) error {
+	if err := validatePIN(pin); err != nil {
+		return err
+	}
+	hashedPIN, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return repository.SaveTransactionPIN(ctx, db, userID, string(hashedPIN))
+}
+
+func notifyPINChanged(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) error {
+	notification := &models.Notification{
+		UserID:   userID,
+		Template: messages.PINChanged,
+		Type:     models.NotificationWarning,
+		Category: models.CategorySecurity,
+	}
+	return Notify(ctx, db, notification)
+}
+
+func validatePIN(pin string) error {
+	if len(pin) < 4 || len(pin) > 6 {
+		return errors.New("transaction PIN must be 4 to 6 digits")
+	}
+	for _, r := range pin {
+		if r < '0' || r > '9' {
+			return errors.New("transaction PIN must be 4 to 6 digits")
+		}
+	}
+	return nil
+}
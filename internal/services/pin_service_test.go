@@ -0,0 +1,53 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPINLockout(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		want     time.Duration
+	}{
+		{"first failure", 1, 0},
+		{"last free failure", 2, 0},
+		{"first lockout", 3, 30 * time.Second},
+		{"doubles each time", 4, time.Minute},
+		{"keeps doubling", 6, 4 * time.Minute},
+		{"capped at a day", 15, 24 * time.Hour},
+		{"capped when the exponent overflows", 2000, 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, pinLockout(tt.attempts))
+		})
+	}
+}
+
+func TestValidatePIN(t *testing.T) {
+	tests := []struct {
+		name    string
+		pin     string
+		wantErr bool
+	}{
+		{"four digits", "4821", false},
+		{"six digits", "482193", false},
+		{"too short", "482", true},
+		{"too long", "4821937", true},
+		{"letters", "48a1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePIN(tt.pin)
+			if tt.wantErr {
+				assert.EqualError(t, err, "transaction PIN must be 4 to 6 digits")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -1,8 +1,11 @@
 package services
 
 import (
+	"errors"
+
 	"github.com/Gerard-007/ajor_app/internal/models"
 	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/messages"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -12,9 +15,22 @@ func GetUserProfile(db *mongo.Database, userID primitive.ObjectID) (*models.Prof
 }
 
 func UpdateUserProfile(db *mongo.Database, userID primitive.ObjectID, profileUpdate *models.Profile) error {
+	if profileUpdate.Locale != "" && !messages.IsSupported(messages.Locale(profileUpdate.Locale)) {
+		return errors.New("unsupported locale")
+	}
 	return repository.UpdateUserProfile(db.Collection("profiles"), userID, profileUpdate)
 }
 
+// userLocale is the locale the user's messages are rendered in: the one on
+// their profile, or the default if they have not chosen one.
+func userLocale(db *mongo.Database, userID primitive.ObjectID) messages.Locale {
+	profile, err := repository.GetUserProfile(db.Collection("profiles"), userID)
+	if err != nil || !messages.IsSupported(messages.Locale(profile.Locale)) {
+		return messages.DefaultLocale
+	}
+	return messages.Locale(profile.Locale)
+}
+
 func UpdateUserProfilePicture(db *mongo.Database, userID primitive.ObjectID, picturePath string) error {
 	return repository.UpdateUserProfilePicture(db.Collection("profiles"), userID, picturePath)
 }
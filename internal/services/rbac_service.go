@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// auditEventLimit caps how many audit events one request returns.
+const auditEventLimit = 200
+
+// HasPermission reports whether the user's platform roles grant the
+// permission.
+func HasPermission(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, permission models.Permission) (bool, error) {
+	user, err := repository.GetUserByID(db.Collection("users"), userID)
+	if err != nil {
+		return false, errors.New("user not found")
+	}
+	return user.HasPermission(permission), nil
+}
+
+// RequirePermission fails with "permission denied" unless the user's
+// platform roles grant the permission.
+func RequirePermission(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, permission models.Permission) error {
+	allowed, err := HasPermission(ctx, db, userID, permission)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return errors.New("permission denied")
+	}
+	return nil
+}
+
+// requireSelfOrPermission lets users act on their own account and admins
+// with the permission act on anyone's.
+func requireSelfOrPermission(ctx context.Context, db *mongo.Database, actorID, userID primitive.ObjectID, permission models.Permission) error {
+	if actorID == userID {
+		return nil
+	}
+	return RequirePermission(ctx, db, actorID, permission)
+}
+
+// AssignRoles replaces a user's platform roles. The last super admin cannot
+// lose the role, so the platform always has someone who can assign roles.
+func AssignRoles(ctx context.Context, db *mongo.Database, actorID, userID primitive.ObjectID, roles []models.Role) (*models.User, error) {
+	if err := RequirePermission(ctx, db, actorID, models.PermRolesAssign); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[models.Role]bool)
+	unique := make([]models.Role, 0, len(roles))
+	for _, role := range roles {
+		if _, ok := models.RolePermissions[role]; !ok {
+			return nil, fmt.Errorf("unknown role %q", role)
+		}
+		if !seen[role] {
+			seen[role] = true
+			unique = append(unique, role)
+		}
+	}
+
+	user, err := repository.GetUserByID(db.Collection("users"), userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	previous := user.EffectiveRoles()
+	if containsRole(previous, models.RoleSuperAdmin) && !seen[models.RoleSuperAdmin] {
+		count, err := repository.CountUsersWithRole(db, models.RoleSuperAdmin)
+		if err != nil {
+			return nil, err
+		}
+		if count <= 1 {
+			return nil, errors.New("cannot remove the last super admin")
+		}
+	}
+
+	updated, err := repository.SetUserRoles(db, userID, unique)
+	if err != nil {
+		return nil, err
+	}
+	recordAuditEvent(ctx, db, &models.AuditEvent{
+		Type:    models.AuditRolesChanged,
+		UserID:  &userID,
+		ActorID: &actorID,
+		Details: map[string]interface{}{"previous_roles": previous, "roles": unique},
+	})
+	if !canReadPII(ctx, db, actorID) {
+		maskUserPII(updated)
+	}
+	return updated, nil
+}
+
+func GetAuditEvents(ctx context.Context, db *mongo.Database, actorID primitive.ObjectID, eventType models.AuditEventType) ([]*models.AuditEvent, error) {
+	if err := RequirePermission(ctx, db, actorID, models.PermAuditRead); err != nil {
+		return nil, err
+	}
+	return repository.GetAuditEvents(ctx, db, eventType, auditEventLimit)
+}
+
+func containsRole(roles []models.Role, role models.Role) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// neutralReliabilityScore is given to users with no payment history yet.
+const neutralReliabilityScore = 50
+
+// GetReliabilityScore returns a user's score, building it from their
+// transaction history the first time it is asked for.
+func GetReliabilityScore(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) (*models.ReliabilityScore, error) {
+	score, err := repository.GetReliabilityScore(ctx, db, userID)
+	if err == nil {
+		return score, nil
+	}
+	if !strings.Contains(err.Error(), "not found") {
+		return nil, err
+	}
+	return rebuildReliabilityScore(ctx, db, userID)
+}
+
+// recordPayment counts a contribution payment towards the payer's score.
+func recordPayment(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, late, penalised bool) error {
+	counters := bson.M{"on_time_payments": 1}
+	if late {
+		counters = bson.M{"late_payments": 1}
+	}
+	if penalised {
+		counters["penalties"] = 1
+	}
+	return updateReliability(ctx, db, userID, counters)
+}
+
+// recordRotation counts a payout the user collected in full.
+func recordRotation(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) error {
+	return updateReliability(ctx, db, userID, bson.M{"completed_rotations": 1})
+}
+
+// recordDefault counts a missed obligation: a guarantee claim against the
+// user or a loan repayment that fell overdue.
+func recordDefault(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) error {
+	return updateReliability(ctx, db, userID, bson.M{"defaults": 1})
+}
+
+// updateReliability applies counter changes and recomputes the score. It must
+// be called after the event itself is saved, so that a user without a score
+// document yet is seeded from history that already includes it.
+func updateReliability(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, counters bson.M) error {
+	score, err := repository.IncrementReliability(ctx, db, userID, counters)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			_, err = rebuildReliabilityScore(ctx, db, userID)
+		}
+		return err
+	}
+	return repository.UpdateReliabilityScoreValue(ctx, db, userID, computeReliability(score))
+}
+
+// rebuildReliabilityScore recounts a user's history across all contributions.
+func rebuildReliabilityScore(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) (*models.ReliabilityScore, error) {
+	score := &models.ReliabilityScore{UserID: userID}
+
+	if wallet, err := repository.GetWalletByUserID(db, userID); err == nil {
+		payments, err := repository.GetTransactions(ctx, db, bson.M{
+			"from_wallet": wallet.ID,
+			"type":        models.TransactionContribution,
+			"status":      models.StatusSuccess,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, payment := range payments {
+			if payment.Late {
+				score.LatePayments++
+			} else {
+				score.OnTimePayments++
+			}
+			if payment.Penalty > 0 {
+				score.Penalties++
+			}
+		}
+
+		payouts, err := repository.GetTransactions(ctx, db, bson.M{
+			"to_wallet": wallet.ID,
+			"type":      models.TransactionPayout,
+			"status":    models.StatusSuccess,
+		})
+		if err != nil {
+			return nil, err
+		}
+		score.CompletedRotations = len(payouts)
+	}
+
+	claims, err := repository.GetGuarantees(ctx, db, bson.M{"member_id": userID, "status": models.GuaranteeClaimed})
+	if err != nil {
+		return nil, err
+	}
+	lateLoans, err := repository.GetLoans(ctx, db, bson.M{
+		"borrower_id": userID,
+		"$or": []bson.M{
+			{"overdue": true},
+			{"schedule.late_interest": bson.M{"$gt": 0}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	score.Defaults = len(claims) + len(lateLoans)
+
+	score.Score = computeReliability(score)
+	if err := repository.SaveReliabilityScore(ctx, db, score); err != nil {
+		return nil, err
+	}
+	return score, nil
+}
+
+// computeReliability weighs how promptly a user pays (up to 70 points) and
+// how many rotations they have completed (up to 30), then subtracts for
+// penalties and defaults.
+func computeReliability(score *models.ReliabilityScore) float64 {
+	payments := score.OnTimePayments + score.LatePayments
+	if payments+score.CompletedRotations+score.Defaults == 0 {
+		return neutralReliabilityScore
+	}
+
+	paymentRate := 1.0
+	if payments > 0 {
+		paymentRate = (float64(score.OnTimePayments) + 0.5*float64(score.LatePayments)) / float64(payments)
+	}
+	value := 70*paymentRate +
+		6*math.Min(float64(score.CompletedRotations), 5) -
+		2*float64(score.Penalties) -
+		15*float64(score.Defaults)
+	value = math.Max(0, math.Min(100, value))
+	return math.Round(value*10) / 10
+}
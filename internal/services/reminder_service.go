@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/messages"
+	"github.com/Gerard-007/ajor_app/pkg/schedule"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// defaultReminderOffsets are how long before a deadline unpaid members are
+// reminded.
+var defaultReminderOffsets = []time.Duration{72 * time.Hour, 24 * time.Hour, 2 * time.Hour}
+
+const defaultReminderEscalationDays = 3
+
+// reminderLookback is how long after a round could have been escalated the
+// job still looks at it, so a run missed while the job was down is caught
+// up without going back over old rounds.
+const reminderLookback = 24 * time.Hour
+
+// reminderOffsets reads REMINDER_OFFSETS, a comma separated list of
+// durations such as "3d,1d,2h", and returns them largest first.
+func reminderOffsets() []time.Duration {
+	raw := strings.TrimSpace(os.Getenv("REMINDER_OFFSETS"))
+	if raw == "" {
+		return defaultReminderOffsets
+	}
+	var offsets []time.Duration
+	for _, field := range strings.Split(raw, ",") {
+		offset, err := parseReminderOffset(strings.TrimSpace(field))
+		if err != nil || offset <= 0 {
+			log.Printf("Ignoring invalid reminder offset %q", field)
+			continue
+		}
+		offsets = append(offsets, offset)
+	}
+	if len(offsets) == 0 {
+		return defaultReminderOffsets
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] > offsets[j] })
+	return offsets
+}
+
+// parseReminderOffset parses a Go duration, or a whole number of days such
+// as "3d".
+func parseReminderOffset(offset string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(offset, "d"); ok {
+		n, err := strconv.Atoi(days)
+		return time.Duration(n) * 24 * time.Hour, err
+	}
+	return time.ParseDuration(offset)
+}
+
+func formatReminderOffset(offset time.Duration) string {
+	if offset%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", offset/(24*time.Hour))
+	}
+	return offset.String()
+}
+
+// reminderEscalationDelay is how long after a deadline an unpaid member is
+// reported to the group admin.
+func reminderEscalationDelay() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("REMINDER_ESCALATION_DAYS"))
+	if err != nil || days <= 0 {
+		days = defaultReminderEscalationDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// dueReminder is a reminder about one round that may be sent now.
+type dueReminder struct {
+	deadline time.Time
+	kind     models.ReminderKind
+	offset   string
+}
+
+func reminderKey(memberID primitive.ObjectID, deadline time.Time, kind models.ReminderKind, offset string) string {
+	return fmt.Sprintf("%s:%d:%s:%s", memberID.Hex(), deadline.Unix(), kind, offset)
+}
+
+// dueReminders lists the reminders that may be sent at now: the nearest
+// offset already reached before the next deadline, and the overdue notice
+// and escalation of rounds whose deadline has passed. Only the nearest
+// offset is sent so that members are not sent several reminders at once
+// after the job has been down.
+func dueReminders(recurrence schedule.Recurrence, now time.Time, offsets []time.Duration, escalateAfter time.Duration) []dueReminder {
+	var due []dueReminder
+	next := recurrence.Next(now)
+	for i := len(offsets) - 1; i >= 0; i-- {
+		if !now.Before(next.Add(-offsets[i])) {
+			due = append(due, dueReminder{deadline: next, kind: models.ReminderBeforeDeadline, offset: formatReminderOffset(offsets[i])})
+			break
+		}
+	}
+
+	for deadline := recurrence.Next(now.Add(-escalateAfter - reminderLookback)); !deadline.After(now); deadline = recurrence.Next(deadline) {
+		due = append(due, dueReminder{deadline: deadline, kind: models.ReminderOverdue})
+		if !now.Before(deadline.Add(escalateAfter)) {
+			due = append(due, dueReminder{deadline: deadline, kind: models.ReminderEscalation})
+		}
+	}
+	return due
+}
+
+// SendContributionReminders reminds members who have not paid for a round
+// as its deadline approaches, tells them once it has passed, and reports
+// them to the group admin if they still have not paid after
+// REMINDER_ESCALATION_DAYS. Each reminder is recorded before it is sent, so
+// it goes out at most once per member per round however often this runs.
+// It returns how many reminders it sent.
+func SendContributionReminders(ctx context.Context, db *mongo.Database, now time.Time) (int, error) {
+	contributions, err := repository.GetAllContributions(db)
+	if err != nil {
+		return 0, err
+	}
+	offsets := reminderOffsets()
+	escalateAfter := reminderEscalationDelay()
+	total := 0
+	for _, contribution := range contributions {
+		sent, err := remindContribution(ctx, db, contribution, now, offsets, escalateAfter)
+		total += sent
+		if err != nil {
+			log.Printf("Failed to send reminders for contribution %s: %v", contribution.ID.Hex(), err)
+		}
+	}
+	return total, nil
+}
+
+func remindContribution(ctx context.Context, db *mongo.Database, contribution *models.Contribution, now time.Time, offsets []time.Duration, escalateAfter time.Duration) (int, error) {
+	members := append(append([]primitive.ObjectID{}, contribution.YetToCollectMembers...), contribution.AlreadyCollectedMembers...)
+	if len(members) == 0 {
+		return 0, nil
+	}
+	recurrence, err := contributionRecurrence(ctx, db, contribution)
+	if err != nil {
+		return 0, err
+	}
+	due := dueReminders(recurrence, now, offsets, escalateAfter)
+	if len(due) == 0 {
+		return 0, nil
+	}
+
+	deadlines := make([]time.Time, 0, len(due))
+	for _, reminder := range due {
+		deadlines = append(deadlines, reminder.deadline)
+	}
+	sentReminders, err := repository.GetRemindersForRounds(ctx, db, contribution.ID, deadlines)
+	if err != nil {
+		return 0, err
+	}
+	sent := make(map[string]bool, len(sentReminders))
+	for _, reminder := range sentReminders {
+		sent[reminderKey(reminder.MemberID, reminder.Deadline, reminder.Kind, reminder.Offset)] = true
+	}
+
+	count := 0
+	for _, memberID := range members {
+		var pending []dueReminder
+		for _, reminder := range due {
+			if reminder.kind == models.ReminderEscalation && memberID == contribution.GroupAdmin {
+				continue
+			}
+			if !sent[reminderKey(memberID, reminder.deadline, reminder.kind, reminder.offset)] {
+				pending = append(pending, reminder)
+			}
+		}
+		if len(pending) == 0 {
+			continue
+		}
+
+		since, err := arrearsSince(ctx, db, contribution, memberID)
+		if err != nil {
+			log.Printf("Failed to check arrears of user %s: %v", memberID.Hex(), err)
+			continue
+		}
+		arrears, err := memberArrears(ctx, db, contribution, memberID, since)
+		if err != nil {
+			log.Printf("Failed to check arrears of user %s: %v", memberID.Hex(), err)
+			continue
+		}
+		for _, reminder := range pending {
+			if roundPaid(recurrence, arrears, reminder.deadline) {
+				continue
+			}
+			sent, err := sendReminder(ctx, db, contribution, memberID, reminder, recurrence.Location, now)
+			if err != nil {
+				log.Printf("Failed to send %s reminder to user %s: %v", reminder.kind, memberID.Hex(), err)
+			}
+			if sent {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+// roundPaid reports whether the member has paid for every round up to and
+// including the one ending at deadline.
+func roundPaid(recurrence schedule.Recurrence, arrears *models.Arrears, deadline time.Time) bool {
+	rounds := 0
+	for d := recurrence.Next(arrears.Since); !d.After(deadline); d = recurrence.Next(d) {
+		rounds++
+	}
+	return arrears.CyclesPaid >= rounds
+}
+
+// sendReminder records and sends a reminder, reporting whether it was sent
+// rather than found to have been sent already.
+func sendReminder(ctx context.Context, db *mongo.Database, contribution *models.Contribution, memberID primitive.ObjectID, reminder dueReminder, loc *time.Location, now time.Time) (bool, error) {
+	notification := &models.Notification{
+		UserID:         memberID,
+		ContributionID: contribution.ID,
+		Category:       models.CategoryPaymentReminders,
+	}
+	params := messages.Params{
+		"Amount":   contribution.Amount,
+		"Group":    contribution.Name,
+		"Deadline": reminder.deadline.In(loc),
+	}
+	switch reminder.kind {
+	case models.ReminderBeforeDeadline:
+		notification.Template = messages.ContributionReminder
+		notification.Type = models.NotificationInfo
+	case models.ReminderOverdue:
+		notification.Template = messages.ContributionOverdue
+		notification.Type = models.NotificationWarning
+		params["Penalty"] = contribution.PenaltyAmount
+	case models.ReminderEscalation:
+		member, err := repository.GetUserByID(db.Collection("users"), memberID)
+		if err != nil {
+			return false, err
+		}
+		notification.UserID = contribution.GroupAdmin
+		notification.Template = messages.ContributionOverdueAdmin
+		notification.Type = models.NotificationWarning
+		params["Member"] = member.Username
+		params["Days"] = int(now.Sub(reminder.deadline) / (24 * time.Hour))
+	}
+	notification.Params = params
+
+	if err := repository.RecordReminder(ctx, db, &models.ContributionReminder{
+		ContributionID: contribution.ID,
+		MemberID:       memberID,
+		Deadline:       reminder.deadline,
+		Kind:           reminder.kind,
+		Offset:         reminder.offset,
+	}); err != nil {
+		if err.Error() == "reminder already sent" {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, Notify(ctx, db, notification)
+}
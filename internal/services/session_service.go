@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// refreshTokenTTL is how long a session lasts without being refreshed.
+const refreshTokenTTL = 30 * 24 * time.Hour
+
+// sessionTouchInterval limits how often authenticated requests write the
+// session's last-seen time.
+const sessionTouchInterval = time.Minute
+
+// startSession creates a session for a device that has just signed in and
+// issues its first token pair. An account being closed cannot sign in.
+func startSession(ctx context.Context, db *mongo.Database, user *models.User, deviceName, ip string) (*models.AuthTokens, error) {
+	if user.Closure != nil {
+		return nil, errors.New("account is being closed")
+	}
+	secret, err := newRefreshSecret()
+	if err != nil {
+		return nil, err
+	}
+	session := &models.Session{
+		UserID:           user.ID,
+		RefreshTokenHash: hashToken(secret),
+		DeviceName:       deviceName,
+		IP:               ip,
+		LastSeenAt:       time.Now(),
+		ExpiresAt:        time.Now().Add(refreshTokenTTL),
+	}
+	if err := repository.CreateSession(ctx, db, session); err != nil {
+		return nil, err
+	}
+	return issueTokens(user, session.ID, secret)
+}
+
+// RefreshSession exchanges a refresh token for a new token pair. A refresh
+// token that has already been rotated out means it leaked, so the whole
+// session is revoked. Any other unknown token is just rejected, so guessing
+// at a session ID cannot sign its device out.
+func RefreshSession(ctx context.Context, db *mongo.Database, refreshToken, ip string) (*models.AuthTokens, error) {
+	sessionID, secret, err := parseRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	session, err := repository.GetSessionByID(ctx, db, sessionID)
+	if err != nil {
+		return nil, errors.New("invalid refresh token")
+	}
+	if session.RevokedAt != nil {
+		return nil, errors.New("session revoked")
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return nil, errors.New("session expired")
+	}
+
+	presented := hashToken(secret)
+	if subtle.ConstantTimeCompare([]byte(presented), []byte(session.RefreshTokenHash)) != 1 {
+		if !wasRotated(session, presented) {
+			return nil, errors.New("invalid refresh token")
+		}
+		if err := repository.RevokeSession(ctx, db, session.ID, "refresh token reuse"); err != nil {
+			log.Printf("Failed to revoke session %s: %v", session.ID.Hex(), err)
+		}
+		log.Printf("Refresh token reuse detected for session %s", session.ID.Hex())
+		return nil, errors.New("refresh token reuse detected")
+	}
+
+	user, err := repository.GetUserByID(db.Collection("users"), session.UserID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	newSecret, err := newRefreshSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := repository.RotateSessionToken(ctx, db, session.ID, presented, hashToken(newSecret), ip); err != nil {
+		// Another request rotated this token first; treat it as reuse
+		repository.RevokeSession(ctx, db, session.ID, "refresh token reuse")
+		return nil, errors.New("refresh token reuse detected")
+	}
+	return issueTokens(user, session.ID, newSecret)
+}
+
+// wasRotated reports whether hash belongs to a refresh token the session
+// has already rotated out.
+func wasRotated(session *models.Session, hash string) bool {
+	rotated := 0
+	for _, previous := range session.RotatedTokenHashes {
+		rotated |= subtle.ConstantTimeCompare([]byte(hash), []byte(previous))
+	}
+	return rotated == 1
+}
+
+// ValidateSession checks that an access token's session is still active and
+// records that it was seen.
+func ValidateSession(ctx context.Context, db *mongo.Database, sessionID, userID primitive.ObjectID, ip string) error {
+	session, err := repository.GetSessionByID(ctx, db, sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return errors.New("session does not belong to user")
+	}
+	if session.RevokedAt != nil {
+		return errors.New("session revoked")
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return errors.New("session expired")
+	}
+	if time.Since(session.LastSeenAt) > sessionTouchInterval || session.IP != ip {
+		if err := repository.TouchSession(ctx, db, session.ID, ip); err != nil {
+			log.Printf("Failed to update session %s: %v", session.ID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+func GetUserSessions(ctx context.Context, db *mongo.Database, userID, currentSessionID primitive.ObjectID) ([]*models.Session, error) {
+	sessions, err := repository.GetActiveSessions(ctx, db, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, session := range sessions {
+		session.Current = session.ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs one of the user's devices out.
+func RevokeSession(ctx context.Context, db *mongo.Database, userID, sessionID primitive.ObjectID, reason string) error {
+	session, err := repository.GetSessionByID(ctx, db, sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return errors.New("unauthorized access to session")
+	}
+	return repository.RevokeSession(ctx, db, sessionID, reason)
+}
+
+// RevokeAllSessions signs the user out everywhere and returns how many
+// sessions were ended.
+func RevokeAllSessions(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, reason string) (int64, error) {
+	return repository.RevokeUserSessions(ctx, db, userID, reason, nil)
+}
+
+func issueTokens(user *models.User, sessionID primitive.ObjectID, secret string) (*models.AuthTokens, error) {
+	accessToken, err := utils.GenerateToken(user.Username, user.Email, user.ID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AuthTokens{
+		AccessToken:  accessToken,
+		RefreshToken: sessionID.Hex() + "." + secret,
+		ExpiresIn:    int64(utils.AccessTokenTTL.Seconds()),
+		SessionID:    sessionID,
+	}, nil
+}
+
+// parseRefreshToken splits a "<session id>.<secret>" refresh token.
+func parseRefreshToken(token string) (primitive.ObjectID, string, error) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return primitive.NilObjectID, "", errors.New("invalid refresh token")
+	}
+	sessionID, err := primitive.ObjectIDFromHex(parts[0])
+	if err != nil {
+		return primitive.NilObjectID, "", errors.New("invalid refresh token")
+	}
+	return sessionID, parts[1], nil
+}
+
+func newRefreshSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestWasRotated(t *testing.T) {
+	session := &models.Session{
+		RefreshTokenHash:   hashToken("current"),
+		RotatedTokenHashes: []string{hashToken("first"), hashToken("second")},
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  bool
+	}{
+		{"oldest rotated token", "first", true},
+		{"latest rotated token", "second", true},
+		{"current token", "current", false},
+		{"unknown token", "guess", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, wasRotated(session, hashToken(tt.token)))
+		})
+	}
+}
+
+func TestParseRefreshToken(t *testing.T) {
+	sessionID := primitive.NewObjectID()
+
+	tests := []struct {
+		name       string
+		token      string
+		wantSecret string
+		wantErr    bool
+	}{
+		{"valid", sessionID.Hex() + ".secret", "secret", false},
+		{"secret containing a dot", sessionID.Hex() + ".se.cret", "se.cret", false},
+		{"missing secret", sessionID.Hex() + ".", "", true},
+		{"no separator", sessionID.Hex(), "", true},
+		{"bad session id", "nothex.secret", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, secret, err := parseRefreshToken(tt.token)
+			if tt.wantErr {
+				assert.EqualError(t, err, "invalid refresh token")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, sessionID, id)
+			assert.Equal(t, tt.wantSecret, secret)
+		})
+	}
+}
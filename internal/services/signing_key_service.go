@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/fieldcrypt"
+	"github.com/Gerard-007/ajor_app/pkg/utils"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Every instance reloads its keys more often than this, so a new key is
+// known everywhere by the time it starts signing tokens.
+const signingKeyActivationDelay = 10 * time.Minute
+
+// signingAlgorithm is the algorithm new keys are made for. Changing
+// JWT_SIGNING_ALG takes effect at the next rotation.
+func signingAlgorithm() string {
+	if os.Getenv("JWT_SIGNING_ALG") == utils.AlgorithmEdDSA {
+		return utils.AlgorithmEdDSA
+	}
+	return utils.AlgorithmRS256
+}
+
+func signingKeyRotationInterval() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("JWT_KEY_ROTATION_DAYS"))
+	if err != nil || days <= 0 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// LoadSigningKeys loads the current signing keys into the token signer,
+// creating the first one if there are none.
+func LoadSigningKeys(ctx context.Context, db *mongo.Database) error {
+	now := time.Now()
+	stored, err := repository.GetSigningKeys(ctx, db, now)
+	if err != nil {
+		return err
+	}
+	if len(stored) == 0 {
+		key, err := createSigningKey(ctx, db, now)
+		if err != nil {
+			return err
+		}
+		stored = append(stored, key)
+	}
+
+	keys := make([]utils.SigningKey, 0, len(stored))
+	for _, key := range stored {
+		private, err := openSigningKey(key)
+		if err != nil {
+			return fmt.Errorf("failed to load signing key %s: %v", key.ID, err)
+		}
+		keys = append(keys, utils.SigningKey{
+			ID:          key.ID,
+			Algorithm:   key.Algorithm,
+			Private:     private,
+			ActivatesAt: key.ActivatesAt,
+		})
+	}
+	utils.SetSigningKeys(keys)
+	return nil
+}
+
+// RotateSigningKeys adds a new signing key once the newest one is older
+// than the rotation interval or uses another algorithm than configured.
+// The new key is published straight away but only signs after the
+// activation delay. Older keys keep verifying until the tokens they signed
+// have expired, and are then deleted.
+func RotateSigningKeys(ctx context.Context, db *mongo.Database) error {
+	now := time.Now()
+	if err := rewrapSigningKeys(ctx, db, now); err != nil {
+		return err
+	}
+
+	stored, err := repository.GetSigningKeys(ctx, db, now)
+	if err != nil {
+		return err
+	}
+	if len(stored) == 0 || stored[0].Algorithm != signingAlgorithm() ||
+		now.Sub(stored[0].ActivatesAt) >= signingKeyRotationInterval() {
+		key, err := createSigningKey(ctx, db, now.Add(signingKeyActivationDelay))
+		if err != nil {
+			return err
+		}
+		expiresAt := key.ActivatesAt.Add(utils.AccessTokenTTL + signingKeyActivationDelay)
+		if err := repository.ExpireSigningKeys(ctx, db, key.ID, expiresAt); err != nil {
+			return err
+		}
+		log.Printf("Created signing key %s, active from %s", key.ID, key.ActivatesAt.Format(time.RFC3339))
+	}
+
+	if _, err := repository.DeleteExpiredSigningKeys(ctx, db, now); err != nil {
+		return err
+	}
+	return LoadSigningKeys(ctx, db)
+}
+
+func createSigningKey(ctx context.Context, db *mongo.Database, activatesAt time.Time) (*models.SigningKey, error) {
+	algorithm := signingAlgorithm()
+	private, err := utils.GenerateSigningKey(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := fieldcrypt.Default().Encrypt(base64.StdEncoding.EncodeToString(der))
+	if err != nil {
+		return nil, err
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	key := &models.SigningKey{
+		ID:          hex.EncodeToString(id),
+		Algorithm:   algorithm,
+		PrivateKey:  sealed,
+		CreatedAt:   time.Now(),
+		ActivatesAt: activatesAt,
+	}
+	if err := repository.CreateSigningKey(ctx, db, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func openSigningKey(key *models.SigningKey) (crypto.Signer, error) {
+	encoded, err := fieldcrypt.Default().Decrypt(key.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	der, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	private, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("not a signing key")
+	}
+	return private, nil
+}
+
+// rewrapSigningKeys re-encrypts private keys sealed under a retired field
+// encryption key, so it can be removed from PII_KEYS.
+func rewrapSigningKeys(ctx context.Context, db *mongo.Database, now time.Time) error {
+	keyring := fieldcrypt.Default()
+	stored, err := repository.GetSigningKeys(ctx, db, now)
+	if err != nil {
+		return err
+	}
+	for _, key := range stored {
+		if !keyring.NeedsRewrap(key.PrivateKey) {
+			continue
+		}
+		rewrapped, err := keyring.Rewrap(key.PrivateKey)
+		if err != nil {
+			return err
+		}
+		if err := repository.UpdateSigningKeyPrivateKey(ctx, db, key.ID, rewrapped); err != nil {
+			return err
+		}
+	}
+	return nil
+}
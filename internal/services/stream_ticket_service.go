@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// streamTicketTTL is how long a client has to open the event stream with a
+// ticket.
+const streamTicketTTL = 30 * time.Second
+
+// CreateStreamTicket issues a single-use ticket for opening the event
+// stream within the signed-in session. The stream it opens ends when the
+// access token it was requested with expires.
+func CreateStreamTicket(ctx context.Context, db *mongo.Database, userID, sessionID primitive.ObjectID, tokenExpiresAt time.Time) (string, time.Time, error) {
+	secret, err := newRefreshSecret()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	ticket := &models.StreamTicket{
+		UserID:         userID,
+		SessionID:      sessionID,
+		TicketHash:     hashToken(secret),
+		TokenExpiresAt: tokenExpiresAt,
+		ExpiresAt:      time.Now().Add(streamTicketTTL),
+	}
+	if err := repository.CreateStreamTicket(ctx, db, ticket); err != nil {
+		return "", time.Time{}, err
+	}
+	return secret, ticket.ExpiresAt, nil
+}
+
+// RedeemStreamTicket uses up a ticket, checking that its session is still
+// valid.
+func RedeemStreamTicket(ctx context.Context, db *mongo.Database, secret, ip string) (*models.StreamTicket, error) {
+	ticket, err := repository.UseStreamTicket(ctx, db, hashToken(secret), time.Now())
+	if err != nil {
+		if err.Error() == "stream ticket not found" {
+			return nil, errors.New("invalid or expired stream ticket")
+		}
+		return nil, err
+	}
+	if err := ValidateSession(ctx, db, ticket.SessionID, ticket.UserID, ip); err != nil {
+		return nil, errors.New("session is no longer valid")
+	}
+	return ticket, nil
+}
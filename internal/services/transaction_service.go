@@ -4,59 +4,87 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/Gerard-007/ajor_app/internal/models"
 	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/events"
+	"github.com/Gerard-007/ajor_app/pkg/messages"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func RecordContribution(ctx context.Context, db *mongo.Database, contributionID, userID primitive.ObjectID, amount float64, paymentMethod models.PaymentMethod) error {
-	contribution, err := repository.GetContributionByID(ctx, db, contributionID)
+	var transaction *models.Transaction
+	err := inTransaction(ctx, db, func(ctx context.Context) error {
+		var err error
+		transaction, err = recordContribution(ctx, db, contributionID, userID, amount, paymentMethod)
+		return err
+	})
 	if err != nil {
 		return err
 	}
+
+	if err := recordPayment(ctx, db, userID, transaction.Late, transaction.Penalty > 0); err != nil {
+		log.Printf("Failed to update reliability score for user %s: %v", userID.Hex(), err)
+	}
+	return nil
+}
+
+// recordContribution moves a contribution from the member's wallet to the
+// group's, queueing its events and the late payment notice. It is run
+// within a transaction.
+func recordContribution(ctx context.Context, db *mongo.Database, contributionID, userID primitive.ObjectID, amount float64, paymentMethod models.PaymentMethod) (*models.Transaction, error) {
+	contribution, err := repository.GetContributionByID(ctx, db, contributionID)
+	if err != nil {
+		return nil, err
+	}
 	if !containsUser(contribution.YetToCollectMembers, userID) && !containsUser(contribution.AlreadyCollectedMembers, userID) {
-		return errors.New("user not in contribution")
+		return nil, errors.New("user not in contribution")
 	}
 	if amount != contribution.Amount {
-		return errors.New("contribution amount mismatch")
+		return nil, errors.New("contribution amount mismatch")
 	}
 
 	// Get wallets
-	var user models.User
-	err = db.Collection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	user, err := repository.GetUserByID(db.Collection("users"), userID)
 	if err != nil {
-		return errors.New("user not found")
+		return nil, errors.New("user not found")
 	}
-	userWallet, err := repository.GetWalletByUserID(db, user.ID)
+	userWallet, err := repository.GetWalletByUserIDContext(ctx, db, user.ID)
 	if err != nil {
-		return errors.New("user wallet not found")
+		return nil, errors.New("user wallet not found")
+	}
+
+	// Check KYC limits
+	if err := checkContributionLimit(user, amount); err != nil {
+		return nil, err
+	}
+	if err := checkOutflowLimit(ctx, db, user, userWallet, models.TransactionContribution, amount); err != nil {
+		return nil, err
 	}
-	fmt.Println("Wallet ID from contribution:", contribution.WalletID.Hex())
 
 	groupWallet, err := repository.GetWalletByID(db, contribution.WalletID)
 	if err != nil {
-		return errors.New("group wallet not found")
+		return nil, errors.New("group wallet not found")
 	}
 
 	// Check balance
 	if userWallet.Balance < amount {
-		return errors.New("insufficient balance")
+		return nil, errors.New("insufficient balance")
 	}
 
 	// Update wallets
-	if err := repository.UpdateWalletBalance(db, userWallet.ID, amount, false); err != nil {
-		return err
+	if err := repository.UpdateWalletBalanceContext(ctx, db, userWallet.ID, amount, false); err != nil {
+		return nil, err
 	}
-	if err := repository.UpdateWalletBalance(db, groupWallet.ID, amount, true); err != nil {
-		// Rollback
-		repository.UpdateWalletBalance(db, userWallet.ID, amount, true)
-		return err
+	if err := repository.UpdateWalletBalanceContext(ctx, db, groupWallet.ID, amount, true); err != nil {
+		return nil, err
 	}
 
+	late := time.Now().After(contribution.CollectionDeadline)
 	transaction := &models.Transaction{
 		FromWallet:     userWallet.ID,
 		ToWallet:       groupWallet.ID,
@@ -66,24 +94,32 @@ func RecordContribution(ctx context.Context, db *mongo.Database, contributionID,
 		PaymentMethod:  paymentMethod,
 		Status:         models.StatusSuccess,
 		ContributionID: contributionID,
+		Late:           late,
+	}
+	if late {
+		transaction.Penalty = contribution.PenaltyAmount
 	}
 	if err := repository.CreateTransaction(ctx, db, transaction); err != nil {
-		// Rollback
-		repository.UpdateWalletBalance(db, userWallet.ID, amount, true)
-		repository.UpdateWalletBalance(db, groupWallet.ID, amount, false)
-		return err
+		return nil, err
+	}
+	if err := queueTransactionEvents(ctx, db, transaction); err != nil {
+		return nil, err
 	}
 
-	if time.Now().After(contribution.CollectionDeadline) {
+	if late {
 		notification := &models.Notification{
 			UserID:         userID,
 			ContributionID: contributionID,
-			Message:        fmt.Sprintf("Late contribution recorded. Penalty applied: %.2f", contribution.PenaltyAmount),
+			Template:       messages.ContributionLate,
+			Params:         messages.Params{"Penalty": contribution.PenaltyAmount},
 			Type:           models.NotificationWarning,
+			Category:       models.CategoryPaymentReminders,
+		}
+		if err := queueNotification(ctx, db, notification); err != nil {
+			return nil, err
 		}
-		return repository.CreateNotification(ctx, db, notification)
 	}
-	return nil
+	return transaction, nil
 }
 
 func RecordPayout(ctx context.Context, db *mongo.Database, contributionID, userID, groupAdminID primitive.ObjectID, amount float64, paymentMethod models.PaymentMethod) error {
@@ -101,15 +137,18 @@ func RecordPayout(ctx context.Context, db *mongo.Database, contributionID, userI
 	}
 
 	// Get wallets
-	var user models.User
-	err = db.Collection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	user, err := repository.GetUserByID(db.Collection("users"), userID)
 	if err != nil {
 		return errors.New("user not found")
 	}
-	userWallet, err := repository.GetWalletByID(db, user.ID)
+	userWallet, err := repository.GetWalletByUserID(db, user.ID)
 	if err != nil {
 		return errors.New("user wallet not found")
 	}
+	// Checked again on approval, since the recipient's balance may change meanwhile
+	if err := checkInflowLimit(ctx, db, user, userWallet, amount); err != nil {
+		return fmt.Errorf("recipient's %v", err)
+	}
 	groupWallet, err := repository.GetWalletByID(db, contribution.WalletID)
 	if err != nil {
 		return errors.New("group wallet not found")
@@ -147,14 +186,21 @@ func RecordPayout(ctx context.Context, db *mongo.Database, contributionID, userI
 		db.Collection("transactions").DeleteOne(ctx, bson.M{"_id": transaction.ID})
 		return err
 	}
+	publishEvent(events.ApprovalRequested, []primitive.ObjectID{groupAdminID}, contributionID, map[string]interface{}{
+		"transaction_id":   transaction.ID.Hex(),
+		"transaction_type": transaction.Type,
+		"amount":           amount,
+	})
 
 	notification := &models.Notification{
 		UserID:         userID,
 		ContributionID: contributionID,
-		Message:        fmt.Sprintf("Payout of %.2f requested for contribution: %s", amount, contribution.Name),
+		Template:       messages.PayoutRequested,
+		Params:         messages.Params{"Amount": amount, "Group": contribution.Name},
 		Type:           models.NotificationInfo,
+		Category:       models.CategoryPayouts,
 	}
-	return repository.CreateNotification(ctx, db, notification)
+	return Notify(ctx, db, notification)
 }
 
 // func GetUserTransactions(ctx context.Context, db *mongo.Database, userID, contributionID primitive.ObjectID) ([]*models.Transaction, error) {
@@ -162,23 +208,17 @@ func RecordPayout(ctx context.Context, db *mongo.Database, contributionID, userI
 // }
 
 
-func GetUserTransactions(ctx context.Context, db *mongo.Database, userID primitive.ObjectID, isAdmin bool) ([]models.Transaction, error) {
-	var filter bson.M
-	if isAdmin {
-		// System admins can view all transactions
-		filter = bson.M{}
-	} else {
-		// Regular users can only view transactions involving their wallet
-		wallet, err := repository.GetWalletByUserID(db, userID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to fetch wallet: %v", err)
-		}
-		filter = bson.M{
-			"$or": []bson.M{
-				{"from_wallet": wallet.ID},
-				{"to_wallet": wallet.ID},
-			},
-		}
+func GetUserTransactions(ctx context.Context, db *mongo.Database, userID primitive.ObjectID) ([]models.Transaction, error) {
+	// Users only see transactions involving their wallet
+	wallet, err := repository.GetWalletByUserID(db, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch wallet: %v", err)
+	}
+	filter := bson.M{
+		"$or": []bson.M{
+			{"from_wallet": wallet.ID},
+			{"to_wallet": wallet.ID},
+		},
 	}
 
 	transactions, err := repository.GetTransactions(ctx, db, filter)
@@ -189,7 +229,20 @@ func GetUserTransactions(ctx context.Context, db *mongo.Database, userID primiti
 	return transactions, nil
 }
 
-func GetContributionTransactions(ctx context.Context, db *mongo.Database, contributionID, userID primitive.ObjectID, isAdmin bool) ([]models.Transaction, error) {
+// GetAllTransactions lists every transaction on the platform for admins
+// who can read transactions.
+func GetAllTransactions(ctx context.Context, db *mongo.Database, actorID primitive.ObjectID) ([]models.Transaction, error) {
+	if err := RequirePermission(ctx, db, actorID, models.PermTransactionsRead); err != nil {
+		return nil, err
+	}
+	transactions, err := repository.GetTransactions(ctx, db, bson.M{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transactions: %v", err)
+	}
+	return transactions, nil
+}
+
+func GetContributionTransactions(ctx context.Context, db *mongo.Database, contributionID, userID primitive.ObjectID) ([]models.Transaction, error) {
 	// Fetch contribution to verify authorization
 	contribution, err := repository.GetContributionByID(ctx, db, contributionID)
 	if err != nil {
@@ -199,9 +252,15 @@ func GetContributionTransactions(ctx context.Context, db *mongo.Database, contri
 		return nil, fmt.Errorf("failed to fetch contribution: %v", err)
 	}
 
-	// Check authorization: user must be group admin, a member, or system admin
-	if contribution.GroupAdmin != userID && !isAdmin && !containsUser(contribution.YetToCollectMembers, userID) && !containsUser(contribution.AlreadyCollectedMembers, userID) {
-		return nil, fmt.Errorf("unauthorized access")
+	// Check authorization: user must be group admin, a member, or able to read transactions
+	if contribution.GroupAdmin != userID && !containsUser(contribution.YetToCollectMembers, userID) && !containsUser(contribution.AlreadyCollectedMembers, userID) {
+		allowed, err := HasPermission(ctx, db, userID, models.PermTransactionsRead)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, fmt.Errorf("unauthorized access")
+		}
 	}
 
 	// Fetch wallet for the contribution
@@ -1,24 +1,43 @@
 package services
 
 import (
+	"context"
 	"errors"
 
 	"github.com/Gerard-007/ajor_app/internal/models"
 	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/sms"
+	"github.com/Gerard-007/ajor_app/pkg/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func GetAllUsers(db *mongo.Collection) ([]*models.User, error) {
-	return repository.GetAllUsers(db)
+func GetAllUsers(ctx context.Context, db *mongo.Database, actorID primitive.ObjectID) ([]*models.User, error) {
+	if err := RequirePermission(ctx, db, actorID, models.PermUsersRead); err != nil {
+		return nil, err
+	}
+	users, err := repository.GetAllUsers(db.Collection("users"))
+	if err != nil {
+		return nil, err
+	}
+	if !canReadPII(ctx, db, actorID) {
+		for _, user := range users {
+			maskUserPII(user)
+		}
+	}
+	return users, nil
 }
 
 // func GetUserByID(db *mongo.Collection, id primitive.ObjectID) (*models.User, error) {
 // 	return repository.GetUserByID(db, id)
 // }
 
-func GetUserByID(db *mongo.Database, id primitive.ObjectID) (*models.UserResponse, error) {
-	//ctx := context.Background()
+// GetUserByID returns a user's details to themselves or to an admin who
+// can read users.
+func GetUserByID(db *mongo.Database, actorID, id primitive.ObjectID) (*models.UserResponse, error) {
+	if err := requireSelfOrPermission(context.Background(), db, actorID, id, models.PermUsersRead); err != nil {
+		return nil, err
+	}
 
 	// Fetch user
 	usersCollection := db.Collection("users")
@@ -41,18 +60,28 @@ func GetUserByID(db *mongo.Database, id primitive.ObjectID) (*models.UserRespons
 		return nil, err
 	}
 
+	// Fetch reliability score
+	reliability, err := GetReliabilityScore(context.Background(), db, id)
+	if err != nil {
+		return nil, err
+	}
+
 	// Combine into UserResponse
 	response := &models.UserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		IsAdmin:   user.IsAdmin,
-		Phone:     user.Phone,
-		BVN:       user.BVN,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-		Profile:   profile,
-		Wallet:    wallet,
+		ID:          user.ID,
+		Username:    user.Username,
+		Email:       user.Email,
+		IsAdmin:     user.IsAdmin,
+		Roles:       user.EffectiveRoles(),
+		Phone:       user.Phone,
+		Verified:    user.Verified,
+		BVN:         user.BVN,
+		KYCTier:     user.EffectiveKYCTier(),
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+		Profile:     profile,
+		Wallet:      wallet,
+		Reliability: reliability,
 	}
 
 	// Map wallet fields to match desired output
@@ -60,6 +89,10 @@ func GetUserByID(db *mongo.Database, id primitive.ObjectID) (*models.UserRespons
 	response.Wallet.VirtualBankName = wallet.VirtualBankName
 	response.Wallet.VirtualAccountNumber = wallet.VirtualAccountID
 
+	if !canReadPII(context.Background(), db, actorID) {
+		response.Phone = utils.MaskPhone(response.Phone)
+		response.BVN = utils.MaskBVN(response.BVN)
+	}
 	return response, nil
 }
 
@@ -67,18 +100,26 @@ type UserUpdate struct {
 	Email    string `json:"email"`
 	Username string `json:"username"`
 	Phone    string `json:"phone"`
-	Verified bool   `json:"verified"`
-	IsAdmin  bool   `json:"is_admin"`
 }
 
-func UpdateUser(db *mongo.Database, id primitive.ObjectID, userUpdate *UserUpdate, isAdmin bool) (*models.User, error) {
-	// Restrict Verified and IsAdmin updates to admins
-	if !isAdmin {
-		if userUpdate.Verified || userUpdate.IsAdmin {
-			return nil, errors.New("only admins can update verified or admin status")
+// UpdateUser changes a user's account details. Users may update themselves;
+// anyone else needs permission to write users. Roles are changed through
+// AssignRoles.
+func UpdateUser(db *mongo.Database, actorID, id primitive.ObjectID, userUpdate *UserUpdate) (*models.User, error) {
+	if err := requireSelfOrPermission(context.Background(), db, actorID, id, models.PermUsersWrite); err != nil {
+		return nil, err
+	}
+
+	current, err := repository.GetUserByID(db.Collection("users"), id)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+	if userUpdate.Phone != "" {
+		phone, err := sms.NormalizePhone(userUpdate.Phone)
+		if err != nil {
+			return nil, err
 		}
-		userUpdate.Verified = false
-		userUpdate.IsAdmin = false
+		userUpdate.Phone = phone
 	}
 
 	// Map to repository UserUpdate
@@ -86,13 +127,35 @@ func UpdateUser(db *mongo.Database, id primitive.ObjectID, userUpdate *UserUpdat
 		Email:    userUpdate.Email,
 		Username: userUpdate.Username,
 		Phone:    userUpdate.Phone,
-		Verified: userUpdate.Verified,
-		IsAdmin:  userUpdate.IsAdmin,
 	}
 
-	return repository.UpdateUser(db, id, repoUpdate)
+	updated, err := repository.UpdateUser(db, id, repoUpdate)
+	if err != nil {
+		return nil, err
+	}
+
+	// A new phone number has to be verified again
+	if updated.Phone != current.Phone && updated.Verified {
+		if err := repository.SetUserVerified(db, id, false); err != nil {
+			return nil, err
+		}
+		updated.Verified = false
+	}
+	if !canReadPII(context.Background(), db, actorID) {
+		maskUserPII(updated)
+	}
+	updated.Password = ""
+	return updated, nil
+}
+
+// canReadPII reports whether the actor may see phone numbers and BVNs in
+// full. Everyone else, including the user themselves, gets masked values.
+func canReadPII(ctx context.Context, db *mongo.Database, actorID primitive.ObjectID) bool {
+	allowed, err := HasPermission(ctx, db, actorID, models.PermPIIRead)
+	return err == nil && allowed
 }
 
-func DeleteUser(db *mongo.Database, userID primitive.ObjectID) error {
-	return repository.DeleteUserAndProfile(db, userID)
+func maskUserPII(user *models.User) {
+	user.Phone = utils.MaskPhone(user.Phone)
+	user.BVN = utils.MaskBVN(user.BVN)
 }
\ No newline at end of file
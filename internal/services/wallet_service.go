@@ -27,6 +27,9 @@ func FundWallet(ctx context.Context, db *mongo.Database, userID primitive.Object
 	if wallet.VirtualAccountID == "" {
 		return fmt.Errorf("no virtual account linked to wallet")
 	}
+	if err := checkInflowLimit(ctx, db, user, wallet, amount); err != nil {
+		return err
+	}
 
 	// Initiate funding to virtual account
 	txRef := fmt.Sprintf("fund-wallet-%s-%d", userID.Hex(), time.Now().UnixNano())
@@ -60,35 +63,42 @@ func FundWallet(ctx context.Context, db *mongo.Database, userID primitive.Object
 		return fmt.Errorf("failed to create transaction: %v", err)
 	}
 
+	// fail marks the transaction failed, so it is not left pending
+	fail := func(reason error) error {
+		if err := repository.SettleTransaction(ctx, db, transaction.ID, models.StatusFailed); err != nil {
+			return fmt.Errorf("failed to update transaction status: %v", err)
+		}
+		return reason
+	}
+
 	// Verify transaction (in real-world, this would be via webhook; here we simulate verification)
 	verifiedTx, err := pg.VerifyTransaction(ctx, transactionResponse.TransactionID)
 	if err != nil {
-		// Update transaction to failed
-		repository.UpdateTransactionStatus(ctx, db, transaction.ID, models.StatusFailed)
-		return fmt.Errorf("transaction verification failed: %v", err)
+		return fail(fmt.Errorf("transaction verification failed: %v", err))
 	}
 	if verifiedTx.Status != "success" || verifiedTx.Amount != amount {
-		repository.UpdateTransactionStatus(ctx, db, transaction.ID, models.StatusFailed)
-		return fmt.Errorf("invalid transaction status or amount")
+		return fail(fmt.Errorf("invalid transaction status or amount"))
 	}
 
-	// Update wallet balance
-	if err := repository.UpdateWalletBalance(db, wallet.ID, amount, true); err != nil {
-		// Update transaction to failed (rollback attempt)
-		repository.UpdateTransactionStatus(ctx, db, transaction.ID, models.StatusFailed)
-		return fmt.Errorf("failed to update wallet balance: %v", err)
-	}
-
-	// Update transaction to success
-	if err := repository.UpdateTransactionStatus(ctx, db, transaction.ID, models.StatusSuccess); err != nil {
-		return fmt.Errorf("failed to update transaction status: %v", err)
+	// Credit the wallet together with the transaction status and its events
+	err = inTransaction(ctx, db, func(ctx context.Context) error {
+		if err := repository.SettleTransaction(ctx, db, transaction.ID, models.StatusSuccess); err != nil {
+			return fmt.Errorf("failed to update transaction status: %v", err)
+		}
+		if err := repository.UpdateWalletBalanceContext(ctx, db, wallet.ID, amount, true); err != nil {
+			return fmt.Errorf("failed to update wallet balance: %v", err)
+		}
+		transaction.Status = models.StatusSuccess
+		return queueTransactionEvents(ctx, db, transaction)
+	})
+	if err != nil {
+		return fail(err)
 	}
-
 	return nil
 }
 
 
-func GetContributionWallet(ctx context.Context, db *mongo.Database, pg payment.PaymentGateway, contributionID, userID primitive.ObjectID, isAdmin bool) (*models.Wallet, error) {
+func GetContributionWallet(ctx context.Context, db *mongo.Database, pg payment.PaymentGateway, contributionID, userID primitive.ObjectID) (*models.Wallet, error) {
 	log.Printf("Fetching contribution ID: %s for user ID: %s", contributionID.Hex(), userID.Hex())
 
 	// Fetch contribution by ID
@@ -103,8 +113,8 @@ func GetContributionWallet(ctx context.Context, db *mongo.Database, pg payment.P
 	}
 
 	// Check authorization
-	log.Printf("Checking authorization for user ID: %s, isAdmin: %v", userID.Hex(), isAdmin)
-	if contribution.GroupAdmin != userID && !isAdmin {
+	log.Printf("Checking authorization for user ID: %s", userID.Hex())
+	if contribution.GroupAdmin != userID {
 		// Ensure member arrays are not nil to prevent panic
 		hasAccess := false
 		if contribution.YetToCollectMembers != nil {
@@ -123,6 +133,14 @@ func GetContributionWallet(ctx context.Context, db *mongo.Database, pg payment.P
 				}
 			}
 		}
+		if !hasAccess {
+			// Admins who can read wallets may view any contribution's wallet
+			allowed, err := HasPermission(ctx, db, userID, models.PermWalletsRead)
+			if err != nil {
+				return nil, err
+			}
+			hasAccess = allowed
+		}
 		if !hasAccess {
 			log.Printf("Unauthorized access for user ID: %s", userID.Hex())
 			return nil, fmt.Errorf("unauthorized access")
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/pkg/fieldcrypt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// webhookClient only connects to public addresses, checked on the address
+// actually dialled, so a webhook host that resolves differently after it
+// was set cannot reach internal services either.
+var webhookClient = &http.Client{
+	Timeout: 10 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || !publicAddress(ip) {
+					return fmt.Errorf("webhook address %s is not public", host)
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, which is not public
+// either but is not covered by net.IP.IsPrivate.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// publicAddress reports whether ip can be reached on the internet, so that
+// webhooks cannot be pointed at the server itself or its network.
+func publicAddress(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+// checkWebhookHost resolves the host of a webhook URL and fails unless
+// every address it has is public.
+func checkWebhookHost(ctx context.Context, host string) error {
+	addresses, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addresses) == 0 {
+		return errors.New("invalid webhook url: host could not be resolved")
+	}
+	for _, address := range addresses {
+		if !publicAddress(address.IP) {
+			return errors.New("invalid webhook url: must not point to a private address")
+		}
+	}
+	return nil
+}
+
+// SetOrganisationWebhook sets the URL the organisation's wallet events are
+// posted to and returns a new signing secret. The secret is only returned
+// here; setting the webhook again replaces it.
+func SetOrganisationWebhook(ctx context.Context, db *mongo.Database, organisationID, actorID primitive.ObjectID, webhookURL string) (string, error) {
+	if _, err := getOwnedOrganisation(ctx, db, organisationID, actorID); err != nil {
+		return "", err
+	}
+	webhookURL = strings.TrimSpace(webhookURL)
+	if webhookURL == "" {
+		return "", errors.New("url is required")
+	}
+	parsed, err := url.Parse(webhookURL)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		return "", errors.New("invalid webhook url: must be an https URL")
+	}
+	if err := checkWebhookHost(ctx, parsed.Hostname()); err != nil {
+		return "", err
+	}
+
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+	sealedSecret, err := fieldcrypt.Default().Encrypt(secret)
+	if err != nil {
+		return "", err
+	}
+	if err := repository.SetOrganisationWebhook(ctx, db, organisationID, webhookURL, sealedSecret); err != nil {
+		return "", err
+	}
+
+	recordAuditEvent(ctx, db, &models.AuditEvent{
+		Type:    models.AuditWebhookSet,
+		UserID:  &actorID,
+		Subject: organisationID.Hex(),
+		Details: map[string]interface{}{"url": webhookURL},
+	})
+	return secret, nil
+}
+
+// RemoveOrganisationWebhook stops posting events to the organisation.
+// Events already queued are dropped when they come to be sent.
+func RemoveOrganisationWebhook(ctx context.Context, db *mongo.Database, organisationID, actorID primitive.ObjectID) error {
+	if _, err := getOwnedOrganisation(ctx, db, organisationID, actorID); err != nil {
+		return err
+	}
+	if err := repository.SetOrganisationWebhook(ctx, db, organisationID, "", ""); err != nil {
+		return err
+	}
+	recordAuditEvent(ctx, db, &models.AuditEvent{
+		Type:    models.AuditWebhookRemoved,
+		UserID:  &actorID,
+		Subject: organisationID.Hex(),
+	})
+	return nil
+}
+
+// sendWebhook posts a queued webhook event to the organisation:
+//
+//	{"id": "...", "type": "wallet.debited", "organisation_id": "...", "data": {...}, "created_at": "..."}
+//
+// X-Webhook-ID repeats the id, which stays the same when a delivery is
+// retried, so receivers can ignore events they have already handled.
+// X-Signature is the hex HMAC-SHA256, under the webhook secret, of
+// "<X-Timestamp>\n<body>".
+func sendWebhook(ctx context.Context, db *mongo.Database, entry *models.OutboxEntry) error {
+	webhook := entry.Webhook
+	organisation, err := repository.GetOrganisationByID(ctx, db, webhook.OrganisationID)
+	if err != nil {
+		return err
+	}
+	if organisation.WebhookURL == "" {
+		// The webhook was removed after the event was queued
+		return nil
+	}
+	secret, err := fieldcrypt.Default().Decrypt(organisation.WebhookSecret)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"id":              entry.ID.Hex(),
+		"type":            webhook.Type,
+		"organisation_id": webhook.OrganisationID.Hex(),
+		"data":            json.RawMessage(webhook.Data),
+		"created_at":      entry.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s\n", timestamp)
+	mac.Write(body)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, organisation.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-ID", entry.ID.Hex())
+	req.Header.Set("X-Timestamp", timestamp)
+	req.Header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return nil
+}
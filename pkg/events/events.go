@@ -0,0 +1,78 @@
+// Package events carries real-time events from the services to the clients
+// streaming them, through a Broker.
+package events
+
+import (
+	"errors"
+	"time"
+)
+
+// Type names what happened.
+type Type string
+
+const (
+	WalletCredited      Type = "wallet.credited"
+	WalletDebited       Type = "wallet.debited"
+	ApprovalRequested   Type = "approval.requested"
+	ApprovalDecided     Type = "approval.decided"
+	MemberJoined        Type = "member.joined"
+	MemberRemoved       Type = "member.removed"
+	RoundClosed         Type = "round.closed"
+	NotificationCreated Type = "notification.created"
+	// StreamReset tells a resuming client that events it missed are no
+	// longer available, so it should reload what it shows.
+	StreamReset Type = "stream.reset"
+)
+
+// Event is one thing that happened, for the users in UserIDs and every
+// member of the contribution in ContributionID. Key, if set, identifies
+// what happened, so an event published again, say after a retry, can be
+// recognised as a repeat.
+type Event struct {
+	ID             string      `json:"id"`
+	Key            string      `json:"key,omitempty"`
+	Type           Type        `json:"type"`
+	ContributionID string      `json:"contribution_id,omitempty"`
+	Data           interface{} `json:"data,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UserIDs        []string    `json:"-"`
+}
+
+// ForUser reports whether the event is addressed to the user directly.
+func (e Event) ForUser(userID string) bool {
+	for _, id := range e.UserIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrEventsMissed is returned by Subscribe when the event to resume after
+// is no longer held, so some events in between cannot be replayed.
+var ErrEventsMissed = errors.New("events since the last event ID are no longer available")
+
+// Subscription receives the events a subscriber asked for. Replay holds
+// the events published after the last event ID it resumed from, in order,
+// and is to be read before Events. Events is closed if the subscriber
+// falls too far behind; it should then resume from the last event it saw.
+type Subscription struct {
+	Replay []Event
+	Events <-chan Event
+	Close  func()
+}
+
+// Broker fans events out to subscribers. The in-process MemoryBroker
+// serves a single instance; running several behind a load balancer needs a
+// shared one, such as one fed from MongoDB change streams.
+type Broker interface {
+	// Publish assigns the event its ID and time and sends it to every
+	// subscriber it matches. An event with the Key of one the broker still
+	// holds is dropped.
+	Publish(event Event)
+	// Subscribe returns events accepted by match. With lastEventID set it
+	// first replays the matching events published since then. If some can
+	// no longer be replayed, it replays those it still holds and returns
+	// ErrEventsMissed alongside the subscription.
+	Subscribe(match func(Event) bool, lastEventID string) (*Subscription, error)
+}
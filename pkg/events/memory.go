@@ -0,0 +1,138 @@
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind by
+// before its subscription is closed.
+const subscriberBuffer = 64
+
+// MemoryBroker keeps the most recent events in memory to replay to
+// resuming subscribers. Event IDs are "<instance>-<sequence>", so IDs from
+// before a restart are recognised as missed rather than mistaken for new
+// ones.
+type MemoryBroker struct {
+	mu          sync.Mutex
+	instance    string
+	sequence    uint64
+	history     []Event
+	keys        map[string]struct{}
+	size        int
+	subscribers map[*memorySubscriber]struct{}
+}
+
+type memorySubscriber struct {
+	match  func(Event) bool
+	events chan Event
+}
+
+// NewMemoryBroker returns a broker that can replay the last size events.
+func NewMemoryBroker(size int) *MemoryBroker {
+	instance := make([]byte, 4)
+	rand.Read(instance)
+	return &MemoryBroker{
+		instance:    hex.EncodeToString(instance),
+		keys:        map[string]struct{}{},
+		size:        size,
+		subscribers: map[*memorySubscriber]struct{}{},
+	}
+}
+
+func (b *MemoryBroker) Publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if event.Key != "" {
+		if _, ok := b.keys[event.Key]; ok {
+			return
+		}
+		b.keys[event.Key] = struct{}{}
+	}
+	b.sequence++
+	event.ID = b.instance + "-" + strconv.FormatUint(b.sequence, 10)
+	event.CreatedAt = time.Now()
+	b.history = append(b.history, event)
+	if len(b.history) > b.size {
+		for _, dropped := range b.history[:len(b.history)-b.size] {
+			delete(b.keys, dropped.Key)
+		}
+		b.history = b.history[len(b.history)-b.size:]
+	}
+
+	for subscriber := range b.subscribers {
+		if !subscriber.match(event) {
+			continue
+		}
+		select {
+		case subscriber.events <- event:
+		default:
+			// Too far behind: drop it so it resumes and replays instead
+			// of silently losing events.
+			delete(b.subscribers, subscriber)
+			close(subscriber.events)
+		}
+	}
+}
+
+func (b *MemoryBroker) Subscribe(match func(Event) bool, lastEventID string) (*Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subscriber := &memorySubscriber{match: match, events: make(chan Event, subscriberBuffer)}
+	b.subscribers[subscriber] = struct{}{}
+	subscription := &Subscription{
+		Events: subscriber.events,
+		Close: func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if _, ok := b.subscribers[subscriber]; ok {
+				delete(b.subscribers, subscriber)
+				close(subscriber.events)
+			}
+		},
+	}
+	if lastEventID == "" {
+		return subscription, nil
+	}
+
+	// An ID from another instance or one no longer held means events
+	// were missed; replay everything still held after it.
+	after, ok := b.sequenceOf(lastEventID)
+	missed := !ok || after > b.sequence || (after < b.sequence && b.oldestSequence() > after+1)
+	if missed {
+		after = 0
+	}
+	for _, event := range b.history {
+		if sequence, _ := b.sequenceOf(event.ID); sequence > after && match(event) {
+			subscription.Replay = append(subscription.Replay, event)
+		}
+	}
+	if missed {
+		return subscription, ErrEventsMissed
+	}
+	return subscription, nil
+}
+
+// sequenceOf parses an event ID issued by this broker instance.
+func (b *MemoryBroker) sequenceOf(id string) (uint64, bool) {
+	instance, sequence, ok := strings.Cut(id, "-")
+	if !ok || instance != b.instance {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(sequence, 10, 64)
+	return n, err == nil
+}
+
+func (b *MemoryBroker) oldestSequence() uint64 {
+	if len(b.history) == 0 {
+		return b.sequence + 1
+	}
+	sequence, _ := b.sequenceOf(b.history[0].ID)
+	return sequence
+}
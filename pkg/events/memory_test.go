@@ -0,0 +1,38 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryBrokerDropsRepeatedKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+		keys []string
+		want []string
+	}{
+		{"events without keys", 10, []string{"", ""}, []string{"", ""}},
+		{"distinct keys", 10, []string{"a", "b"}, []string{"a", "b"}},
+		{"repeated key", 10, []string{"a", "b", "a"}, []string{"a", "b"}},
+		{"key no longer held", 1, []string{"a", "b", "a"}, []string{"a", "b", "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broker := NewMemoryBroker(tt.size)
+			subscription, err := broker.Subscribe(func(Event) bool { return true }, "")
+			assert.NoError(t, err)
+			defer subscription.Close()
+
+			for _, key := range tt.keys {
+				broker.Publish(Event{Key: key, Type: WalletCredited})
+			}
+			got := []string{}
+			for len(subscription.Events) > 0 {
+				got = append(got, (<-subscription.Events).Key)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,289 @@
+// Package fieldcrypt encrypts individual database fields with envelope
+// encryption and computes blind indexes for looking them up.
+//
+// Each value gets its own random data key. The value is sealed with the
+// data key using AES-256-GCM, and the data key is sealed with a key
+// encryption key (KEK) from configuration. Rotating the KEK only requires
+// re-sealing data keys, not values. Encrypted values are stored as
+//
+//	enc:v1:<kek id>:<sealed data key>:<sealed value>
+//
+// with both sealed parts in unpadded base64. Values without the prefix are
+// treated as legacy plaintext so existing records keep working until they
+// are rewrapped.
+package fieldcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+const prefix = "enc:v1:"
+
+// minDerivationSecretLength is the shortest JWT_SECRET keys are derived
+// from when PII_KEYS or PII_INDEX_KEY is not set.
+const minDerivationSecretLength = 32
+
+var encoding = base64.RawStdEncoding
+
+// Keyring holds the KEKs that can decrypt fields, the one used to encrypt
+// new values, and the key used for blind indexes.
+type Keyring struct {
+	activeID string
+	keks     map[string][]byte
+	indexKey []byte
+}
+
+// NewKeyring builds a keyring. keks maps key IDs to 32-byte keys and
+// activeID picks the one new values are encrypted with. The index key must
+// never change, or existing blind indexes stop matching.
+func NewKeyring(activeID string, keks map[string][]byte, indexKey []byte) (*Keyring, error) {
+	if _, ok := keks[activeID]; !ok {
+		return nil, fmt.Errorf("active key %q is not in the keyring", activeID)
+	}
+	for id, key := range keks {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid key ID %q", id)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("key %q must be 32 bytes", id)
+		}
+	}
+	if len(indexKey) < 32 {
+		return nil, errors.New("index key must be at least 32 bytes")
+	}
+	return &Keyring{activeID: activeID, keks: keks, indexKey: indexKey}, nil
+}
+
+// KeyringFromEnv reads PII_KEYS, a comma-separated list of id:base64key
+// pairs whose first entry is the active key, and PII_INDEX_KEY, a base64
+// key for blind indexes. Without them, keys are derived from JWT_SECRET so
+// development setups work, as long as it is at least 32 characters;
+// production should always set both.
+func KeyringFromEnv() (*Keyring, error) {
+	keks := make(map[string][]byte)
+	var activeID string
+	if raw := strings.TrimSpace(os.Getenv("PII_KEYS")); raw != "" {
+		for _, entry := range strings.Split(raw, ",") {
+			id, encoded, ok := strings.Cut(strings.TrimSpace(entry), ":")
+			if !ok {
+				return nil, fmt.Errorf("PII_KEYS entry %q must be id:base64key", entry)
+			}
+			key, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, fmt.Errorf("PII_KEYS key %q is not valid base64: %v", id, err)
+			}
+			if activeID == "" {
+				activeID = id
+			}
+			keks[id] = key
+		}
+	} else {
+		key, err := deriveFromJWTSecret("pii-kek")
+		if err != nil {
+			return nil, fmt.Errorf("PII_KEYS is not set and %v", err)
+		}
+		log.Println("PII_KEYS not set, deriving field encryption key from JWT_SECRET")
+		activeID = "default"
+		keks[activeID] = key
+	}
+
+	var indexKey []byte
+	if raw := strings.TrimSpace(os.Getenv("PII_INDEX_KEY")); raw != "" {
+		key, err := base64.StdEncoding.DecodeString(raw)
+		if err != nil {
+			return nil, fmt.Errorf("PII_INDEX_KEY is not valid base64: %v", err)
+		}
+		indexKey = key
+	} else {
+		key, err := deriveFromJWTSecret("pii-index")
+		if err != nil {
+			return nil, fmt.Errorf("PII_INDEX_KEY is not set and %v", err)
+		}
+		indexKey = key
+	}
+	return NewKeyring(activeID, keks, indexKey)
+}
+
+var (
+	defaultOnce    sync.Once
+	defaultKeyring *Keyring
+)
+
+// Default returns the keyring configured from the environment. It exits if
+// the configuration is invalid, since no user record could be read.
+func Default() *Keyring {
+	defaultOnce.Do(func() {
+		keyring, err := KeyringFromEnv()
+		if err != nil {
+			log.Fatalf("Invalid field encryption configuration: %v", err)
+		}
+		defaultKeyring = keyring
+	})
+	return defaultKeyring
+}
+
+// Encrypt seals a value under a fresh data key. Empty values stay empty.
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	sealedValue, err := seal(dataKey, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	sealedKey, err := seal(k.keks[k.activeID], dataKey)
+	if err != nil {
+		return "", err
+	}
+	return prefix + k.activeID + ":" + encoding.EncodeToString(sealedKey) + ":" + encoding.EncodeToString(sealedValue), nil
+}
+
+// Decrypt opens a value produced by Encrypt. Values without the encryption
+// prefix are returned unchanged.
+func (k *Keyring) Decrypt(value string) (string, error) {
+	if !strings.HasPrefix(value, prefix) {
+		return value, nil
+	}
+	_, dataKey, sealedValue, err := k.open(value)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := unseal(dataKey, sealedValue)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt field: %v", err)
+	}
+	return string(plaintext), nil
+}
+
+// NeedsRewrap reports whether a stored value is plaintext or sealed under a
+// key other than the active one.
+func (k *Keyring) NeedsRewrap(value string) bool {
+	if value == "" {
+		return false
+	}
+	if !strings.HasPrefix(value, prefix) {
+		return true
+	}
+	id, _, _ := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	return id != k.activeID
+}
+
+// Rewrap re-seals a value's data key under the active KEK, leaving the
+// sealed value itself untouched. Plaintext values are encrypted.
+func (k *Keyring) Rewrap(value string) (string, error) {
+	if !k.NeedsRewrap(value) {
+		return value, nil
+	}
+	if !strings.HasPrefix(value, prefix) {
+		return k.Encrypt(value)
+	}
+	_, dataKey, sealedValue, err := k.open(value)
+	if err != nil {
+		return "", err
+	}
+	sealedKey, err := seal(k.keks[k.activeID], dataKey)
+	if err != nil {
+		return "", err
+	}
+	return prefix + k.activeID + ":" + encoding.EncodeToString(sealedKey) + ":" + encoding.EncodeToString(sealedValue), nil
+}
+
+// BlindIndex returns a keyed hash of a value for equality lookups. field
+// keeps equal values in different fields from sharing an index.
+func (k *Keyring) BlindIndex(field, value string) string {
+	if value == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte(field + ":" + value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// open unseals the data key of an encrypted value.
+func (k *Keyring) open(value string) (string, []byte, []byte, error) {
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, errors.New("malformed encrypted field")
+	}
+	kek, ok := k.keks[parts[0]]
+	if !ok {
+		return "", nil, nil, fmt.Errorf("unknown field encryption key %q", parts[0])
+	}
+	sealedKey, err := encoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, nil, errors.New("malformed encrypted field")
+	}
+	sealedValue, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, nil, errors.New("malformed encrypted field")
+	}
+	dataKey, err := unseal(kek, sealedKey)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("failed to unwrap data key: %v", err)
+	}
+	return parts[0], dataKey, sealedValue, nil
+}
+
+// seal encrypts with AES-GCM and prepends the nonce.
+func seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func unseal(key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// deriveFromJWTSecret derives a key from JWT_SECRET, refusing a secret too
+// short to be a key.
+func deriveFromJWTSecret(purpose string) ([]byte, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if len(secret) < minDerivationSecretLength {
+		return nil, fmt.Errorf("JWT_SECRET is shorter than %d characters, too short to derive a key from", minDerivationSecretLength)
+	}
+	return derive(purpose, secret), nil
+}
+
+func derive(purpose, secret string) []byte {
+	sum := sha256.Sum256([]byte(purpose + ":" + secret))
+	return sum[:]
+}
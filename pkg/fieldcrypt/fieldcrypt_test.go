@@ -0,0 +1,220 @@
+package fieldcrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func newTestKeyring(t *testing.T, activeID string, keks map[string][]byte) *Keyring {
+	t.Helper()
+	keyring, err := NewKeyring(activeID, keks, testKey(9))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keyring
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	keyring := newTestKeyring(t, "a", map[string][]byte{"a": testKey(1)})
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"phone", "+2348031234567"},
+		{"bvn", "22212345678"},
+		{"unicode", "Adébáyọ̀"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sealed, err := keyring.Encrypt(tt.value)
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(sealed, "enc:v1:a:"))
+			assert.NotContains(t, sealed, tt.value)
+
+			opened, err := keyring.Decrypt(sealed)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.value, opened)
+		})
+	}
+
+	t.Run("each value gets a fresh data key", func(t *testing.T) {
+		first, _ := keyring.Encrypt("22212345678")
+		second, _ := keyring.Encrypt("22212345678")
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("empty stays empty", func(t *testing.T) {
+		sealed, err := keyring.Encrypt("")
+		assert.NoError(t, err)
+		assert.Equal(t, "", sealed)
+	})
+
+	t.Run("plaintext passes through", func(t *testing.T) {
+		opened, err := keyring.Decrypt("08031234567")
+		assert.NoError(t, err)
+		assert.Equal(t, "08031234567", opened)
+	})
+}
+
+func TestDecryptErrors(t *testing.T) {
+	keyring := newTestKeyring(t, "a", map[string][]byte{"a": testKey(1)})
+	sealed, err := keyring.Encrypt("22212345678")
+	assert.NoError(t, err)
+	parts := strings.Split(sealed, ":")
+	tampered := []byte(parts[4])
+	tampered[0] ^= 'A' ^ 'B'
+	if tampered[0] == parts[4][0] {
+		tampered[0] = 'C'
+	}
+
+	other := newTestKeyring(t, "b", map[string][]byte{"b": testKey(2)})
+	wrongKey := newTestKeyring(t, "a", map[string][]byte{"a": testKey(3)})
+
+	tests := []struct {
+		name    string
+		keyring *Keyring
+		value   string
+	}{
+		{"unknown key", other, sealed},
+		{"wrong key under the same ID", wrongKey, sealed},
+		{"malformed", keyring, "enc:v1:a:only-two"},
+		{"bad base64", keyring, "enc:v1:a:!!!:!!!"},
+		{"tampered value", keyring, strings.Join(append(parts[:4:4], string(tampered)), ":")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.keyring.Decrypt(tt.value)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestRewrap(t *testing.T) {
+	old := newTestKeyring(t, "2025a", map[string][]byte{"2025a": testKey(1)})
+	rotated := newTestKeyring(t, "2025b", map[string][]byte{"2025b": testKey(2), "2025a": testKey(1)})
+
+	sealedOld, err := old.Encrypt("22212345678")
+	assert.NoError(t, err)
+	sealedNew, err := rotated.Encrypt("22212345678")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		value       string
+		needsRewrap bool
+		want        string
+	}{
+		{"sealed under a retired key", sealedOld, true, "22212345678"},
+		{"sealed under the active key", sealedNew, false, "22212345678"},
+		{"legacy plaintext", "22212345678", true, "22212345678"},
+		{"empty", "", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.needsRewrap, rotated.NeedsRewrap(tt.value))
+
+			rewrapped, err := rotated.Rewrap(tt.value)
+			assert.NoError(t, err)
+			assert.False(t, rotated.NeedsRewrap(rewrapped))
+			if !tt.needsRewrap {
+				assert.Equal(t, tt.value, rewrapped)
+			}
+			opened, err := rotated.Decrypt(rewrapped)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, opened)
+		})
+	}
+
+	t.Run("only the data key is resealed", func(t *testing.T) {
+		rewrapped, err := rotated.Rewrap(sealedOld)
+		assert.NoError(t, err)
+		assert.Equal(t, strings.Split(sealedOld, ":")[4], strings.Split(rewrapped, ":")[4])
+	})
+}
+
+func TestBlindIndex(t *testing.T) {
+	keyring := newTestKeyring(t, "a", map[string][]byte{"a": testKey(1)})
+	other, err := NewKeyring("a", map[string][]byte{"a": testKey(1)}, testKey(8))
+	assert.NoError(t, err)
+
+	index := keyring.BlindIndex("phone", "+2348031234567")
+	assert.Len(t, index, 64)
+	assert.Equal(t, index, keyring.BlindIndex("phone", "+2348031234567"))
+	assert.NotEqual(t, index, keyring.BlindIndex("bvn", "+2348031234567"), "fields are kept apart")
+	assert.NotEqual(t, index, other.BlindIndex("phone", "+2348031234567"), "depends on the index key")
+	assert.Equal(t, "", keyring.BlindIndex("phone", ""))
+}
+
+func TestNewKeyring(t *testing.T) {
+	tests := []struct {
+		name     string
+		activeID string
+		keks     map[string][]byte
+		indexKey []byte
+		wantErr  string
+	}{
+		{"valid", "a", map[string][]byte{"a": testKey(1)}, testKey(9), ""},
+		{"missing active key", "b", map[string][]byte{"a": testKey(1)}, testKey(9), `active key "b" is not in the keyring`},
+		{"key ID with a colon", "a:1", map[string][]byte{"a:1": testKey(1)}, testKey(9), `invalid key ID "a:1"`},
+		{"short key", "a", map[string][]byte{"a": testKey(1)[:16]}, testKey(9), `key "a" must be 32 bytes`},
+		{"short index key", "a", map[string][]byte{"a": testKey(1)}, testKey(9)[:16], "index key must be at least 32 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeyring(tt.activeID, tt.keks, tt.indexKey)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyringFromEnv(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(testKey(1))
+	longSecret := strings.Repeat("s", 32)
+
+	tests := []struct {
+		name       string
+		piiKeys    string
+		indexKey   string
+		jwtSecret  string
+		wantActive string
+		wantErr    string
+	}{
+		{"explicit keys", "b:" + key + ",a:" + key, key, "", "b", ""},
+		{"derived from a long JWT secret", "", "", longSecret, "default", ""},
+		{"no keys and no JWT secret", "", "", "", "", "PII_KEYS is not set and JWT_SECRET is shorter than 32 characters, too short to derive a key from"},
+		{"no keys and a short JWT secret", "", key, "secret", "", "PII_KEYS is not set and JWT_SECRET is shorter than 32 characters, too short to derive a key from"},
+		{"no index key and a short JWT secret", "a:" + key, "", "secret", "", "PII_INDEX_KEY is not set and JWT_SECRET is shorter than 32 characters, too short to derive a key from"},
+		{"entry without an ID", key, key, "", "", "must be id:base64key"},
+		{"bad base64", "a:not base64", key, "", "", `PII_KEYS key "a" is not valid base64`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PII_KEYS", tt.piiKeys)
+			t.Setenv("PII_INDEX_KEY", tt.indexKey)
+			t.Setenv("JWT_SECRET", tt.jwtSecret)
+
+			keyring, err := KeyringFromEnv()
+			if tt.wantErr != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantActive, keyring.activeID)
+		})
+	}
+}
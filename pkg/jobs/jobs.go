@@ -2,27 +2,55 @@ package jobs
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"time"
 
 	"github.com/Gerard-007/ajor_app/internal/models"
 	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/Gerard-007/ajor_app/internal/services"
+	"github.com/Gerard-007/ajor_app/pkg/messages"
+	"github.com/Gerard-007/ajor_app/pkg/payment"
+	"github.com/Gerard-007/ajor_app/pkg/schedule"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// Register adds the application's background jobs to the scheduler.
+func Register(s *Scheduler, pg payment.PaymentGateway) error {
+	for _, job := range []Job{
+		{Name: "advance_collection_deadlines", Schedule: "0 0 * * *", Func: AdvanceCollectionDeadlines, CatchUp: true, Retries: 2},
+		{Name: "process_collections", Schedule: "0 0 * * *", Func: ProcessCollections, CatchUp: true, Retries: 2},
+		{Name: "process_loans", Schedule: "0 0 * * *", Func: ProcessLoans, CatchUp: true, Retries: 2},
+		{Name: "rotate_pii_keys", Schedule: "0 0 * * *", Func: RotatePIIKeys, CatchUp: true, Retries: 2},
+		{Name: "rotate_signing_keys", Schedule: "0 0 * * *", Func: RotateSigningKeys, CatchUp: true, Retries: 2},
+		// Every instance keeps its own copy of the signing keys
+		{Name: "reload_signing_keys", Schedule: "* * * * *", Func: ReloadSigningKeys, Local: true},
+		{Name: "deliver_notifications", Schedule: "* * * * *", Func: DeliverNotifications},
+		{Name: "relay_outbox", Schedule: "* * * * *", Func: RelayOutbox},
+		{Name: "settle_withdrawals", Schedule: "*/5 * * * *", Func: SettleWithdrawals(pg)},
+		// Often enough for reminders a couple of hours before a deadline
+		{Name: "send_contribution_reminders", Schedule: "*/15 * * * *", Func: SendContributionReminders, CatchUp: true, Retries: 1},
+	} {
+		if err := s.Register(job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // ProcessCollections checks for collections due today and processes them (e.g., sends notifications).
-func ProcessCollections(db *mongo.Database) error {
-	ctx := context.Background()
+func ProcessCollections(ctx context.Context, db *mongo.Database, run *Run) error {
 	collectionColl := db.Collection("collections")
 
-	// Find collections due today
-	today := time.Now().Truncate(24 * time.Hour)
-	tomorrow := today.Add(24 * time.Hour)
+	// "Today" depends on each contribution's timezone, so take every
+	// collection that could be due today anywhere and check each against
+	// its own contribution's day below
+	now := time.Now()
 	filter := bson.M{
 		"collection_date": bson.M{
-			"$gte": today,
-			"$lt":  tomorrow,
+			"$gte": now.Add(-48 * time.Hour),
+			"$lt":  now.Add(48 * time.Hour),
 		},
 	}
 
@@ -35,14 +63,18 @@ func ProcessCollections(db *mongo.Database) error {
 	for cursor.Next(ctx) {
 		var collection models.Collection
 		if err := cursor.Decode(&collection); err != nil {
-			log.Printf("Failed to decode collection: %v", err)
+			run.Errorf("Failed to decode collection: %v", err)
 			continue
 		}
 
 		// Get the associated contribution
 		contribution, err := repository.GetContributionByID(ctx, db, collection.ContributionID)
 		if err != nil {
-			log.Printf("Failed to get contribution %s: %v", collection.ContributionID.Hex(), err)
+			run.Errorf("Failed to get contribution %s: %v", collection.ContributionID.Hex(), err)
+			continue
+		}
+		today := schedule.StartOfDay(now, schedule.FromContribution(contribution, nil).Location)
+		if collection.CollectionDate.Before(today) || !collection.CollectionDate.Before(today.AddDate(0, 0, 1)) {
 			continue
 		}
 
@@ -50,15 +82,20 @@ func ProcessCollections(db *mongo.Database) error {
 		notification := &models.Notification{
 			UserID:         collection.Collector,
 			ContributionID: collection.ContributionID,
-			Message:        "Reminder: Collection due today for group: " + contribution.Name,
+			Template:       messages.CollectionDue,
+			Params:         messages.Params{"Group": contribution.Name},
 			Type:           models.NotificationInfo,
+			Category:       models.CategoryPaymentReminders,
 			CreatedAt:      time.Now(),
+			// A retried run does not remind the collector again
+			Key: fmt.Sprintf("collection_due:%s:%s", collection.ID.Hex(), today.Format("2006-01-02")),
 		}
-		if err := repository.CreateNotification(ctx, db, notification); err != nil {
-			log.Printf("Failed to create notification for user %s: %v", collection.Collector.Hex(), err)
+		if err := services.Notify(ctx, db, notification); err != nil {
+			run.Errorf("Failed to create notification for user %s: %v", collection.Collector.Hex(), err)
 			continue
 		}
 
+		run.Add(1)
 		log.Printf("Processed collection %s for contribution %s", collection.ID.Hex(), contribution.Name)
 	}
 
@@ -67,4 +104,109 @@ func ProcessCollections(db *mongo.Database) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// SendContributionReminders reminds unpaid members before and after each
+// collection deadline and escalates to the group admin.
+func SendContributionReminders(ctx context.Context, db *mongo.Database, run *Run) error {
+	sent, err := services.SendContributionReminders(ctx, db, time.Now())
+	run.Add(sent)
+	return err
+}
+
+// AdvanceCollectionDeadlines rolls every contribution whose collection deadline
+// has passed forward to its next deadline.
+func AdvanceCollectionDeadlines(ctx context.Context, db *mongo.Database, run *Run) error {
+	now := time.Now()
+
+	contributions, err := repository.GetContributionsWithDeadlineBefore(ctx, db, now)
+	if err != nil {
+		return err
+	}
+
+	holidays, err := repository.GetHolidays(ctx, db)
+	if err != nil {
+		return err
+	}
+	calendar := schedule.NewCalendar(holidays)
+
+	for _, contribution := range contributions {
+		deadline := schedule.FromContribution(contribution, calendar).Next(now)
+		if err := repository.UpdateCollectionDeadline(ctx, db, contribution.ID, deadline); err != nil {
+			run.Errorf("Failed to advance deadline for contribution %s: %v", contribution.ID.Hex(), err)
+			continue
+		}
+		run.Add(1)
+		log.Printf("Advanced deadline for contribution %s to %s", contribution.Name, deadline.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// ProcessLoans accrues late interest on active loans and flags overdue
+// installments.
+func ProcessLoans(ctx context.Context, db *mongo.Database, run *Run) error {
+	updated, err := services.AccrueLoans(ctx, db, time.Now())
+	run.Add(updated)
+	return err
+}
+
+// RotatePIIKeys re-encrypts user phone numbers and BVNs still stored in
+// plaintext or under a retired key, so old keys can be removed from
+// PII_KEYS once it has run. Phone numbers saved in another form are
+// normalised on the way.
+func RotatePIIKeys(ctx context.Context, db *mongo.Database, run *Run) error {
+	updated, err := repository.RewrapUserPII(ctx, db)
+	run.Add(updated)
+	if updated > 0 {
+		log.Printf("Re-encrypted personal details of %d users", updated)
+	}
+	return err
+}
+
+// RotateSigningKeys adds a new access token signing key when the current
+// one is due for rotation, and deletes keys no longer needed to verify.
+func RotateSigningKeys(ctx context.Context, db *mongo.Database, run *Run) error {
+	return services.RotateSigningKeys(ctx, db)
+}
+
+// ReloadSigningKeys picks up keys another instance has added or removed.
+func ReloadSigningKeys(ctx context.Context, db *mongo.Database, run *Run) error {
+	return services.LoadSigningKeys(ctx, db)
+}
+
+// DeliverNotifications sends notification deliveries that are due,
+// including retries of ones that failed before.
+func DeliverNotifications(ctx context.Context, db *mongo.Database, run *Run) error {
+	delivered, err := services.DeliverNotifications(ctx, db, nil)
+	run.Add(delivered)
+	if delivered > 0 {
+		log.Printf("Attempted %d notification deliveries", delivered)
+	}
+	return err
+}
+
+// RelayOutbox publishes the side effects of committed changes that were
+// not published straight away, and retries those that failed.
+func RelayOutbox(ctx context.Context, db *mongo.Database, run *Run) error {
+	relayed, err := services.RelayOutbox(ctx, db)
+	run.Add(relayed)
+	if relayed > 0 {
+		log.Printf("Relayed %d outbox entries", relayed)
+	}
+	return err
+}
+
+// SettleWithdrawals returns a job that marks bank payouts successful once
+// the payment provider has completed them, and refunds those that failed.
+// Accounts waiting on their closure payout are closed or reopened with it.
+func SettleWithdrawals(pg payment.PaymentGateway) Func {
+	return func(ctx context.Context, db *mongo.Database, run *Run) error {
+		settled, err := services.SettleWithdrawals(ctx, db, pg)
+		run.Add(settled)
+		if settled > 0 {
+			log.Printf("Settled %d withdrawals", settled)
+		}
+		return err
+	}
+}
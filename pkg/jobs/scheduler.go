@@ -0,0 +1,492 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/Gerard-007/ajor_app/internal/repository"
+	"github.com/robfig/cron/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// jobLeaseTTL is how long a lease lasts without renewal, and so how soon
+	// another instance can take over a job whose instance died. Leases are
+	// renewed every third of it while the job runs.
+	jobLeaseTTL = time.Minute
+	// defaultJobTimeout bounds one attempt of a job that sets no Timeout.
+	defaultJobTimeout = 10 * time.Minute
+	// jobRetryBase is the wait before the first retry; it doubles with each
+	// further one.
+	jobRetryBase = 30 * time.Second
+	// maxJobRunErrors caps the errors kept on one run record.
+	maxJobRunErrors = 50
+)
+
+// Func does a job's work, reporting what it processed through run. An error
+// fails the attempt, which is retried up to the job's Retries.
+type Func func(ctx context.Context, db *mongo.Database, run *Run) error
+
+// Job is a named background job run on a cron schedule.
+type Job struct {
+	Name     string
+	Schedule string // standard five-field cron spec, in the server's local time
+	Func     Func
+	// Local jobs run on every instance and take no lease, for work such as
+	// refreshing in-memory state.
+	Local bool
+	// CatchUp runs the job at startup if a scheduled run was missed while
+	// no instance was up.
+	CatchUp bool
+	Retries int           // attempts after the first that fails
+	Timeout time.Duration // bounds each attempt; defaultJobTimeout if zero
+}
+
+func (j *Job) timeout() time.Duration {
+	if j.Timeout > 0 {
+		return j.Timeout
+	}
+	return defaultJobTimeout
+}
+
+// Run is the record of a run in progress. Jobs report the items they
+// processed and the ones that failed through it.
+type Run struct {
+	mu     sync.Mutex
+	record *models.JobRun
+}
+
+// Add counts n more items processed.
+func (r *Run) Add(n int) {
+	r.mu.Lock()
+	r.record.Items += n
+	r.mu.Unlock()
+}
+
+// Errorf records an item that failed without failing the run, and logs it.
+func (r *Run) Errorf(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	log.Printf("Job %s: %s", r.record.Job, message)
+	r.addError(message)
+}
+
+func (r *Run) addError(message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.record.Errors) < maxJobRunErrors {
+		r.record.Errors = append(r.record.Errors, message)
+	}
+}
+
+func (r *Run) snapshot() *models.JobRun {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record := *r.record
+	record.Errors = append([]string(nil), r.record.Errors...)
+	return &record
+}
+
+// JobStatus describes a registered job for the admin API.
+type JobStatus struct {
+	Name            string         `json:"name"`
+	Schedule        string         `json:"schedule"`
+	Local           bool           `json:"local"`
+	CatchUp         bool           `json:"catch_up"`
+	Retries         int            `json:"retries"`
+	Running         bool           `json:"running"`
+	LeaseOwner      string         `json:"lease_owner,omitempty"`
+	LastScheduledAt *time.Time     `json:"last_scheduled_at,omitempty"`
+	NextRunAt       time.Time      `json:"next_run_at"`
+	LastRun         *models.JobRun `json:"last_run,omitempty"`
+}
+
+// Scheduler runs registered jobs on their schedules. Jobs that are not
+// Local hold a lease in Mongo while they run, so with several instances
+// each scheduled run happens on only one of them. Every run is recorded in
+// job_runs.
+type Scheduler struct {
+	db       *mongo.Database
+	instance string
+	cron     *cron.Cron
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	entries map[string]cron.EntryID
+	active  map[string]bool
+}
+
+func NewScheduler(db *mongo.Database) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		db:       db,
+		instance: instanceName(),
+		cron:     cron.New(),
+		ctx:      ctx,
+		cancel:   cancel,
+		jobs:     map[string]*Job{},
+		entries:  map[string]cron.EntryID{},
+		active:   map[string]bool{},
+	}
+}
+
+// instanceName identifies this process as a lease owner.
+func instanceName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return host + "-" + hex.EncodeToString(suffix)
+}
+
+// Register adds a job to the schedule.
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" || job.Func == nil {
+		return errors.New("job needs a name and a func")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[job.Name]; ok {
+		return fmt.Errorf("job %s already registered", job.Name)
+	}
+	registered := &job
+	id, err := s.cron.AddFunc(job.Schedule, func() { s.runScheduled(registered) })
+	if err != nil {
+		return fmt.Errorf("invalid schedule for job %s: %v", job.Name, err)
+	}
+	s.jobs[job.Name] = registered
+	s.entries[job.Name] = id
+	return nil
+}
+
+// Start runs jobs that missed a scheduled run while no instance was up and
+// starts the schedule.
+func (s *Scheduler) Start() {
+	s.cron.Start()
+	go s.catchUp()
+}
+
+// Stop stops the schedule, cancels running jobs and waits for them to
+// record how they ended.
+func (s *Scheduler) Stop() {
+	stopped := s.cron.Stop()
+	s.cancel()
+	<-stopped.Done()
+	s.running.Wait()
+}
+
+// runScheduled runs a job for the tick cron fired it for. Ticks fall on
+// whole minutes, so every instance names the same tick alike.
+func (s *Scheduler) runScheduled(job *Job) {
+	scheduledAt := time.Now().Truncate(time.Minute)
+	if _, err := s.start(job, models.JobTriggerSchedule, &scheduledAt, nil, false); err != nil && err.Error() != "job already running" {
+		log.Printf("Failed to start job %s: %v", job.Name, err)
+	}
+}
+
+// catchUp runs, once each, the jobs that opt in and whose latest scheduled
+// time passed without a run being started for it.
+func (s *Scheduler) catchUp() {
+	now := time.Now()
+	for _, job := range s.registered() {
+		if job.Local || !job.CatchUp {
+			continue
+		}
+		lease, err := repository.GetJobLease(s.ctx, s.db, job.Name)
+		if err != nil {
+			// Never run, so there is nothing to catch up on
+			if err.Error() != "job lease not found" {
+				log.Printf("Failed to check missed runs of job %s: %v", job.Name, err)
+			}
+			continue
+		}
+		if lease.LastScheduledAt == nil {
+			continue
+		}
+		schedule, err := cron.ParseStandard(job.Schedule)
+		if err != nil {
+			continue
+		}
+		var missed time.Time
+		for next := schedule.Next(lease.LastScheduledAt.In(time.Local)); !next.After(now); next = schedule.Next(next) {
+			missed = next
+		}
+		if missed.IsZero() {
+			continue
+		}
+		log.Printf("Catching up job %s, missed at %s", job.Name, missed.Format(time.RFC3339))
+		if _, err := s.start(job, models.JobTriggerCatchUp, &missed, nil, false); err != nil && err.Error() != "job already running" {
+			log.Printf("Failed to catch up job %s: %v", job.Name, err)
+		}
+	}
+}
+
+// Trigger starts a run of the named job now, in the background, on behalf
+// of an admin.
+func (s *Scheduler) Trigger(ctx context.Context, name string, actorID primitive.ObjectID) (*models.JobRun, error) {
+	job, ok := s.job(name)
+	if !ok {
+		return nil, errors.New("job not found")
+	}
+	run, err := s.start(job, models.JobTriggerManual, nil, &actorID, true)
+	if err != nil {
+		return nil, err
+	}
+	event := &models.AuditEvent{
+		Type:      models.AuditJobTriggered,
+		ActorID:   &actorID,
+		Subject:   name,
+		Details:   map[string]interface{}{"run_id": run.ID.Hex()},
+		CreatedAt: time.Now(),
+	}
+	if err := repository.CreateAuditEvent(ctx, s.db, event); err != nil {
+		log.Printf("Failed to record %s audit event: %v", event.Type, err)
+	}
+	return run, nil
+}
+
+// start claims the job and records a run of it, then runs it, in the
+// background if async. It fails with "job already running" if this or
+// another instance is running it, or has already run the scheduled time.
+func (s *Scheduler) start(job *Job, trigger models.JobTrigger, scheduledAt *time.Time, triggeredBy *primitive.ObjectID, async bool) (*models.JobRun, error) {
+	s.mu.Lock()
+	if s.active[job.Name] {
+		s.mu.Unlock()
+		return nil, errors.New("job already running")
+	}
+	s.active[job.Name] = true
+	s.mu.Unlock()
+	release := func() {
+		s.mu.Lock()
+		delete(s.active, job.Name)
+		s.mu.Unlock()
+	}
+
+	now := time.Now()
+	if !job.Local {
+		acquired, err := repository.AcquireJobLease(s.ctx, s.db, job.Name, s.instance, now, jobLeaseTTL, scheduledAt)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		if !acquired {
+			release()
+			return nil, errors.New("job already running")
+		}
+		if abandoned, err := repository.AbandonJobRuns(s.ctx, s.db, job.Name, now); err != nil {
+			log.Printf("Failed to close abandoned runs of job %s: %v", job.Name, err)
+		} else if abandoned > 0 {
+			log.Printf("Marked %d runs of job %s abandoned", abandoned, job.Name)
+		}
+	}
+
+	run := &Run{record: &models.JobRun{
+		Job:         job.Name,
+		Instance:    s.instance,
+		Trigger:     trigger,
+		TriggeredBy: triggeredBy,
+		ScheduledAt: scheduledAt,
+		Status:      models.JobRunRunning,
+		StartedAt:   now,
+	}}
+	if err := repository.CreateJobRun(s.ctx, s.db, run.record); err != nil {
+		s.releaseLease(job)
+		release()
+		return nil, err
+	}
+
+	s.running.Add(1)
+	execute := func() {
+		defer s.running.Done()
+		defer release()
+		s.execute(job, run)
+	}
+	if async {
+		go execute()
+	} else {
+		execute()
+	}
+	return run.snapshot(), nil
+}
+
+// execute makes attempts at the job until one succeeds or its retries run
+// out, keeping the lease while it does, and records the outcome.
+func (s *Scheduler) execute(job *Job, run *Run) {
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	if !job.Local {
+		go s.keepLease(ctx, cancel, job)
+	}
+
+	status := models.JobRunFailed
+	for attempt := 1; ; attempt++ {
+		run.mu.Lock()
+		run.record.Attempts = attempt
+		run.mu.Unlock()
+
+		attemptCtx, cancelAttempt := context.WithTimeout(ctx, job.timeout())
+		err := job.Func(attemptCtx, s.db, run)
+		cancelAttempt()
+		if err == nil {
+			status = models.JobRunSucceeded
+			break
+		}
+		run.addError(fmt.Sprintf("attempt %d: %v", attempt, err))
+		log.Printf("Job %s failed on attempt %d: %v", job.Name, attempt, err)
+		if ctx.Err() != nil {
+			status = models.JobRunAbandoned
+			break
+		}
+		if attempt > job.Retries {
+			break
+		}
+		if err := repository.UpdateJobRun(ctx, s.db, run.snapshot()); err != nil {
+			log.Printf("Failed to update run of job %s: %v", job.Name, err)
+		}
+		select {
+		case <-time.After(jobRetryBase << (attempt - 1)):
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			status = models.JobRunAbandoned
+			break
+		}
+	}
+
+	finishedAt := time.Now()
+	run.mu.Lock()
+	run.record.Status = status
+	run.record.FinishedAt = &finishedAt
+	run.mu.Unlock()
+	if err := repository.UpdateJobRun(context.Background(), s.db, run.snapshot()); err != nil {
+		log.Printf("Failed to record run of job %s: %v", job.Name, err)
+	}
+	if !job.Local {
+		s.releaseLease(job)
+	}
+}
+
+// keepLease renews the job's lease until ctx is done, and cancels the run
+// if the lease is lost, since another instance may then start it.
+func (s *Scheduler) keepLease(ctx context.Context, cancel context.CancelFunc, job *Job) {
+	ticker := time.NewTicker(jobLeaseTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			held, err := repository.RenewJobLease(ctx, s.db, job.Name, s.instance, time.Now().Add(jobLeaseTTL))
+			if err != nil {
+				log.Printf("Failed to renew lease of job %s: %v", job.Name, err)
+				continue
+			}
+			if !held {
+				log.Printf("Lost lease of job %s, stopping run", job.Name)
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+func (s *Scheduler) releaseLease(job *Job) {
+	if err := repository.ReleaseJobLease(context.Background(), s.db, job.Name, s.instance, time.Now()); err != nil {
+		log.Printf("Failed to release lease of job %s: %v", job.Name, err)
+	}
+}
+
+func (s *Scheduler) job(name string) (*Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[name]
+	return job, ok
+}
+
+func (s *Scheduler) registered() []*Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]*Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Name < jobs[j].Name })
+	return jobs
+}
+
+// Jobs describes every registered job with its lease and latest run.
+func (s *Scheduler) Jobs(ctx context.Context) ([]*JobStatus, error) {
+	leases, err := repository.GetJobLeases(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+	leaseByJob := make(map[string]*models.JobLease, len(leases))
+	for _, lease := range leases {
+		leaseByJob[lease.Job] = lease
+	}
+
+	now := time.Now()
+	statuses := []*JobStatus{}
+	for _, job := range s.registered() {
+		status := &JobStatus{
+			Name:     job.Name,
+			Schedule: job.Schedule,
+			Local:    job.Local,
+			CatchUp:  job.CatchUp,
+			Retries:  job.Retries,
+		}
+		s.mu.Lock()
+		status.Running = s.active[job.Name]
+		entry := s.cron.Entry(s.entries[job.Name])
+		s.mu.Unlock()
+		status.NextRunAt = entry.Next
+		if lease, ok := leaseByJob[job.Name]; ok {
+			status.LastScheduledAt = lease.LastScheduledAt
+			if lease.ExpiresAt.After(now) {
+				status.Running = true
+				status.LeaseOwner = lease.Owner
+			}
+		}
+		runs, err := repository.GetJobRuns(ctx, s.db, bson.M{"job": job.Name}, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(runs) > 0 {
+			status.LastRun = runs[0]
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Runs returns up to limit runs, newest first, of one job or all of them
+// if name is empty, optionally only those with the given status.
+func (s *Scheduler) Runs(ctx context.Context, name string, status models.JobRunStatus, limit int64) ([]*models.JobRun, error) {
+	filter := bson.M{}
+	if name != "" {
+		if _, ok := s.job(name); !ok {
+			return nil, errors.New("job not found")
+		}
+		filter["job"] = name
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+	return repository.GetJobRuns(ctx, s.db, filter, limit)
+}
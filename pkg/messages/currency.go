@@ -0,0 +1,31 @@
+package messages
+
+import (
+	"math"
+	"strconv"
+)
+
+// FormatNaira formats an amount as naira with thousands separators and
+// kobo, such as ₦1,250,000.00.
+func FormatNaira(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	kobo := int64(math.Round(amount * 100))
+	naira := strconv.FormatInt(kobo/100, 10)
+
+	grouped := make([]byte, 0, len(naira)+len(naira)/3)
+	for i := range naira {
+		if i > 0 && (len(naira)-i)%3 == 0 {
+			grouped = append(grouped, ',')
+		}
+		grouped = append(grouped, naira[i])
+	}
+	fraction := strconv.FormatInt(kobo%100, 10)
+	if len(fraction) == 1 {
+		fraction = "0" + fraction
+	}
+	return sign + "₦" + string(grouped) + "." + fraction
+}
@@ -0,0 +1,43 @@
+package messages
+
+var english = map[string]string{
+	PINChanged:                   "Your transaction PIN was changed. Contact support if this was not you.",
+	MFAEnabled:                   "Two-factor authentication was turned on for your account.",
+	MFADisabled:                  "Two-factor authentication was turned off for your account. Contact support if this was not you.",
+	PasswordChanged:              "Your password was changed and all devices were signed out. Contact support if this was not you.",
+	KYCUpgraded:                  "Your account has been upgraded to KYC tier {{.Tier}}.",
+	KYCRejected:                  "Your request for KYC tier {{.Tier}} was not approved.{{if .Reason}} Reason: {{.Reason}}{{end}}",
+	GuaranteeRequested:           "You have been asked to guarantee a member joining group: {{.Group}}. You will be liable for up to their missed contributions of {{naira .Amount}} per cycle",
+	GuaranteeDeclined:            "A guarantor declined your request to join group: {{.Group}}. Join again with another guarantor to continue",
+	GuaranteeClaimFiled:          "A claim of {{naira .Amount}} has been filed against your guarantee in group {{.Group}} after the member missed {{.Cycles}} contributions",
+	GuaranteeClaimUnfunded:       "A guarantee claim of {{naira .Amount}} in group {{.Group}} was approved but the guarantor's wallet has insufficient balance",
+	GuaranteeGuarantorCharged:    "{{naira .Amount}} has been debited from your wallet under your guarantee in group: {{.Group}}",
+	GuaranteeMemberCovered:       "Your guarantor has been charged {{naira .Amount}} for your missed contributions in group: {{.Group}}",
+	ContributionLate:             "Late contribution recorded. Penalty applied: {{naira .Penalty}}",
+	ContributionReminder:         "Reminder: your contribution of {{naira .Amount}} to {{.Group}} is due by {{datetime .Deadline}}",
+	ContributionOverdue:          "Your contribution of {{naira .Amount}} to {{.Group}} was due by {{datetime .Deadline}} and has not been paid.{{if .Penalty}} A late penalty of {{naira .Penalty}} applies.{{end}}",
+	ContributionOverdueAdmin:     "{{.Member}} has not paid their contribution of {{naira .Amount}} to {{.Group}}, {{.Days}} days after the deadline",
+	PayoutRequested:              "Payout of {{naira .Amount}} requested for contribution: {{.Group}}",
+	PayoutApproved:               "Payout of {{naira .Amount}} approved for contribution",
+	CollectionScheduled:          "You are scheduled to collect for group: {{.Group}} on {{date .Date}}",
+	CollectionDue:                "Reminder: Collection due today for group: {{.Group}}",
+	MemberJoined:                 "A new member has joined your contribution group: {{.Group}}",
+	MemberRemoved:                "You have been removed from the contribution group: {{.Group}}",
+	LoanRequested:                "A member has requested a loan of {{naira .Amount}} from group: {{.Group}}",
+	LoanDeclined:                 "Your loan request of {{naira .Amount}} was declined by group: {{.Group}}",
+	LoanDisbursed:                "Your loan of {{naira .Amount}} from group {{.Group}} has been paid into your wallet. First repayment is due on {{date .DueDate}}",
+	LoanPayoutDeduction:          "{{naira .Amount}} was deducted from your payout to repay your loan. Outstanding balance: {{naira .Outstanding}}",
+	LoanOverdue:                  "Your loan repayment of {{naira .Amount}} to group {{.Group}} is overdue",
+	LoanOverdueAdmin:             "A member has an overdue loan repayment of {{naira .Amount}} in group: {{.Group}}",
+	OrganisationStaffJoined:      "A new staff member has joined your organisation: {{.Organisation}}",
+	OrganisationContributionPaid: "{{.Organisation}} paid your contribution of {{naira .Amount}} to {{.Group}}",
+	AccountClosureFailed:         "We could not pay out {{naira .Amount}} to close your account. The money is back in your wallet and your account stays open.",
+	OTPPhoneVerification:         "Your AjoR verification code is {{.Code}}. It expires in 10 minutes.",
+	OTPPINReset:                  "Your AjoR transaction PIN reset code is {{.Code}}. It expires in 10 minutes.",
+	PasswordResetToken:           "Your AjoR password reset token is {{.Token}}. It expires in 30 minutes. Ignore this if you did not ask to reset your password.",
+
+	SubjectInfo:    "Ajor: update",
+	SubjectWarning: "Ajor: action needed",
+	SubjectError:   "Ajor: something went wrong",
+	SubjectDigest:  "Ajor: {{.Count}} updates",
+}
@@ -0,0 +1,43 @@
+package messages
+
+var hausa = map[string]string{
+	PINChanged:                   "An canza PIN ɗin cinikinka. Tuntuɓi tallafi idan ba kai ba ne.",
+	MFAEnabled:                   "An kunna tabbatarwa ta matakai biyu a asusunka.",
+	MFADisabled:                  "An kashe tabbatarwa ta matakai biyu a asusunka. Tuntuɓi tallafi idan ba kai ba ne.",
+	PasswordChanged:              "An canza kalmar sirrinka kuma an fitar da kai daga duk na'urori. Tuntuɓi tallafi idan ba kai ba ne.",
+	KYCUpgraded:                  "An ɗaga asusunka zuwa matakin KYC {{.Tier}}.",
+	KYCRejected:                  "Ba a amince da buƙatarka ta matakin KYC {{.Tier}} ba.{{if .Reason}} Dalili: {{.Reason}}{{end}}",
+	GuaranteeRequested:           "An nemi ka tsaya a matsayin mai lamuni ga memban da ke shiga ƙungiya: {{.Group}}. Kai ne za ka biya har {{naira .Amount}} a kowane zagaye da bai biya ba",
+	GuaranteeDeclined:            "Mai lamuni ya ƙi buƙatarka ta shiga ƙungiya: {{.Group}}. Sake shiga da wani mai lamuni",
+	GuaranteeClaimFiled:          "An nemi {{naira .Amount}} a kan lamuninka a ƙungiyar {{.Group}} bayan memban ya rasa biyan gudummawa {{.Cycles}}",
+	GuaranteeClaimUnfunded:       "An amince da buƙatar lamuni ta {{naira .Amount}} a ƙungiyar {{.Group}} amma babu isasshen kuɗi a walat ɗin mai lamuni",
+	GuaranteeGuarantorCharged:    "An cire {{naira .Amount}} daga walat ɗinka saboda lamuninka a ƙungiya: {{.Group}}",
+	GuaranteeMemberCovered:       "An caji mai lamuninka {{naira .Amount}} saboda gudummawar da ka rasa a ƙungiya: {{.Group}}",
+	ContributionLate:             "An karɓi gudummawarka a makare. Tarar da aka sanya: {{naira .Penalty}}",
+	ContributionReminder:         "Tunatarwa: gudummawarka ta {{naira .Amount}} ga {{.Group}} za ta cika ranar {{datetime .Deadline}}",
+	ContributionOverdue:          "Gudummawarka ta {{naira .Amount}} ga {{.Group}} ta cika ranar {{datetime .Deadline}} amma ba ka biya ba.{{if .Penalty}} Za a sanya tarar jinkiri ta {{naira .Penalty}}.{{end}}",
+	ContributionOverdueAdmin:     "{{.Member}} bai biya gudummawarsa ta {{naira .Amount}} ga {{.Group}} ba, kwanaki {{.Days}} bayan wa'adin",
+	PayoutRequested:              "An nemi biyan {{naira .Amount}} daga ƙungiya: {{.Group}}",
+	PayoutApproved:               "An amince da biyanka na {{naira .Amount}}",
+	CollectionScheduled:          "Kai ne za ka karɓa a ƙungiya: {{.Group}} ranar {{date .Date}}",
+	CollectionDue:                "Tunatarwa: Yau ce ranar karɓa a ƙungiya: {{.Group}}",
+	MemberJoined:                 "Sabon memba ya shiga ƙungiyarka: {{.Group}}",
+	MemberRemoved:                "An cire ka daga ƙungiya: {{.Group}}",
+	LoanRequested:                "Wani memba ya nemi rancen {{naira .Amount}} daga ƙungiya: {{.Group}}",
+	LoanDeclined:                 "Ƙungiyar {{.Group}} ta ƙi buƙatarka ta rancen {{naira .Amount}}",
+	LoanDisbursed:                "An saka rancenka na {{naira .Amount}} daga ƙungiyar {{.Group}} a walat ɗinka. Biyan farko zai cika ranar {{date .DueDate}}",
+	LoanPayoutDeduction:          "An cire {{naira .Amount}} daga kuɗin da aka biya ka don biyan rancenka. Saura: {{naira .Outstanding}}",
+	LoanOverdue:                  "Lokacin biyan rancenka na {{naira .Amount}} ga ƙungiyar {{.Group}} ya wuce",
+	LoanOverdueAdmin:             "Lokacin biyan rancen {{naira .Amount}} na wani memba a ƙungiyar {{.Group}} ya wuce",
+	OrganisationStaffJoined:      "Sabon ma'aikaci ya shiga ƙungiyarka: {{.Organisation}}",
+	OrganisationContributionPaid: "{{.Organisation}} ta biya gudummawarka ta {{naira .Amount}} ga {{.Group}}",
+	AccountClosureFailed:         "Ba mu iya biyan {{naira .Amount}} don rufe asusunka ba. Kuɗin ya koma walat ɗinka, kuma asusunka yana nan a buɗe.",
+	OTPPhoneVerification:         "Lambar tabbatarwa ta AjoR ɗinka ita ce {{.Code}}. Za ta ƙare cikin mintuna 10.",
+	OTPPINReset:                  "Lambar sake saita PIN ɗin cinikinka na AjoR ita ce {{.Code}}. Za ta ƙare cikin mintuna 10.",
+	PasswordResetToken:           "Alamar sake saita kalmar sirrinka ta AjoR ita ce {{.Token}}. Za ta ƙare cikin mintuna 30. Yi watsi da wannan idan ba kai ka nemi a sake saita kalmar sirrinka ba.",
+
+	SubjectInfo:    "Ajor: sabon labari",
+	SubjectWarning: "Ajor: akwai abin da za ka yi",
+	SubjectError:   "Ajor: wani abu ya samu matsala",
+	SubjectDigest:  "Ajor: labarai {{.Count}}",
+}
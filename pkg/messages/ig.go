@@ -0,0 +1,43 @@
+package messages
+
+var igbo = map[string]string{
+	PINChanged:                   "Agbanweela PIN azụmahịa gị. Kpọtụrụ ndị nkwado ma ọ bụrụ na ọ bụghị gị.",
+	MFAEnabled:                   "Agbanyela nkwenye abụọ maka akaụntụ gị.",
+	MFADisabled:                  "Agbanyụọla nkwenye abụọ maka akaụntụ gị. Kpọtụrụ ndị nkwado ma ọ bụrụ na ọ bụghị gị.",
+	PasswordChanged:              "Agbanweela okwuntughe gị, ewepụkwara gị na ngwaọrụ niile. Kpọtụrụ ndị nkwado ma ọ bụrụ na ọ bụghị gị.",
+	KYCUpgraded:                  "Ebulitela akaụntụ gị gaa na ọkwa KYC {{.Tier}}.",
+	KYCRejected:                  "Anabataghị arịrịọ gị maka ọkwa KYC {{.Tier}}.{{if .Reason}} Ihe kpatara ya: {{.Reason}}{{end}}",
+	GuaranteeRequested:           "A rịọrọ gị ka ị kwado onye otu na-abanye n'otu: {{.Group}}. Ị ga-akwụ ihe ruru {{naira .Amount}} kwa okirikiri ọ na-akwụghị",
+	GuaranteeDeclined:            "Onye nkwado jụrụ arịrịọ gị ịbanye n'otu: {{.Group}}. Banye ọzọ na onye nkwado ọzọ",
+	GuaranteeClaimFiled:          "Arịọla {{naira .Amount}} site na nkwado gị n'otu {{.Group}} maka na onye otu ahụ akwụghị ụgwọ {{.Cycles}}",
+	GuaranteeClaimUnfunded:       "Anabatara arịrịọ nkwado {{naira .Amount}} n'otu {{.Group}} mana ego ezughị n'obere akpa onye nkwado",
+	GuaranteeGuarantorCharged:    "Ewepụla {{naira .Amount}} n'obere akpa gị n'ihi nkwado gị n'otu: {{.Group}}",
+	GuaranteeMemberCovered:       "Ewerela {{naira .Amount}} n'aka onye nkwado gị maka ụgwọ ị kwụghị n'otu: {{.Group}}",
+	ContributionLate:             "Ụgwọ gị bịara n'azụ oge. Ntaramahụhụ: {{naira .Penalty}}",
+	ContributionReminder:         "Ncheta: ụgwọ {{naira .Amount}} gị na {{.Group}} ga-eru na {{datetime .Deadline}}",
+	ContributionOverdue:          "Ụgwọ {{naira .Amount}} gị na {{.Group}} kwesịrị ịkwụ na {{datetime .Deadline}}, ma ị kwụbeghị ya.{{if .Penalty}} Ntaramahụhụ {{naira .Penalty}} ga-adị maka ịbịa n'azụ oge.{{end}}",
+	ContributionOverdueAdmin:     "{{.Member}} akwụbeghị ụgwọ {{naira .Amount}} na {{.Group}}, ụbọchị {{.Days}} mgbe oge gafere",
+	PayoutRequested:              "Ị rịọla ka akwụọ gị {{naira .Amount}} site n'otu: {{.Group}}",
+	PayoutApproved:               "Anabatala ịkwụ gị {{naira .Amount}}",
+	CollectionScheduled:          "Ọ bụ gị ga-anata ego maka otu: {{.Group}} na {{date .Date}}",
+	CollectionDue:                "Ncheta: Taa bụ ụbọchị ịnata ego maka otu: {{.Group}}",
+	MemberJoined:                 "Onye otu ọhụrụ abanyela n'otu gị: {{.Group}}",
+	MemberRemoved:                "Ewepụla gị n'otu: {{.Group}}",
+	LoanRequested:                "Onye otu arịọla mbinye ego {{naira .Amount}} site n'otu: {{.Group}}",
+	LoanDeclined:                 "Otu {{.Group}} jụrụ arịrịọ mbinye ego {{naira .Amount}} gị",
+	LoanDisbursed:                "Etinyela mbinye ego {{naira .Amount}} sitere n'otu {{.Group}} n'obere akpa gị. Nkwụghachi mbụ ga-eru na {{date .DueDate}}",
+	LoanPayoutDeduction:          "Ewepụrụ {{naira .Amount}} n'ego akwụrụ gị iji kwụọ mbinye ego gị. Ihe fọdụrụ: {{naira .Outstanding}}",
+	LoanOverdue:                  "Oge nkwụghachi mbinye ego {{naira .Amount}} gị n'otu {{.Group}} agafeela",
+	LoanOverdueAdmin:             "Oge nkwụghachi mbinye ego {{naira .Amount}} nke onye otu n'otu {{.Group}} agafeela",
+	OrganisationStaffJoined:      "Onye ọrụ ọhụrụ abanyela n'ụlọ ọrụ gị: {{.Organisation}}",
+	OrganisationContributionPaid: "{{.Organisation}} akwụọla ụgwọ {{naira .Amount}} gị na {{.Group}}",
+	AccountClosureFailed:         "Anyị enweghị ike ịkwụ {{naira .Amount}} iji mechie akaụntụ gị. Ego ahụ alaghachila n'obere akpa gị, akaụntụ gị ka na-emeghe.",
+	OTPPhoneVerification:         "Koodu nkwenye AjoR gị bụ {{.Code}}. Ọ ga-agwụ n'ime nkeji 10.",
+	OTPPINReset:                  "Koodu ntọgharị PIN azụmahịa AjoR gị bụ {{.Code}}. Ọ ga-agwụ n'ime nkeji 10.",
+	PasswordResetToken:           "Akara ntọgharị okwuntughe AjoR gị bụ {{.Token}}. Ọ ga-agwụ n'ime nkeji 30. Leghara nke a anya ma ọ bụrụ na ọ bụghị gị rịọrọ ka etọgharịa okwuntughe gị.",
+
+	SubjectInfo:    "Ajor: ozi ọhụrụ",
+	SubjectWarning: "Ajor: ihe ị ga-eme",
+	SubjectError:   "Ajor: ihe adabaghị",
+	SubjectDigest:  "Ajor: ozi {{.Count}}",
+}
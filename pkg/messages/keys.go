@@ -0,0 +1,48 @@
+package messages
+
+// Keys of the message templates. Each names the event the message is
+// about; the params a template uses are listed beside it.
+const (
+	PINChanged                   = "pin.changed"
+	MFAEnabled                   = "mfa.enabled"
+	MFADisabled                  = "mfa.disabled"
+	PasswordChanged              = "password.changed"
+	KYCUpgraded                  = "kyc.upgraded"                   // Tier
+	KYCRejected                  = "kyc.rejected"                   // Tier, Reason
+	GuaranteeRequested           = "guarantee.requested"            // Group, Amount
+	GuaranteeDeclined            = "guarantee.declined"             // Group
+	GuaranteeClaimFiled          = "guarantee.claim_filed"          // Amount, Group, Cycles
+	GuaranteeClaimUnfunded       = "guarantee.claim_unfunded"       // Amount, Group
+	GuaranteeGuarantorCharged    = "guarantee.guarantor_charged"    // Amount, Group
+	GuaranteeMemberCovered       = "guarantee.member_covered"       // Amount, Group
+	ContributionLate             = "contribution.late"              // Penalty
+	ContributionReminder         = "contribution.reminder"          // Amount, Group, Deadline
+	ContributionOverdue          = "contribution.overdue"           // Amount, Group, Deadline, Penalty
+	ContributionOverdueAdmin     = "contribution.overdue_admin"     // Member, Amount, Group, Days
+	PayoutRequested              = "payout.requested"               // Amount, Group
+	PayoutApproved               = "payout.approved"                // Amount
+	CollectionScheduled          = "collection.scheduled"           // Group, Date
+	CollectionDue                = "collection.due"                 // Group
+	MemberJoined                 = "member.joined"                  // Group
+	MemberRemoved                = "member.removed"                 // Group
+	LoanRequested                = "loan.requested"                 // Amount, Group
+	LoanDeclined                 = "loan.declined"                  // Amount, Group
+	LoanDisbursed                = "loan.disbursed"                 // Amount, Group, DueDate
+	LoanPayoutDeduction          = "loan.payout_deduction"          // Amount, Outstanding
+	LoanOverdue                  = "loan.overdue"                   // Amount, Group
+	LoanOverdueAdmin             = "loan.overdue_admin"             // Amount, Group
+	OrganisationStaffJoined      = "organisation.staff_joined"      // Organisation
+	OrganisationContributionPaid = "organisation.contribution_paid" // Organisation, Amount, Group
+	AccountClosureFailed         = "account.closure_failed"         // Amount
+
+	// Codes texted to the user; sent straight away, not as notifications.
+	OTPPhoneVerification = "otp.phone_verification" // Code
+	OTPPINReset          = "otp.pin_reset"          // Code
+	PasswordResetToken   = "password.reset_token"   // Token
+
+	// Email subjects and push titles.
+	SubjectInfo    = "subject.info"
+	SubjectWarning = "subject.warning"
+	SubjectError   = "subject.error"
+	SubjectDigest  = "subject.digest" // Count
+)
@@ -0,0 +1,84 @@
+// Package messages renders user-facing notification text from a catalogue
+// of templates, one variant per supported locale.
+package messages
+
+import (
+	"bytes"
+	"log"
+	"text/template"
+	"time"
+)
+
+// Locale is a language users can receive messages in.
+type Locale string
+
+const (
+	English Locale = "en"
+	Pidgin  Locale = "pcm" // Nigerian Pidgin
+	Yoruba  Locale = "yo"
+	Igbo    Locale = "ig"
+	Hausa   Locale = "ha"
+)
+
+// DefaultLocale is used for users who have not chosen one, and for any
+// message a locale has no translation of.
+const DefaultLocale = English
+
+// Locales lists every supported locale.
+var Locales = []Locale{English, Pidgin, Yoruba, Igbo, Hausa}
+
+// IsSupported reports whether locale is one messages can be rendered in.
+func IsSupported(locale Locale) bool {
+	_, ok := catalogue[locale]
+	return ok
+}
+
+// Params are the values a template refers to, such as .Amount or .Group.
+type Params map[string]interface{}
+
+var catalogue = map[Locale]map[string]*template.Template{}
+
+var funcs = template.FuncMap{
+	"naira":    FormatNaira,
+	"date":     func(t time.Time) string { return t.Format("2006-01-02") },
+	"datetime": func(t time.Time) string { return t.Format("2006-01-02 15:04") },
+}
+
+func init() {
+	sources := map[Locale]map[string]string{
+		English: english,
+		Pidgin:  pidgin,
+		Yoruba:  yoruba,
+		Igbo:    igbo,
+		Hausa:   hausa,
+	}
+	for locale, texts := range sources {
+		templates := make(map[string]*template.Template, len(texts))
+		for key, text := range texts {
+			templates[key] = template.Must(template.New(string(locale) + ":" + key).Funcs(funcs).Option("missingkey=zero").Parse(text))
+		}
+		catalogue[locale] = templates
+	}
+}
+
+// Render fills in the template for key in the given locale, falling back
+// to English if the locale is unknown or has no translation of it.
+func Render(locale Locale, key string, params Params) string {
+	tmpl, ok := catalogue[locale][key]
+	if !ok {
+		tmpl, ok = catalogue[DefaultLocale][key]
+	}
+	if !ok {
+		log.Printf("No message template %q", key)
+		return key
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, params); err != nil {
+		log.Printf("Failed to render message %q in %s: %v", key, locale, err)
+		if locale != DefaultLocale {
+			return Render(DefaultLocale, key, params)
+		}
+		return key
+	}
+	return out.String()
+}
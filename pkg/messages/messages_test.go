@@ -0,0 +1,133 @@
+package messages
+
+import (
+	"testing"
+	"text/template/parse"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name   string
+		locale Locale
+		key    string
+		params Params
+		want   string
+	}{
+		{
+			name:   "fills in params with formatting",
+			locale: English,
+			key:    ContributionReminder,
+			params: Params{"Amount": 25000.0, "Group": "Office Ajo", "Deadline": time.Date(2025, time.June, 30, 23, 59, 0, 0, time.UTC)},
+			want:   "Reminder: your contribution of ₦25,000.00 to Office Ajo is due by 2025-06-30 23:59",
+		},
+		{
+			name:   "optional sentence left out",
+			locale: English,
+			key:    KYCRejected,
+			params: Params{"Tier": 2},
+			want:   "Your request for KYC tier 2 was not approved.",
+		},
+		{
+			name:   "optional sentence included",
+			locale: English,
+			key:    KYCRejected,
+			params: Params{"Tier": 2, "Reason": "blurry ID"},
+			want:   "Your request for KYC tier 2 was not approved. Reason: blurry ID",
+		},
+		{
+			name:   "other locale",
+			locale: Pidgin,
+			key:    OTPPhoneVerification,
+			params: Params{"Code": "123456"},
+			want:   "Your AjoR verification code na 123456. E go expire for 10 minutes.",
+		},
+		{
+			name:   "unknown locale falls back to English",
+			locale: "fr",
+			key:    OTPPINReset,
+			params: Params{"Code": "654321"},
+			want:   "Your AjoR transaction PIN reset code is 654321. It expires in 10 minutes.",
+		},
+		{
+			name:   "unknown key is returned as is",
+			locale: English,
+			key:    "no.such.message",
+			want:   "no.such.message",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Render(tt.locale, tt.key, tt.params))
+		})
+	}
+}
+
+func TestCatalogueIsComplete(t *testing.T) {
+	for _, locale := range Locales {
+		t.Run(string(locale), func(t *testing.T) {
+			assert.True(t, IsSupported(locale))
+			for key, english := range catalogue[English] {
+				translated, ok := catalogue[locale][key]
+				if !assert.True(t, ok, "no translation of %s", key) {
+					continue
+				}
+				assert.Equal(t, templateFields(english.Tree.Root), templateFields(translated.Tree.Root), "params of %s", key)
+			}
+		})
+	}
+}
+
+// templateFields lists the params a template refers to.
+func templateFields(node parse.Node) map[string]bool {
+	fields := map[string]bool{}
+	var walk func(parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.PipeNode:
+			for _, cmd := range n.Cmds {
+				for _, arg := range cmd.Args {
+					walk(arg)
+				}
+			}
+		case *parse.FieldNode:
+			fields[n.Ident[0]] = true
+		}
+	}
+	walk(node)
+	return fields
+}
+
+func TestFormatNaira(t *testing.T) {
+	tests := []struct {
+		amount float64
+		want   string
+	}{
+		{0, "₦0.00"},
+		{5, "₦5.00"},
+		{999.5, "₦999.50"},
+		{1250000, "₦1,250,000.00"},
+		{1234.567, "₦1,234.57"},
+		{-45000.1, "-₦45,000.10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, FormatNaira(tt.amount))
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package messages
+
+var pidgin = map[string]string{
+	PINChanged:                   "Dem don change your transaction PIN. If no be you, contact support.",
+	MFAEnabled:                   "Two-factor authentication don dey on for your account.",
+	MFADisabled:                  "Dem don off two-factor authentication for your account. If no be you, contact support.",
+	PasswordChanged:              "Dem don change your password and sign you out for all your devices. If no be you, contact support.",
+	KYCUpgraded:                  "Your account don move go KYC tier {{.Tier}}.",
+	KYCRejected:                  "Dem no approve your request for KYC tier {{.Tier}}.{{if .Reason}} Reason: {{.Reason}}{{end}}",
+	GuaranteeRequested:           "Dem wan make you stand as guarantor for member wey dey join group: {{.Group}}. You go cover reach {{naira .Amount}} for every cycle wey dem no pay",
+	GuaranteeDeclined:            "Guarantor no gree for your request to join group: {{.Group}}. Join again with another guarantor",
+	GuaranteeClaimFiled:          "Dem don file claim of {{naira .Amount}} against your guarantee for group {{.Group}} because the member no pay {{.Cycles}} contributions",
+	GuaranteeClaimUnfunded:       "Dem approve guarantee claim of {{naira .Amount}} for group {{.Group}} but money no reach for the guarantor wallet",
+	GuaranteeGuarantorCharged:    "Dem don comot {{naira .Amount}} from your wallet because of your guarantee for group: {{.Group}}",
+	GuaranteeMemberCovered:       "Dem don charge your guarantor {{naira .Amount}} for the contributions wey you no pay for group: {{.Group}}",
+	ContributionLate:             "Your contribution come late. Penalty na {{naira .Penalty}}",
+	ContributionReminder:         "Reminder: your contribution of {{naira .Amount}} to {{.Group}} go due by {{datetime .Deadline}}",
+	ContributionOverdue:          "Your contribution of {{naira .Amount}} to {{.Group}} suppose don pay by {{datetime .Deadline}} but you never pay.{{if .Penalty}} Late penalty of {{naira .Penalty}} go apply.{{end}}",
+	ContributionOverdueAdmin:     "{{.Member}} never pay im contribution of {{naira .Amount}} to {{.Group}}, {{.Days}} days after the deadline",
+	PayoutRequested:              "You don request payout of {{naira .Amount}} for contribution: {{.Group}}",
+	PayoutApproved:               "Dem don approve your payout of {{naira .Amount}}",
+	CollectionScheduled:          "Na you go collect for group: {{.Group}} on {{date .Date}}",
+	CollectionDue:                "Reminder: Na today collection dey due for group: {{.Group}}",
+	MemberJoined:                 "New member don join your contribution group: {{.Group}}",
+	MemberRemoved:                "Dem don comot you from contribution group: {{.Group}}",
+	LoanRequested:                "One member don ask for loan of {{naira .Amount}} from group: {{.Group}}",
+	LoanDeclined:                 "Group {{.Group}} no gree for your loan request of {{naira .Amount}}",
+	LoanDisbursed:                "Your loan of {{naira .Amount}} from group {{.Group}} don enter your wallet. First repayment go due on {{date .DueDate}}",
+	LoanPayoutDeduction:          "Dem comot {{naira .Amount}} from your payout to pay your loan. Wetin remain: {{naira .Outstanding}}",
+	LoanOverdue:                  "Your loan repayment of {{naira .Amount}} to group {{.Group}} don pass due date",
+	LoanOverdueAdmin:             "One member loan repayment of {{naira .Amount}} for group {{.Group}} don pass due date",
+	OrganisationStaffJoined:      "New staff don join your organisation: {{.Organisation}}",
+	OrganisationContributionPaid: "{{.Organisation}} don pay your contribution of {{naira .Amount}} to {{.Group}}",
+	AccountClosureFailed:         "We no fit pay out {{naira .Amount}} to close your account. The money don return to your wallet and your account still dey open.",
+	OTPPhoneVerification:         "Your AjoR verification code na {{.Code}}. E go expire for 10 minutes.",
+	OTPPINReset:                  "Your AjoR transaction PIN reset code na {{.Code}}. E go expire for 10 minutes.",
+	PasswordResetToken:           "Your AjoR password reset token na {{.Token}}. E go expire for 30 minutes. If no be you ask make dem reset your password, ignore this message.",
+
+	SubjectInfo:    "Ajor: new update",
+	SubjectWarning: "Ajor: you need do something",
+	SubjectError:   "Ajor: something no work",
+	SubjectDigest:  "Ajor: {{.Count}} updates",
+}
@@ -0,0 +1,43 @@
+package messages
+
+var yoruba = map[string]string{
+	PINChanged:                   "A ti yí PIN ìṣòwò rẹ padà. Kàn sí ẹ̀ka ìrànlọ́wọ́ tí kì í bá ṣe ìwọ.",
+	MFAEnabled:                   "A ti tan ìjẹ́rìísí ìpele-méjì fún àkáǹtì rẹ.",
+	MFADisabled:                  "A ti pa ìjẹ́rìísí ìpele-méjì fún àkáǹtì rẹ. Kàn sí ẹ̀ka ìrànlọ́wọ́ tí kì í bá ṣe ìwọ.",
+	PasswordChanged:              "A ti yí ọ̀rọ̀ aṣínà rẹ padà, a sì ti jáde kúrò lórí gbogbo ẹ̀rọ rẹ. Kàn sí ẹ̀ka ìrànlọ́wọ́ tí kì í bá ṣe ìwọ.",
+	KYCUpgraded:                  "A ti gbé àkáǹtì rẹ sókè sí ìpele KYC {{.Tier}}.",
+	KYCRejected:                  "A kò fọwọ́ sí ìbéèrè rẹ fún ìpele KYC {{.Tier}}.{{if .Reason}} Ìdí: {{.Reason}}{{end}}",
+	GuaranteeRequested:           "A ní kí o ṣe onídùúró fún ọmọ ẹgbẹ́ tí ń darapọ̀ mọ́ ẹgbẹ́: {{.Group}}. Ìwọ ni yóò san owó tí wọn kò bá san, tó tó {{naira .Amount}} ní ìyípo kọ̀ọ̀kan",
+	GuaranteeDeclined:            "Onídùúró kan kọ ìbéèrè rẹ láti darapọ̀ mọ́ ẹgbẹ́: {{.Group}}. Darapọ̀ lẹ́ẹ̀kan sí i pẹ̀lú onídùúró mìíràn",
+	GuaranteeClaimFiled:          "A ti béèrè owó {{naira .Amount}} lórí ìdùúró rẹ nínú ẹgbẹ́ {{.Group}} nítorí ọmọ ẹgbẹ́ náà kò san owó ìdásí {{.Cycles}}",
+	GuaranteeClaimUnfunded:       "A fọwọ́ sí ìbéèrè ìdùúró owó {{naira .Amount}} nínú ẹgbẹ́ {{.Group}} ṣùgbọ́n owó kò tó nínú àpamọ́wọ́ onídùúró",
+	GuaranteeGuarantorCharged:    "A ti yọ {{naira .Amount}} kúrò nínú àpamọ́wọ́ rẹ nítorí ìdùúró rẹ nínú ẹgbẹ́: {{.Group}}",
+	GuaranteeMemberCovered:       "A ti gba {{naira .Amount}} lọ́wọ́ onídùúró rẹ fún owó ìdásí tí o kò san nínú ẹgbẹ́: {{.Group}}",
+	ContributionLate:             "A ti gba owó ìdásí rẹ tó pẹ́. Owó ìtanràn: {{naira .Penalty}}",
+	ContributionReminder:         "Ìránnilétí: owó ìdásí {{naira .Amount}} rẹ sí {{.Group}} yóò tó ní {{datetime .Deadline}}",
+	ContributionOverdue:          "Owó ìdásí {{naira .Amount}} rẹ sí {{.Group}} ti yẹ kí o san ní {{datetime .Deadline}}, o kò sì tí ì san án.{{if .Penalty}} Owó ìtanràn {{naira .Penalty}} yóò wà fún ìpẹ́.{{end}}",
+	ContributionOverdueAdmin:     "{{.Member}} kò tí ì san owó ìdásí {{naira .Amount}} sí {{.Group}}, ọjọ́ {{.Days}} lẹ́yìn àkókò",
+	PayoutRequested:              "O ti béèrè owó {{naira .Amount}} láti inú ẹgbẹ́: {{.Group}}",
+	PayoutApproved:               "A ti fọwọ́ sí owó {{naira .Amount}} tí o béèrè",
+	CollectionScheduled:          "Ìwọ ni yóò gbà fún ẹgbẹ́: {{.Group}} ní {{date .Date}}",
+	CollectionDue:                "Ìránnilétí: Òní ni ọjọ́ gbígbà fún ẹgbẹ́: {{.Group}}",
+	MemberJoined:                 "Ọmọ ẹgbẹ́ tuntun ti darapọ̀ mọ́ ẹgbẹ́ àjọ rẹ: {{.Group}}",
+	MemberRemoved:                "A ti yọ ọ́ kúrò nínú ẹgbẹ́ àjọ: {{.Group}}",
+	LoanRequested:                "Ọmọ ẹgbẹ́ kan ti béèrè owó-yá {{naira .Amount}} láti inú ẹgbẹ́: {{.Group}}",
+	LoanDeclined:                 "Ẹgbẹ́ {{.Group}} kọ ìbéèrè owó-yá {{naira .Amount}} rẹ",
+	LoanDisbursed:                "A ti san owó-yá {{naira .Amount}} láti inú ẹgbẹ́ {{.Group}} sínú àpamọ́wọ́ rẹ. Ìsanpadà àkọ́kọ́ yóò tó ní {{date .DueDate}}",
+	LoanPayoutDeduction:          "A yọ {{naira .Amount}} kúrò nínú owó tí o gbà láti san owó-yá rẹ. Èyí tí ó kù: {{naira .Outstanding}}",
+	LoanOverdue:                  "Ìsanpadà owó-yá {{naira .Amount}} rẹ sí ẹgbẹ́ {{.Group}} ti kọjá àkókò",
+	LoanOverdueAdmin:             "Ìsanpadà owó-yá {{naira .Amount}} ọmọ ẹgbẹ́ kan nínú ẹgbẹ́ {{.Group}} ti kọjá àkókò",
+	OrganisationStaffJoined:      "Òṣìṣẹ́ tuntun ti darapọ̀ mọ́ ilé-iṣẹ́ rẹ: {{.Organisation}}",
+	OrganisationContributionPaid: "{{.Organisation}} ti san owó ìdásí {{naira .Amount}} rẹ sí {{.Group}}",
+	AccountClosureFailed:         "A kò lè san {{naira .Amount}} jáde láti pa àkáǹtì rẹ. Owó náà ti padà sínú àpamọ́wọ́ rẹ, àkáǹtì rẹ sì ṣì wà ní ṣíṣí.",
+	OTPPhoneVerification:         "Kóòdù ìjẹ́rìísí AjoR rẹ ni {{.Code}}. Yóò dópin ní ìṣẹ́jú 10.",
+	OTPPINReset:                  "Kóòdù àtúntò PIN ìṣòwò AjoR rẹ ni {{.Code}}. Yóò dópin ní ìṣẹ́jú 10.",
+	PasswordResetToken:           "Àmì àtúntò ọ̀rọ̀ aṣínà AjoR rẹ ni {{.Token}}. Yóò dópin ní ìṣẹ́jú 30. Má ṣe bìkítà fún èyí tí kì í bá ṣe ìwọ ló béèrè láti tún ọ̀rọ̀ aṣínà rẹ ṣe.",
+
+	SubjectInfo:    "Ajor: ìròyìn tuntun",
+	SubjectWarning: "Ajor: ó yẹ kí o ṣe nǹkan",
+	SubjectError:   "Ajor: nǹkan kan kò ṣiṣẹ́",
+	SubjectDigest:  "Ajor: ìròyìn {{.Count}}",
+}
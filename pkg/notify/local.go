@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// ConsoleSender writes messages to the server log. Use it for local
+// development only.
+type ConsoleSender struct {
+	Channel Channel
+}
+
+func NewConsoleSender(channel Channel) *ConsoleSender {
+	return &ConsoleSender{Channel: channel}
+}
+
+func (s *ConsoleSender) Send(ctx context.Context, msg Message) error {
+	log.Printf("%s to %s: %s", s.Channel, msg.To, msg.Body)
+	return nil
+}
+
+// FileSender appends messages to a file as JSON lines so tests and local
+// tools can read what was sent.
+type FileSender struct {
+	Channel Channel
+	Path    string
+	mu      sync.Mutex
+}
+
+func NewFileSender(channel Channel, path string) *FileSender {
+	return &FileSender{Channel: channel, Path: path}
+}
+
+func (s *FileSender) Send(ctx context.Context, msg Message) error {
+	line, err := json.Marshal(struct {
+		SentAt  time.Time `json:"sent_at"`
+		Channel Channel   `json:"channel"`
+		Message
+	}{time.Now(), s.Channel, msg})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open %s file: %w", s.Channel, err)
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "%s\n", line)
+	return err
+}
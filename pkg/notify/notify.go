@@ -0,0 +1,128 @@
+// Package notify delivers notifications outside the in-app inbox, over SMS,
+// email and push.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Gerard-007/ajor_app/pkg/sms"
+)
+
+// Channel is a way of reaching a user outside the app.
+type Channel string
+
+const (
+	ChannelSMS   Channel = "sms"
+	ChannelEmail Channel = "email"
+	ChannelPush  Channel = "push"
+)
+
+// Message is one notification addressed to one recipient on a channel:
+// a phone number, an email address, or for push the user's ID, which the
+// push provider maps to their devices.
+type Message struct {
+	To      string            `json:"to"`
+	Subject string            `json:"subject,omitempty"`
+	Body    string            `json:"body"`
+	Data    map[string]string `json:"data,omitempty"`
+}
+
+// Sender delivers messages on one channel.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewSendersFromEnv builds a sender for each channel in NOTIFY_CHANNELS.
+// SMS goes through the given provider, email through EMAIL_PROVIDER and
+// push through PUSH_PROVIDER; SMS also needs SMS_PROVIDER set. By default
+// every channel with a provider configured is on and the rest are off.
+// Listing a channel without one is an error rather than a console sender
+// that logs the message and reports it sent. The "console" and "file"
+// providers are for local use and must be chosen explicitly.
+func NewSendersFromEnv(smsProvider sms.Provider) (map[Channel]Sender, error) {
+	var channels []Channel
+	if value := os.Getenv("NOTIFY_CHANNELS"); value != "" {
+		for _, name := range strings.Split(value, ",") {
+			channels = append(channels, Channel(strings.TrimSpace(name)))
+		}
+	} else {
+		for channel, key := range providerKeys {
+			if os.Getenv(key) != "" {
+				channels = append(channels, channel)
+			}
+		}
+	}
+
+	senders := map[Channel]Sender{}
+	for _, channel := range channels {
+		key, ok := providerKeys[channel]
+		if !ok {
+			return nil, fmt.Errorf("unknown notification channel %q in NOTIFY_CHANNELS", channel)
+		}
+		if os.Getenv(key) == "" {
+			return nil, fmt.Errorf("%s notifications are enabled but %s is not set", channel, key)
+		}
+		var sender Sender
+		var err error
+		switch channel {
+		case ChannelSMS:
+			sender = NewSMSSender(smsProvider)
+		case ChannelEmail:
+			sender, err = newEmailSenderFromEnv()
+		case ChannelPush:
+			sender, err = newPushSenderFromEnv()
+		}
+		if err != nil {
+			return nil, err
+		}
+		senders[channel] = sender
+	}
+	return senders, nil
+}
+
+// providerKeys names the variable that configures each channel's provider.
+var providerKeys = map[Channel]string{
+	ChannelSMS:   "SMS_PROVIDER",
+	ChannelEmail: "EMAIL_PROVIDER",
+	ChannelPush:  "PUSH_PROVIDER",
+}
+
+func newEmailSenderFromEnv() (Sender, error) {
+	switch provider := os.Getenv("EMAIL_PROVIDER"); provider {
+	case "smtp":
+		return NewSMTPSenderFromEnv(), nil
+	case "file":
+		return NewFileSender(ChannelEmail, envOr("EMAIL_FILE_PATH", "email.log")), nil
+	case "console":
+		return NewConsoleSender(ChannelEmail), nil
+	default:
+		return nil, fmt.Errorf("unknown EMAIL_PROVIDER %q", provider)
+	}
+}
+
+func newPushSenderFromEnv() (Sender, error) {
+	switch provider := os.Getenv("PUSH_PROVIDER"); provider {
+	case "http":
+		if os.Getenv("PUSH_URL") == "" {
+			return nil, errors.New("PUSH_PROVIDER is http but PUSH_URL is not set")
+		}
+		return NewHTTPPushSender(os.Getenv("PUSH_URL"), os.Getenv("PUSH_TOKEN")), nil
+	case "file":
+		return NewFileSender(ChannelPush, envOr("PUSH_FILE_PATH", "push.log")), nil
+	case "console":
+		return NewConsoleSender(ChannelPush), nil
+	default:
+		return nil, fmt.Errorf("unknown PUSH_PROVIDER %q", provider)
+	}
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
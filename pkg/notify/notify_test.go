@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"testing"
+
+	"github.com/Gerard-007/ajor_app/pkg/sms"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSendersFromEnv(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		wantChannels []Channel
+		wantErr      string
+	}{
+		{
+			name:         "nothing configured turns every channel off",
+			wantChannels: []Channel{},
+		},
+		{
+			name:         "configured providers turn their channels on",
+			env:          map[string]string{"SMS_PROVIDER": "file", "EMAIL_PROVIDER": "smtp"},
+			wantChannels: []Channel{ChannelSMS, ChannelEmail},
+		},
+		{
+			name:         "NOTIFY_CHANNELS picks from the configured channels",
+			env:          map[string]string{"NOTIFY_CHANNELS": "email", "SMS_PROVIDER": "file", "EMAIL_PROVIDER": "console"},
+			wantChannels: []Channel{ChannelEmail},
+		},
+		{
+			name:    "listed channel without a provider",
+			env:     map[string]string{"NOTIFY_CHANNELS": "sms,push", "SMS_PROVIDER": "file"},
+			wantErr: "push notifications are enabled but PUSH_PROVIDER is not set",
+		},
+		{
+			name:    "unknown channel",
+			env:     map[string]string{"NOTIFY_CHANNELS": "fax"},
+			wantErr: `unknown notification channel "fax" in NOTIFY_CHANNELS`,
+		},
+		{
+			name:    "unknown email provider",
+			env:     map[string]string{"EMAIL_PROVIDER": "sendgrid"},
+			wantErr: `unknown EMAIL_PROVIDER "sendgrid"`,
+		},
+		{
+			name:    "http push without a URL",
+			env:     map[string]string{"PUSH_PROVIDER": "http"},
+			wantErr: "PUSH_PROVIDER is http but PUSH_URL is not set",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"NOTIFY_CHANNELS", "SMS_PROVIDER", "EMAIL_PROVIDER", "PUSH_PROVIDER", "PUSH_URL"} {
+				t.Setenv(key, tt.env[key])
+			}
+			senders, err := NewSendersFromEnv(sms.NewConsoleProvider())
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			channels := []Channel{}
+			for channel := range senders {
+				channels = append(channels, channel)
+			}
+			assert.ElementsMatch(t, tt.wantChannels, channels)
+		})
+	}
+}
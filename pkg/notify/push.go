@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// HTTPPushSender posts push notifications as JSON to a push gateway, which
+// looks up the recipient's devices:
+//
+//	{"to": "<user id>", "title": "...", "body": "...", "data": {...}}
+type HTTPPushSender struct {
+	URL    string
+	Token  string
+	Client *http.Client
+}
+
+func NewHTTPPushSender(url, token string) *HTTPPushSender {
+	return &HTTPPushSender{URL: url, Token: token, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (s *HTTPPushSender) Send(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"to":    msg.To,
+		"title": msg.Subject,
+		"body":  msg.Body,
+		"data":  msg.Data,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("push gateway returned %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return nil
+}
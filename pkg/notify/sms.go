@@ -0,0 +1,21 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/Gerard-007/ajor_app/pkg/sms"
+)
+
+// SMSSender sends notifications as text messages through the same
+// provider that sends verification codes.
+type SMSSender struct {
+	Provider sms.Provider
+}
+
+func NewSMSSender(provider sms.Provider) *SMSSender {
+	return &SMSSender{Provider: provider}
+}
+
+func (s *SMSSender) Send(ctx context.Context, msg Message) error {
+	return s.Provider.Send(ctx, msg.To, msg.Body)
+}
@@ -86,6 +86,50 @@ type verifyTransactionResponse struct {
 	} `json:"data"`
 }
 
+type resolveBVNResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Data    struct {
+		FirstName   string `json:"first_name"`
+		LastName    string `json:"last_name"`
+		PhoneNumber string `json:"phone_number"`
+	} `json:"data"`
+}
+
+type transferRequest struct {
+	AccountBank   string  `json:"account_bank"`
+	AccountNumber string  `json:"account_number"`
+	Amount        float64 `json:"amount"`
+	Currency      string  `json:"currency"`
+	Narration     string  `json:"narration"`
+	Reference     string  `json:"reference"`
+	DebitCurrency string  `json:"debit_currency"`
+}
+
+type transferResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Data    struct {
+		ID     int     `json:"id"`
+		Amount float64 `json:"amount"`
+		Status string  `json:"status"`
+	} `json:"data"`
+}
+
+type resolveAccountRequest struct {
+	AccountNumber string `json:"account_number"`
+	AccountBank   string `json:"account_bank"`
+}
+
+type resolveAccountResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Data    struct {
+		AccountNumber string `json:"account_number"`
+		AccountName   string `json:"account_name"`
+	} `json:"data"`
+}
+
 func NewFlutterwaveGateway() *FlutterwaveGateway {
 	apiKey := os.Getenv("FLW_SECRET_KEY")
 	if apiKey == "" {
@@ -355,6 +399,206 @@ func (f *FlutterwaveGateway) VerifyTransaction(ctx context.Context, transactionI
 	}, nil
 }
 
+// TransferToBank queues a payout to a bank account. Flutterwave completes
+// transfers asynchronously, so the returned status is usually pending.
+func (f *FlutterwaveGateway) TransferToBank(ctx context.Context, req BankTransfer) (*TransactionResponse, error) {
+	url := f.BaseURL + "/transfers"
+
+	payload := transferRequest{
+		AccountBank:   req.BankCode,
+		AccountNumber: req.AccountNumber,
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		Narration:     req.Narration,
+		Reference:     req.Reference,
+		DebitCurrency: req.Currency,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+f.APIKey)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(respBody))
+	}
+
+	var response transferResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if response.Status != "success" {
+		return nil, fmt.Errorf("failed to initiate transfer: %s", response.Message)
+	}
+
+	return &TransactionResponse{
+		TransactionID: fmt.Sprintf("%d", response.Data.ID),
+		Status:        "pending",
+		Amount:        response.Data.Amount,
+	}, nil
+}
+
+// ResolveBVN looks up the details registered against a BVN.
+func (f *FlutterwaveGateway) ResolveBVN(ctx context.Context, bvn string) (*BVNDetails, error) {
+	url := fmt.Sprintf("%s/kyc/bvns/%s", f.BaseURL, bvn)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+f.APIKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(respBody))
+	}
+
+	var response resolveBVNResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if response.Status != "success" {
+		return nil, fmt.Errorf("failed to resolve BVN: %s", response.Message)
+	}
+
+	return &BVNDetails{
+		FirstName:   response.Data.FirstName,
+		LastName:    response.Data.LastName,
+		PhoneNumber: response.Data.PhoneNumber,
+	}, nil
+}
+
+// ResolveBankAccount looks up the name an account number is registered to.
+func (f *FlutterwaveGateway) ResolveBankAccount(ctx context.Context, bankCode, accountNumber string) (*BankAccountDetails, error) {
+	url := f.BaseURL + "/accounts/resolve"
+
+	body, err := json.Marshal(resolveAccountRequest{AccountNumber: accountNumber, AccountBank: bankCode})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+f.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(respBody))
+	}
+
+	var response resolveAccountResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if response.Status != "success" {
+		return nil, fmt.Errorf("failed to resolve bank account: %s", response.Message)
+	}
+
+	return &BankAccountDetails{AccountName: response.Data.AccountName}, nil
+}
+
+// GetTransfer reports how a transfer started by TransferToBank is going.
+// Flutterwave's statuses are mapped to TransferPending, TransferSuccessful
+// and TransferFailed.
+func (f *FlutterwaveGateway) GetTransfer(ctx context.Context, transferID string) (*TransactionResponse, error) {
+	url := fmt.Sprintf("%s/transfers/%s", f.BaseURL, transferID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+f.APIKey)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(respBody))
+	}
+
+	var response transferResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if response.Status != "success" {
+		return nil, fmt.Errorf("failed to get transfer: %s", response.Message)
+	}
+
+	status := TransferPending
+	switch response.Data.Status {
+	case "SUCCESSFUL":
+		status = TransferSuccessful
+	case "FAILED":
+		status = TransferFailed
+	}
+	return &TransactionResponse{
+		TransactionID: fmt.Sprintf("%d", response.Data.ID),
+		Status:        status,
+		Amount:        response.Data.Amount,
+	}, nil
+}
+
 func (f *FlutterwaveGateway) Transfer(ctx context.Context, fromWalletID, toWalletID primitive.ObjectID, amount float64, reference string) error {
 	// Placeholder: Implement Flutterwave transfer API
 	// https://developer.flutterwave.com/reference/endpoints/transfers
@@ -22,6 +22,35 @@ type FundingRequest struct {
 	PhoneNumber  string
 }
 
+// BankTransfer sends money out of the platform to a bank account.
+type BankTransfer struct {
+	BankCode      string
+	AccountNumber string
+	Amount        float64
+	Currency      string
+	Narration     string
+	Reference     string
+}
+
+// BVNDetails is what the bank verification service holds for a BVN.
+type BVNDetails struct {
+	FirstName   string
+	LastName    string
+	PhoneNumber string
+}
+
+// BankAccountDetails is what the bank holds for an account number.
+type BankAccountDetails struct {
+	AccountName string
+}
+
+// Transfer statuses reported by GetTransfer.
+const (
+	TransferPending    = "pending"
+	TransferSuccessful = "successful"
+	TransferFailed     = "failed"
+)
+
 type TransactionResponse struct {
 	TransactionID string
 	Status        string
@@ -34,4 +63,8 @@ type PaymentGateway interface {
 	DeactivateVirtualAccount(ctx context.Context, accountID string) error
 	FundVirtualAccount(ctx context.Context, accountID string, req FundingRequest) (*TransactionResponse, error)
 	VerifyTransaction(ctx context.Context, transactionID string) (*TransactionResponse, error)
+	TransferToBank(ctx context.Context, req BankTransfer) (*TransactionResponse, error)
+	ResolveBVN(ctx context.Context, bvn string) (*BVNDetails, error)
+	ResolveBankAccount(ctx context.Context, bankCode, accountNumber string) (*BankAccountDetails, error)
+	GetTransfer(ctx context.Context, transferID string) (*TransactionResponse, error)
 }
\ No newline at end of file
@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxRetries bounds how often Allow retries when another instance updates
+// the same bucket between its read and write.
+const maxRetries = 5
+
+type mongoBucket struct {
+	Key       string    `bson:"_id"`
+	Tokens    float64   `bson:"tokens"`
+	UpdatedAt time.Time `bson:"updated_at"`
+	Version   int64     `bson:"version"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// MongoStore keeps buckets in a collection so every instance sees the
+// same limits. Writes use a version number so concurrent requests cannot
+// both spend the same token.
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	return &MongoStore{collection: collection}
+}
+
+// EnsureIndexes lets MongoDB delete buckets that have been idle long
+// enough to be full again.
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+func (s *MongoStore) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	for i := 0; i < maxRetries; i++ {
+		now := time.Now()
+		var bucket mongoBucket
+		err := s.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&bucket)
+		if err == mongo.ErrNoDocuments {
+			tokens, result := take(float64(limit.Burst), now, now, limit)
+			_, err := s.collection.InsertOne(ctx, mongoBucket{
+				Key:       key,
+				Tokens:    tokens,
+				UpdatedAt: now,
+				ExpiresAt: now.Add(idleTTL(limit)),
+			})
+			if mongo.IsDuplicateKeyError(err) {
+				continue
+			}
+			if err != nil {
+				return Result{}, err
+			}
+			return result, nil
+		}
+		if err != nil {
+			return Result{}, err
+		}
+
+		tokens, result := take(bucket.Tokens, bucket.UpdatedAt, now, limit)
+		filter := bson.M{"_id": key, "version": bucket.Version}
+		update := bson.M{"$set": bson.M{
+			"tokens":     tokens,
+			"updated_at": now,
+			"version":    bucket.Version + 1,
+			"expires_at": now.Add(idleTTL(limit)),
+		}}
+		updated, err := s.collection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return Result{}, err
+		}
+		if updated.MatchedCount == 0 {
+			continue
+		}
+		return result, nil
+	}
+	return Result{}, errors.New("rate limit bucket is under contention")
+}
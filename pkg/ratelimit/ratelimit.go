@@ -0,0 +1,87 @@
+// Package ratelimit implements token bucket rate limiting with buckets kept
+// in memory or in MongoDB.
+package ratelimit
+
+import (
+	"context"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Limit is a token bucket: it holds at most Burst tokens and refills at
+// Rate tokens per second. Each request takes one token.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// PerMinute allows n requests a minute, all of which may arrive at once.
+func PerMinute(n int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: n}
+}
+
+// PerHour allows n requests an hour, all of which may arrive at once.
+func PerHour(n int) Limit {
+	return Limit{Rate: float64(n) / 3600, Burst: n}
+}
+
+// PerMinuteFromEnv reads a requests-per-minute limit from an environment
+// variable, falling back to def when it is unset or invalid.
+func PerMinuteFromEnv(name string, def int) Limit {
+	if n, err := strconv.Atoi(os.Getenv(name)); err == nil && n > 0 {
+		return PerMinute(n)
+	}
+	return PerMinute(def)
+}
+
+// Result is the outcome of taking a token.
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration // how long until a token is available when not allowed
+}
+
+// Store keeps token buckets by key.
+type Store interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// NewStoreFromEnv picks a store from RATE_LIMIT_STORE. "mongo" shares
+// buckets between instances through the rate_limits collection; anything
+// else keeps them in this process.
+func NewStoreFromEnv(db *mongo.Database) Store {
+	if os.Getenv("RATE_LIMIT_STORE") == "mongo" {
+		store := NewMongoStore(db.Collection("rate_limits"))
+		if err := store.EnsureIndexes(context.Background()); err != nil {
+			log.Printf("Failed to create rate limit indexes: %v", err)
+		}
+		return store
+	}
+	return NewMemoryStore()
+}
+
+// take refills a bucket that last held tokens at updated and takes one
+// token from it at now.
+func take(tokens float64, updated, now time.Time, limit Limit) (float64, Result) {
+	elapsed := now.Sub(updated).Seconds()
+	if elapsed > 0 {
+		tokens = math.Min(float64(limit.Burst), tokens+elapsed*limit.Rate)
+	}
+	if tokens >= 1 {
+		tokens--
+		return tokens, Result{Allowed: true, Remaining: int(tokens)}
+	}
+	wait := time.Duration((1 - tokens) / limit.Rate * float64(time.Second))
+	return tokens, Result{Allowed: false, RetryAfter: wait}
+}
+
+// idleTTL is how long a bucket must sit unused before it is full again and
+// can be forgotten.
+func idleTTL(limit Limit) time.Duration {
+	return time.Duration(float64(limit.Burst)/limit.Rate*float64(time.Second)) + time.Minute
+}
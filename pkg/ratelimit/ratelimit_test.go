@@ -0,0 +1,102 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTake(t *testing.T) {
+	now := time.Date(2025, time.June, 1, 12, 0, 0, 0, time.UTC)
+	limit := PerMinute(60) // one token a second, five at once below
+
+	tests := []struct {
+		name          string
+		limit         Limit
+		tokens        float64
+		updated       time.Time
+		wantTokens    float64
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+	}{
+		{"full bucket", limit, 60, now, 59, true, 59, 0},
+		{"last token", limit, 1, now, 0, true, 0, 0},
+		{"empty bucket", limit, 0, now, 0, false, 0, time.Second},
+		{"half a token", limit, 0.5, now, 0.5, false, 0, 500 * time.Millisecond},
+		{"refills with time", limit, 0, now.Add(-3 * time.Second), 2, true, 2, 0},
+		{"never refills past the burst", Limit{Rate: 1, Burst: 5}, 0, now.Add(-time.Hour), 4, true, 4, 0},
+		{"clock going backwards does not refill", limit, 0, now.Add(time.Second), 0, false, 0, time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, result := take(tt.tokens, tt.updated, now, tt.limit)
+			assert.InDelta(t, tt.wantTokens, tokens, 1e-9)
+			assert.Equal(t, tt.wantAllowed, result.Allowed)
+			assert.Equal(t, tt.wantRemaining, result.Remaining)
+			assert.Equal(t, tt.wantRetry, result.RetryAfter)
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit Limit
+		want  Limit
+		ttl   time.Duration
+	}{
+		{"per minute", PerMinute(30), Limit{Rate: 0.5, Burst: 30}, 2 * time.Minute},
+		{"per hour", PerHour(3600), Limit{Rate: 1, Burst: 3600}, 61 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.limit)
+			assert.Equal(t, tt.ttl, idleTTL(tt.limit))
+		})
+	}
+}
+
+func TestPerMinuteFromEnv(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  Limit
+	}{
+		{"unset", "", PerMinute(10)},
+		{"set", "25", PerMinute(25)},
+		{"not a number", "lots", PerMinute(10)},
+		{"zero", "0", PerMinute(10)},
+		{"negative", "-5", PerMinute(10)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_RATE_LIMIT", tt.value)
+			assert.Equal(t, tt.want, PerMinuteFromEnv("TEST_RATE_LIMIT", 10))
+		})
+	}
+}
+
+func TestMemoryStoreAllow(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	limit := PerHour(3)
+
+	for i := 2; i >= 0; i-- {
+		result, err := store.Allow(ctx, "login:ada", limit)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	result, err := store.Allow(ctx, "login:ada", limit)
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.InDelta(t, 20*time.Minute, result.RetryAfter, float64(time.Second))
+
+	result, err = store.Allow(ctx, "login:bola", limit)
+	assert.NoError(t, err)
+	assert.True(t, result.Allowed, "keys have separate buckets")
+}
@@ -0,0 +1,78 @@
+package schedule
+
+import (
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+)
+
+// DefaultTimezone is used for contributions that do not set their own.
+const DefaultTimezone = "Africa/Lagos"
+
+const dateLayout = "2006-01-02"
+
+// LoadLocation resolves a contribution timezone, falling back to
+// DefaultTimezone when none is set.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		name = DefaultTimezone
+	}
+	return time.LoadLocation(name)
+}
+
+// DefaultLocation returns the location for DefaultTimezone.
+func DefaultLocation() *time.Location {
+	loc, err := time.LoadLocation(DefaultTimezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// StartOfDay returns midnight of t's calendar day in loc.
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	return startOfDay(t.In(loc))
+}
+
+// Calendar is a set of public holidays keyed by calendar day.
+type Calendar map[string]string
+
+// NewCalendar indexes holidays by their date.
+func NewCalendar(holidays []*models.Holiday) Calendar {
+	calendar := make(Calendar, len(holidays))
+	for _, holiday := range holidays {
+		calendar[holiday.Date] = holiday.Name
+	}
+	return calendar
+}
+
+// IsHoliday reports whether t's calendar day is a holiday.
+func (c Calendar) IsHoliday(t time.Time) bool {
+	_, ok := c[t.Format(dateLayout)]
+	return ok
+}
+
+// IsBusinessDay reports whether t is a weekday that is not a holiday.
+func (c Calendar) IsBusinessDay(t time.Time) bool {
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	return !c.IsHoliday(t)
+}
+
+// Roll moves a deadline that falls on a holiday to the same time on the next
+// business day. Deadlines on ordinary days are returned unchanged.
+func (c Calendar) Roll(deadline time.Time) time.Time {
+	if !c.IsHoliday(deadline) {
+		return deadline
+	}
+	for !c.IsBusinessDay(deadline) {
+		deadline = deadline.AddDate(0, 0, 1)
+	}
+	return deadline
+}
+
+// ParseDate validates a YYYY-MM-DD holiday date.
+func ParseDate(date string) (time.Time, error) {
+	return time.Parse(dateLayout, date)
+}
@@ -0,0 +1,70 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendarRoll(t *testing.T) {
+	calendar := NewCalendar([]*models.Holiday{
+		{Date: "2025-06-12", Name: "Democracy Day"},  // Thursday
+		{Date: "2025-12-25", Name: "Christmas Day"},  // Thursday
+		{Date: "2025-12-26", Name: "Boxing Day"},     // Friday
+		{Date: "2026-01-01", Name: "New Year's Day"}, // Thursday
+	})
+
+	tests := []struct {
+		name     string
+		deadline time.Time
+		want     time.Time
+	}{
+		{
+			name:     "ordinary weekday is unchanged",
+			deadline: date(2025, time.June, 11, 23, 59, 59, 0),
+			want:     date(2025, time.June, 11, 23, 59, 59, 0),
+		},
+		{
+			name:     "weekend that is not a holiday is unchanged",
+			deadline: date(2025, time.June, 14, 23, 59, 59, 0),
+			want:     date(2025, time.June, 14, 23, 59, 59, 0),
+		},
+		{
+			name:     "holiday rolls to the next day",
+			deadline: date(2025, time.June, 12, 23, 59, 59, 0),
+			want:     date(2025, time.June, 13, 23, 59, 59, 0),
+		},
+		{
+			name:     "consecutive holidays and a weekend roll to monday",
+			deadline: date(2025, time.December, 25, 23, 59, 59, 0),
+			want:     date(2025, time.December, 29, 23, 59, 59, 0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, calendar.Roll(tt.deadline))
+		})
+	}
+}
+
+func TestRecurrenceNextRollsHolidays(t *testing.T) {
+	calendar := NewCalendar([]*models.Holiday{{Date: "2025-06-12", Name: "Democracy Day"}})
+	recurrence := Recurrence{Cycle: models.CycleDayOfMonth, MonthDay: 12, Location: time.UTC, Holidays: calendar}
+
+	assert.Equal(t, date(2025, time.June, 13, 23, 59, 59, 0), recurrence.Next(date(2025, time.June, 1, 0, 0, 0, 0)))
+	assert.Equal(t, date(2025, time.July, 12, 23, 59, 59, 0), recurrence.Next(date(2025, time.June, 13, 0, 0, 0, 0)), "only holidays roll, not weekends")
+}
+
+func TestFromContribution(t *testing.T) {
+	calendar := NewCalendar([]*models.Holiday{{Date: "2025-06-12", Name: "Democracy Day"}})
+
+	rolling := FromContribution(&models.Contribution{Cycle: models.CycleDaily, Timezone: "UTC", RollHolidays: true}, calendar)
+	assert.Equal(t, time.UTC, rolling.Location)
+	assert.NotNil(t, rolling.Holidays)
+
+	plain := FromContribution(&models.Contribution{Cycle: models.CycleDaily, Timezone: "Mars/Olympus"}, calendar)
+	assert.Equal(t, DefaultLocation(), plain.Location)
+	assert.Nil(t, plain.Holidays)
+}
@@ -0,0 +1,229 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+// Recurrence describes when a contribution's collection deadlines fall.
+// Every deadline is the last second of an occurrence day in Location.
+type Recurrence struct {
+	Cycle    models.ContributionCycle
+	Interval int
+	Unit     models.CycleUnit
+	MonthDay int
+	Weekday  string
+	Start    time.Time
+	Timezone string
+	Location *time.Location
+	Holidays Calendar // deadlines on these days roll to the next business day
+}
+
+// FromContribution builds the recurrence rule stored on a contribution.
+// Holidays are only applied when the contribution opts into rolling.
+func FromContribution(contribution *models.Contribution, holidays Calendar) Recurrence {
+	r := Recurrence{
+		Cycle:    contribution.Cycle,
+		Interval: contribution.CycleInterval,
+		Unit:     contribution.CycleUnit,
+		MonthDay: contribution.CycleMonthDay,
+		Weekday:  strings.ToLower(contribution.CycleWeekday),
+		Start:    contribution.CycleStartDate,
+		Timezone: contribution.Timezone,
+	}
+	if loc, err := LoadLocation(contribution.Timezone); err == nil {
+		r.Location = loc
+	} else {
+		r.Location = DefaultLocation()
+	}
+	if contribution.RollHolidays {
+		r.Holidays = holidays
+	}
+	return r
+}
+
+// Validate reports whether the rule has everything its cycle needs.
+func (r Recurrence) Validate() error {
+	if _, err := LoadLocation(r.Timezone); err != nil {
+		return errors.New("invalid timezone")
+	}
+
+	switch r.Cycle {
+	case models.CycleDaily, models.CycleWeekly, models.CycleMonthly, models.CycleYearly:
+		return nil
+	case models.CycleInterval:
+		if r.Interval <= 0 {
+			return errors.New("cycle interval must be positive")
+		}
+		if r.Unit != models.CycleUnitDay && r.Unit != models.CycleUnitWeek {
+			return errors.New("cycle unit must be day or week")
+		}
+		return nil
+	case models.CycleDayOfMonth:
+		if r.MonthDay < 1 || r.MonthDay > 31 {
+			return errors.New("cycle month day must be between 1 and 31")
+		}
+		return nil
+	case models.CycleWeekday:
+		if _, ok := weekdays[r.Weekday]; !ok {
+			return errors.New("cycle weekday must be a day of the week")
+		}
+		if r.Interval < 0 {
+			return errors.New("cycle interval cannot be negative")
+		}
+		return nil
+	default:
+		return errors.New("invalid cycle")
+	}
+}
+
+// Label is the human readable collection day shown on a contribution.
+func (r Recurrence) Label() string {
+	switch r.Cycle {
+	case models.CycleDaily:
+		return "end of day"
+	case models.CycleWeekly:
+		return "end of week"
+	case models.CycleMonthly:
+		return "last day of month"
+	case models.CycleYearly:
+		return "last day of year"
+	case models.CycleInterval:
+		if r.Interval == 1 {
+			return fmt.Sprintf("every %s", r.Unit)
+		}
+		return fmt.Sprintf("every %d %ss", r.Interval, r.Unit)
+	case models.CycleDayOfMonth:
+		return fmt.Sprintf("day %d of each month", r.MonthDay)
+	case models.CycleWeekday:
+		if r.Interval > 1 {
+			return fmt.Sprintf("every %d weeks on %s", r.Interval, r.Weekday)
+		}
+		return "every " + r.Weekday
+	default:
+		return ""
+	}
+}
+
+// Next returns the first deadline strictly after from, rolled past any
+// holiday when the rule carries a calendar.
+func (r Recurrence) Next(from time.Time) time.Time {
+	deadline := r.next(from)
+	if r.Holidays != nil {
+		deadline = r.Holidays.Roll(deadline)
+	}
+	return deadline
+}
+
+func (r Recurrence) next(from time.Time) time.Time {
+	loc := r.Location
+	if loc == nil {
+		loc = DefaultLocation()
+	}
+	from = from.In(loc)
+	start := r.Start.In(loc)
+	if r.Start.IsZero() {
+		start = from
+	}
+
+	switch r.Cycle {
+	case models.CycleDaily:
+		return onGrid(startOfDay(start), 1, from)
+	case models.CycleWeekly:
+		return onGrid(nextWeekday(start, time.Sunday), 7, from)
+	case models.CycleMonthly:
+		return nextMonthDay(31, from)
+	case models.CycleYearly:
+		deadline := endOfDay(time.Date(from.Year(), 12, 31, 0, 0, 0, 0, from.Location()))
+		if !deadline.After(from) {
+			deadline = deadline.AddDate(1, 0, 0)
+		}
+		return deadline
+	case models.CycleInterval:
+		step := r.Interval
+		if step < 1 {
+			step = 1
+		}
+		if r.Unit == models.CycleUnitWeek {
+			step *= 7
+		}
+		return onGrid(startOfDay(start).AddDate(0, 0, step), step, from)
+	case models.CycleDayOfMonth:
+		return nextMonthDay(r.MonthDay, from)
+	case models.CycleWeekday:
+		weeks := r.Interval
+		if weeks < 1 {
+			weeks = 1
+		}
+		return onGrid(nextWeekday(start, weekdays[r.Weekday]), 7*weeks, from)
+	default:
+		// An unknown cycle, e.g. from bad data, is treated as daily so
+		// that callers stepping through deadlines always move forward
+		return onGrid(startOfDay(from), 1, from)
+	}
+}
+
+// onGrid returns the first end-of-day deadline on anchor + k*stepDays (k >= 0)
+// that falls strictly after from.
+func onGrid(anchor time.Time, stepDays int, from time.Time) time.Time {
+	if endOfDay(anchor).After(from) {
+		return endOfDay(anchor)
+	}
+	elapsed := int(startOfDay(from).Sub(anchor).Hours() / 24)
+	k := elapsed / stepDays
+	deadline := endOfDay(anchor.AddDate(0, 0, k*stepDays))
+	for !deadline.After(from) {
+		k++
+		deadline = endOfDay(anchor.AddDate(0, 0, k*stepDays))
+	}
+	return deadline
+}
+
+// nextMonthDay returns the next deadline on the given day of the month,
+// clamped to the last day for shorter months.
+func nextMonthDay(day int, from time.Time) time.Time {
+	year, month, _ := from.Date()
+	for i := 0; ; i++ {
+		deadline := endOfDay(clampedDate(year, month+time.Month(i), day, from.Location()))
+		if deadline.After(from) {
+			return deadline
+		}
+	}
+}
+
+func clampedDate(year int, month time.Month, day int, loc *time.Location) time.Time {
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+func nextWeekday(t time.Time, weekday time.Weekday) time.Time {
+	days := (int(weekday) - int(t.Weekday()) + 7) % 7
+	return startOfDay(t).AddDate(0, 0, days)
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+func endOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 23, 59, 59, 0, t.Location())
+}
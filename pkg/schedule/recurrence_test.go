@@ -0,0 +1,150 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Gerard-007/ajor_app/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day, hour, min, sec, nsec int) time.Time {
+	return time.Date(year, month, day, hour, min, sec, nsec, time.UTC)
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	// 2025-06-04 is a Wednesday
+	start := date(2025, time.June, 4, 0, 0, 0, 0)
+
+	tests := []struct {
+		name       string
+		recurrence Recurrence
+		from       time.Time
+		want       time.Time
+	}{
+		{
+			name:       "daily later the same day",
+			recurrence: Recurrence{Cycle: models.CycleDaily, Start: start},
+			from:       date(2025, time.June, 10, 9, 0, 0, 0),
+			want:       date(2025, time.June, 10, 23, 59, 59, 0),
+		},
+		{
+			name:       "daily at the deadline moves to the next day",
+			recurrence: Recurrence{Cycle: models.CycleDaily, Start: start},
+			from:       date(2025, time.June, 10, 23, 59, 59, 0),
+			want:       date(2025, time.June, 11, 23, 59, 59, 0),
+		},
+		{
+			name:       "weekly ends on sunday",
+			recurrence: Recurrence{Cycle: models.CycleWeekly, Start: start},
+			from:       date(2025, time.June, 10, 9, 0, 0, 0),
+			want:       date(2025, time.June, 15, 23, 59, 59, 0),
+		},
+		{
+			name:       "monthly ends on the last day of a short month",
+			recurrence: Recurrence{Cycle: models.CycleMonthly, Start: start},
+			from:       date(2025, time.February, 3, 0, 0, 0, 0),
+			want:       date(2025, time.February, 28, 23, 59, 59, 0),
+		},
+		{
+			name:       "yearly after the deadline moves to next year",
+			recurrence: Recurrence{Cycle: models.CycleYearly, Start: start},
+			from:       date(2025, time.December, 31, 23, 59, 59, 0),
+			want:       date(2026, time.December, 31, 23, 59, 59, 0),
+		},
+		{
+			name:       "every 10 days from the start",
+			recurrence: Recurrence{Cycle: models.CycleInterval, Interval: 10, Unit: models.CycleUnitDay, Start: start},
+			from:       date(2025, time.June, 15, 0, 0, 0, 0),
+			want:       date(2025, time.June, 24, 23, 59, 59, 0),
+		},
+		{
+			name:       "every 2 weeks from the start",
+			recurrence: Recurrence{Cycle: models.CycleInterval, Interval: 2, Unit: models.CycleUnitWeek, Start: start},
+			from:       date(2025, time.June, 4, 12, 0, 0, 0),
+			want:       date(2025, time.June, 18, 23, 59, 59, 0),
+		},
+		{
+			name:       "day 31 is clamped in april",
+			recurrence: Recurrence{Cycle: models.CycleDayOfMonth, MonthDay: 31, Start: start},
+			from:       date(2025, time.April, 1, 0, 0, 0, 0),
+			want:       date(2025, time.April, 30, 23, 59, 59, 0),
+		},
+		{
+			name:       "day 15 after it has passed",
+			recurrence: Recurrence{Cycle: models.CycleDayOfMonth, MonthDay: 15, Start: start},
+			from:       date(2025, time.June, 16, 0, 0, 0, 0),
+			want:       date(2025, time.July, 15, 23, 59, 59, 0),
+		},
+		{
+			name:       "every friday",
+			recurrence: Recurrence{Cycle: models.CycleWeekday, Weekday: "friday", Start: start},
+			from:       date(2025, time.June, 7, 0, 0, 0, 0),
+			want:       date(2025, time.June, 13, 23, 59, 59, 0),
+		},
+		{
+			name:       "every 2 weeks on friday",
+			recurrence: Recurrence{Cycle: models.CycleWeekday, Weekday: "friday", Interval: 2, Start: start},
+			from:       date(2025, time.June, 7, 0, 0, 0, 0),
+			want:       date(2025, time.June, 20, 23, 59, 59, 0),
+		},
+		{
+			name:       "unknown cycle just before the deadline",
+			recurrence: Recurrence{Cycle: "fortnightly", Start: start},
+			from:       date(2025, time.June, 10, 23, 59, 59, 500),
+			want:       date(2025, time.June, 11, 23, 59, 59, 0),
+		},
+		{
+			name:       "zero interval is treated as one",
+			recurrence: Recurrence{Cycle: models.CycleInterval, Unit: models.CycleUnitDay, Start: start},
+			from:       date(2025, time.June, 10, 9, 0, 0, 0),
+			want:       date(2025, time.June, 10, 23, 59, 59, 0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.recurrence.Location = time.UTC
+			got := tt.recurrence.Next(tt.from)
+			assert.True(t, got.Equal(tt.want), "got %s, want %s", got, tt.want)
+			assert.True(t, got.After(tt.from))
+		})
+	}
+}
+
+func TestRecurrenceNextUsesLocation(t *testing.T) {
+	lagos, err := time.LoadLocation("Africa/Lagos")
+	if err != nil {
+		t.Skip("no timezone database")
+	}
+	recurrence := Recurrence{Cycle: models.CycleDaily, Location: lagos}
+	// 23:30 UTC is already the next day in Lagos (UTC+1)
+	got := recurrence.Next(date(2025, time.June, 10, 23, 30, 0, 0))
+	assert.True(t, got.Equal(time.Date(2025, time.June, 11, 23, 59, 59, 0, lagos)))
+}
+
+func TestRecurrenceValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		recurrence Recurrence
+		wantErr    string
+	}{
+		{"daily", Recurrence{Cycle: models.CycleDaily}, ""},
+		{"interval in days", Recurrence{Cycle: models.CycleInterval, Interval: 3, Unit: models.CycleUnitDay}, ""},
+		{"interval without a count", Recurrence{Cycle: models.CycleInterval, Unit: models.CycleUnitDay}, "cycle interval must be positive"},
+		{"interval in months", Recurrence{Cycle: models.CycleInterval, Interval: 1, Unit: "month"}, "cycle unit must be day or week"},
+		{"month day 0", Recurrence{Cycle: models.CycleDayOfMonth}, "cycle month day must be between 1 and 31"},
+		{"unknown weekday", Recurrence{Cycle: models.CycleWeekday, Weekday: "funday"}, "cycle weekday must be a day of the week"},
+		{"unknown cycle", Recurrence{Cycle: "fortnightly"}, "invalid cycle"},
+		{"unknown timezone", Recurrence{Cycle: models.CycleDaily, Timezone: "Mars/Olympus"}, "invalid timezone"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.recurrence.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
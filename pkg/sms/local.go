@@ -0,0 +1,48 @@
+package sms
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// ConsoleProvider writes messages to the server log. Use it for local
+// development only.
+type ConsoleProvider struct{}
+
+func NewConsoleProvider() *ConsoleProvider {
+	return &ConsoleProvider{}
+}
+
+func (p *ConsoleProvider) Send(ctx context.Context, to, message string) error {
+	log.Printf("SMS to %s: %s", to, message)
+	return nil
+}
+
+// FileProvider appends messages to a file so tests and local tools can read
+// the codes that were sent.
+type FileProvider struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{Path: path}
+}
+
+func (p *FileProvider) Send(ctx context.Context, to, message string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	f, err := os.OpenFile(p.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open sms file: %w", err)
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "%s\t%s\t%s\n", time.Now().Format(time.RFC3339), to, message)
+	return err
+}
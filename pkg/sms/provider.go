@@ -0,0 +1,64 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+)
+
+// Provider delivers text messages to phone numbers.
+type Provider interface {
+	Send(ctx context.Context, to, message string) error
+}
+
+// NewProviderFromEnv picks a provider from SMS_PROVIDER. "file" appends
+// messages to SMS_FILE_PATH; anything else logs them to the console.
+func NewProviderFromEnv() Provider {
+	switch os.Getenv("SMS_PROVIDER") {
+	case "file":
+		path := os.Getenv("SMS_FILE_PATH")
+		if path == "" {
+			path = "sms.log"
+		}
+		return NewFileProvider(path)
+	default:
+		return NewConsoleProvider()
+	}
+}
+
+// NormalizePhone converts a Nigerian mobile number written as 0XXXXXXXXXX,
+// 234XXXXXXXXXX or +234XXXXXXXXXX to +234XXXXXXXXXX.
+func NormalizePhone(phone string) (string, error) {
+	phone = strings.NewReplacer(" ", "", "-", "").Replace(phone)
+	var national string
+	switch {
+	case strings.HasPrefix(phone, "+234"):
+		national = phone[4:]
+	case strings.HasPrefix(phone, "234"):
+		national = phone[3:]
+	case strings.HasPrefix(phone, "0"):
+		national = phone[1:]
+	}
+	if len(national) != 10 || national[0] < '7' || national[0] > '9' {
+		return "", errors.New("phone must be a valid Nigerian mobile number")
+	}
+	for _, r := range national {
+		if r < '0' || r > '9' {
+			return "", errors.New("phone must be a valid Nigerian mobile number")
+		}
+	}
+	return "+234" + national, nil
+}
+
+// PhoneVariants returns the forms a mobile number may have been stored in
+// before numbers were normalised, the normalised form first. A number that
+// cannot be normalised is returned on its own.
+func PhoneVariants(phone string) []string {
+	normalized, err := NormalizePhone(phone)
+	if err != nil {
+		return []string{phone}
+	}
+	national := normalized[4:]
+	return []string{normalized, "234" + national, "0" + national}
+}
@@ -0,0 +1,53 @@
+package sms
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		name    string
+		phone   string
+		want    string
+		wantErr bool
+	}{
+		{"local form", "08031234567", "+2348031234567", false},
+		{"international form", "+2348031234567", "+2348031234567", false},
+		{"without the plus", "2348031234567", "+2348031234567", false},
+		{"spaces and dashes", "0803 123-4567", "+2348031234567", false},
+		{"too short", "0803123456", "", true},
+		{"landline prefix", "01234567890", "", true},
+		{"letters", "0803123456a", "", true},
+		{"foreign number", "+447911123456", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizePhone(tt.phone)
+			if tt.wantErr {
+				assert.EqualError(t, err, "phone must be a valid Nigerian mobile number")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPhoneVariants(t *testing.T) {
+	tests := []struct {
+		name  string
+		phone string
+		want  []string
+	}{
+		{"normalised number", "+2348031234567", []string{"+2348031234567", "2348031234567", "08031234567"}},
+		{"local number", "08031234567", []string{"+2348031234567", "2348031234567", "08031234567"}},
+		{"invalid number", "12345", []string{"12345"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, PhoneVariants(tt.phone))
+		})
+	}
+}
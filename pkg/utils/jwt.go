@@ -1,48 +1,82 @@
 package utils
 
 import (
+	"errors"
 	"os"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt/v5"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// AccessTokenTTL is how long an access token is valid. Clients use their
+// refresh token to get a new one.
+const AccessTokenTTL = 15 * time.Minute
+
 type JWTConfig struct {
-	Email    string `json:"email"`
-	Username string `json:"username"`
-	UserID   string `json:"user_id"`
-	IsAdmin  bool   `json:"is_admin"`
-	jwt.StandardClaims
-	ExpiresAt int64 `json:"exp"`
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+	UserID    string `json:"user_id"`
+	SessionID string `json:"sid"`
+	jwt.RegisteredClaims
 }
 
-func GenerateToken(username, email string, userID primitive.ObjectID, isAdmin bool) (string, error) {
-	claims := JWTConfig{
-		Email:    email,
-		Username: username,
-		UserID:   userID.Hex(),
-		IsAdmin:  isAdmin,
-		ExpiresAt: time.Now().Add(time.Hour * 72).Unix(),
+// tokenIssuer is the iss claim of every token, which other services check
+// alongside the signature.
+func tokenIssuer() string {
+	if issuer := os.Getenv("JWT_ISSUER"); issuer != "" {
+		return issuer
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString([]byte(os.Getenv("JWT_SECRET"))) // Replace with your secret key
+	return "ajor_app"
+}
+
+func GenerateToken(username, email string, userID primitive.ObjectID, sessionID primitive.ObjectID) (string, error) {
+	now := time.Now()
+	key, err := activeSigningKey(now)
 	if err != nil {
 		return "", err
 	}
-	return signedToken, nil
+	claims := JWTConfig{
+		Email:     email,
+		Username:  username,
+		UserID:    userID.Hex(),
+		SessionID: sessionID.Hex(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer(),
+			Subject:   userID.Hex(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
+		},
+	}
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
 }
 
 func ValidateToken(tokenString string) (*JWTConfig, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTConfig{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("JWT_SECRET")), nil // Replace with your secret key
-	})
-	if err != nil || !token.Valid {
+	claims := &JWTConfig{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := lookupSigningKey(kid)
+		if !ok {
+			return nil, errors.New("unknown signing key")
+		}
+		// Each key is only good for the algorithm it was made for
+		if token.Method.Alg() != key.Algorithm {
+			return nil, errors.New("unexpected signing method")
+		}
+		return key.Public(), nil
+	},
+		jwt.WithValidMethods([]string{AlgorithmRS256, AlgorithmEdDSA}),
+		jwt.WithIssuer(tokenIssuer()),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
 		return nil, err
 	}
-	claims, ok := token.Claims.(*JWTConfig)
-	if !ok {
-		return nil, jwt.NewValidationError("invalid token claims", jwt.ValidationErrorClaimsInvalid)
+	if !token.Valid {
+		return nil, errors.New("invalid token")
 	}
 	return claims, nil
-}
+}
\ No newline at end of file
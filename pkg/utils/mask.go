@@ -0,0 +1,29 @@
+package utils
+
+import "strings"
+
+// MaskPhone hides all but the country code and last four digits of a
+// phone number, e.g. "+234*******5678".
+func MaskPhone(phone string) string {
+	if strings.HasPrefix(phone, "+234") {
+		return "+234" + maskTail(phone[4:], 4)
+	}
+	return maskTail(phone, 4)
+}
+
+// MaskBVN hides all but the last four digits of a BVN.
+func MaskBVN(bvn string) string {
+	return maskTail(bvn, 4)
+}
+
+// maskTail replaces every character but the last visible ones with '*'.
+// Values too short to keep anything are masked completely.
+func maskTail(value string, visible int) string {
+	if value == "" {
+		return ""
+	}
+	if len(value) <= visible {
+		return strings.Repeat("*", len(value))
+	}
+	return strings.Repeat("*", len(value)-visible) + value[len(value)-visible:]
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Signing algorithms tokens may use. Anything else is rejected, whatever
+// the token header says.
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// SigningKey is a key access tokens are signed and verified with. Tokens
+// carry its ID in the kid header so verifiers can pick the right key.
+type SigningKey struct {
+	ID          string
+	Algorithm   string
+	Private     crypto.Signer
+	ActivatesAt time.Time
+}
+
+// Public returns the key verifiers check signatures against.
+func (k SigningKey) Public() crypto.PublicKey {
+	return k.Private.Public()
+}
+
+var (
+	signingKeysMu sync.RWMutex
+	signingKeys   = map[string]SigningKey{}
+)
+
+// SetSigningKeys replaces the keys tokens are signed and verified with.
+// Every key verifies; the most recently activated one signs.
+func SetSigningKeys(keys []SigningKey) {
+	byID := make(map[string]SigningKey, len(keys))
+	for _, key := range keys {
+		byID[key.ID] = key
+	}
+	signingKeysMu.Lock()
+	signingKeys = byID
+	signingKeysMu.Unlock()
+}
+
+// activeSigningKey returns the key new tokens are signed with. Keys with a
+// future activation time are already published so that every verifier
+// knows them before the first token signed with them turns up.
+func activeSigningKey(now time.Time) (SigningKey, error) {
+	signingKeysMu.RLock()
+	defer signingKeysMu.RUnlock()
+	var active SigningKey
+	found := false
+	for _, key := range signingKeys {
+		if key.ActivatesAt.After(now) {
+			continue
+		}
+		if !found || key.ActivatesAt.After(active.ActivatesAt) {
+			active, found = key, true
+		}
+	}
+	if !found {
+		return SigningKey{}, errors.New("no active signing key")
+	}
+	return active, nil
+}
+
+func lookupSigningKey(id string) (SigningKey, bool) {
+	signingKeysMu.RLock()
+	defer signingKeysMu.RUnlock()
+	key, ok := signingKeys[id]
+	return key, ok
+}
+
+// GenerateSigningKey creates a new private key for the algorithm.
+func GenerateSigningKey(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case AlgorithmRS256:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case AlgorithmEdDSA:
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		return private, err
+	default:
+		return nil, errors.New("unsupported signing algorithm: " + algorithm)
+	}
+}
+
+// JWK is the public half of a signing key in RFC 7517 form.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+// JWKSet is the document served at /.well-known/jwks.json.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of every current signing key, including
+// ones not yet active.
+func JWKS() JWKSet {
+	signingKeysMu.RLock()
+	defer signingKeysMu.RUnlock()
+	keys := make([]SigningKey, 0, len(signingKeys))
+	for _, key := range signingKeys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ActivatesAt.After(keys[j].ActivatesAt) })
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range keys {
+		jwk := JWK{Use: "sig", Algorithm: key.Algorithm, KeyID: key.ID}
+		switch public := key.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}